@@ -0,0 +1,272 @@
+//nolint:forbidigo // fmt.Println() is allowed in the commands.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/KEINOS/go-hostpital/hostpital"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Exit statuses of the lint command.
+const (
+	LintExitClean   = 0 // No issues of SeverityError found.
+	LintExitIssues  = 1 // Issues of SeverityError found.
+	LintExitFailure = 2 // Failed to lint. Such as unknown flags and unreadable files.
+)
+
+// Output formats of the lint command.
+const (
+	LintFormatHuman  = "human"
+	LintFormatJSON   = "json"
+	LintFormatSARIF  = "sarif"
+	LintFormatGitHub = "github"
+)
+
+// NameCmdLint is the name of the lint command. Such as "hostpital lint".
+const NameCmdLint = "lint"
+
+// formatsLint is the list of the output formats of the lint command.
+//
+//nolint:gochecknoglobals // read-only table
+var formatsLint = []string{LintFormatHuman, LintFormatJSON, LintFormatSARIF, LintFormatGitHub}
+
+// ----------------------------------------------------------------------------
+//  Type: LintFlags
+// ----------------------------------------------------------------------------
+
+// LintFlags holds the parsed flags of the lint command and the Validator to
+// lint the files.
+type LintFlags struct {
+	Args       []string
+	Severities []string // Severities of the rules. Such as "HP003=warning".
+	Sinkholes  []string // Addresses acceptable as sinkholes for --sinkhole.
+	Format     string
+	Mode       string
+	Pattern    string
+	PSLFile    string // Path of the Public Suffix List to use instead of the embedded one.
+	FlagSet    *pflag.FlagSet
+	Validator  *hostpital.Validator
+	Conflicts  bool
+	ShowHelp   bool
+	Sinkhole   bool
+}
+
+// ParseLintFlags returns the LintFlags of the arguments after "lint".
+func ParseLintFlags(args []string) (*LintFlags, error) {
+	flags := new(LintFlags)
+
+	flags.FlagSet = pflag.NewFlagSet(NameExec()+" "+NameCmdLint, pflag.ContinueOnError)
+	flags.Validator = hostpital.NewValidator()
+	flags.Validator.AllowComment = true
+
+	flags.FlagSet.BoolVar(&flags.Conflicts, "conflicts", flags.Conflicts,
+		"report the host names mapped to an address other than the first one across the lines and the files (HP050)")
+	flags.FlagSet.StringVarP(&flags.Format, "format", "f", LintFormatHuman,
+		"output format. 'human', 'json', 'sarif' (SARIF 2.1.0) or 'github' (workflow annotations)")
+	flags.FlagSet.BoolVarP(&flags.ShowHelp, "help", "h", flags.ShowHelp, "show this message")
+	flags.FlagSet.StringVar(&flags.Mode, "mode", flags.Validator.Mode.String(),
+		"semantics of the lines. 'domain-list' or 'hosts-file' (hosts(5) with the address column)")
+	flags.FlagSet.StringVar(&flags.Pattern, "pattern", "hosts*",
+		"file name pattern to search for in the given directories")
+	flags.FlagSet.StringVar(&flags.PSLFile, "psl-file", flags.PSLFile,
+		"load the Public Suffix List from the file instead of the embedded snapshot (HP070). e.g. '/usr/share/publicsuffix/public_suffix_list.dat'")
+	flags.FlagSet.StringArrayVar(&flags.Severities, "severity", nil,
+		"set severity of a rule by ID or name. e.g. 'HP003=warning', 'ip-only=off'. Repeatable")
+	flags.FlagSet.BoolVar(&flags.Sinkhole, "sinkhole", flags.Sinkhole,
+		"report the entries pointing to the addresses other than --sinkhole-ip (HP040)")
+	flags.FlagSet.StringSliceVar(&flags.Sinkholes, "sinkhole-ip", hostpital.DefaultSinkholeAddresses,
+		"addresses or ranges in CIDR acceptable as sinkholes for --sinkhole")
+
+	err := flags.FlagSet.Parse(args)
+
+	if err == nil && !slices.Contains(formatsLint, flags.Format) {
+		err = errors.Errorf("unknown format %#v. It must be one of: %s",
+			flags.Format, strings.Join(formatsLint, ", "))
+	}
+
+	if err == nil {
+		flags.Validator.Mode, err = hostpital.ParseMode(flags.Mode)
+	}
+
+	if flags.Conflicts {
+		flags.Validator.Conflicts = hostpital.NewConflictAnalyzer()
+	}
+
+	if err == nil && flags.Sinkhole {
+		flags.Validator.Sinkhole, err = hostpital.NewSinkholePolicy(flags.Sinkholes...)
+	}
+
+	if err == nil && flags.PSLFile != "" {
+		flags.Validator.PublicSuffixes, err = hostpital.LoadPublicSuffixList(flags.PSLFile)
+	}
+
+	for _, setting := range flags.Severities {
+		if err == nil {
+			err = flags.setSeverity(setting)
+		}
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the flags")
+	}
+
+	flags.Args = flags.FlagSet.Args()
+
+	return flags, nil
+}
+
+// Lint runs the lint command with the arguments after "lint" and writes the
+// issues found to output. The errors are printed to STDERR. It returns the exit
+// status. Such as LintExitIssues if any issue of SeverityError is found.
+func Lint(args []string, output io.Writer) int {
+	flags, err := ParseLintFlags(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+
+		return LintExitFailure
+	}
+
+	if flags.ShowHelp {
+		flags.showHelp(os.Stdout, "")
+
+		return LintExitClean
+	}
+
+	if len(flags.Args) == 0 {
+		flags.showHelp(os.Stderr, "Error: No file or directory path(s) given")
+
+		return LintExitFailure
+	}
+
+	listFiles, err := flags.listFiles()
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+
+		return LintExitFailure
+	}
+
+	reports := make([]hostpital.Report, 0, len(listFiles))
+	status := LintExitClean
+
+	for _, pathFile := range listFiles {
+		report := flags.Validator.ValidateFileReport(pathFile)
+		report.Source = pathFile
+
+		if report.Err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s: %v\n", pathFile, report.Err)
+
+			status = LintExitFailure
+		} else if !report.OK() && status == LintExitClean {
+			status = LintExitIssues
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err := flags.writeReports(output, reports); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+
+		return LintExitFailure
+	}
+
+	return status
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// listFiles returns the files to lint. The directories in Args are searched
+// for the files matching Pattern.
+func (f *LintFlags) listFiles() ([]string, error) {
+	listFiles := []string{}
+
+	for _, pathArg := range f.Args {
+		info, err := os.Stat(pathArg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to lint the path")
+		}
+
+		if !info.IsDir() {
+			listFiles = append(listFiles, pathArg)
+
+			continue
+		}
+
+		found, err := hostpital.FindFile(f.Pattern, pathArg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "no files matching %#v in %#v", f.Pattern, pathArg)
+		}
+
+		listFiles = append(listFiles, found...)
+	}
+
+	return listFiles, nil
+}
+
+// ruleNames returns the names of the rules of the Validator by ID.
+func (f *LintFlags) ruleNames() map[string]string {
+	names := map[string]string{}
+
+	for _, rule := range f.Validator.Rules() {
+		names[rule.ID()] = rule.Name()
+	}
+
+	return names
+}
+
+// setSeverity sets the severity of the rule in the form of "rule=severity".
+func (f *LintFlags) setSeverity(setting string) error {
+	rule, name, ok := strings.Cut(setting, "=")
+	if !ok {
+		return errors.Errorf("malformed severity %#v. It must be in the form of 'rule=severity'", setting)
+	}
+
+	severity, err := hostpital.ParseSeverity(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to set the severity")
+	}
+
+	return errors.Wrap(f.Validator.SetSeverity(strings.TrimSpace(rule), severity), "failed to set the severity")
+}
+
+func (f *LintFlags) showHelp(output *os.File, msg string) {
+	f.FlagSet.SetOutput(output)
+
+	_, _ = fmt.Fprintln(output, NameExec()+" "+NameCmdLint+" - Validate hosts file(s) and report the issues.")
+	_, _ = fmt.Fprintln(output, "Usage:")
+	_, _ = fmt.Fprintf(output, "  %s %s [options] <file or directory path> [<path(s)> ...]\n", NameExec(), NameCmdLint)
+	_, _ = fmt.Fprintln(output, "Exit status:")
+	_, _ = fmt.Fprintln(output, "  0 if no errors, 1 if errors found and 2 if failed to lint.")
+	_, _ = fmt.Fprintln(output, "Options:")
+
+	f.FlagSet.PrintDefaults()
+
+	if msg != "" {
+		_, _ = fmt.Fprintln(output, "\n"+msg)
+	}
+}
+
+// writeReports writes the issues of the reports to output in the Format.
+func (f *LintFlags) writeReports(output io.Writer, reports []hostpital.Report) error {
+	var err error
+
+	switch f.Format {
+	case LintFormatJSON:
+		err = writeLintJSON(output, reports, f.ruleNames())
+	case LintFormatSARIF:
+		err = writeLintSARIF(output, reports, f.Validator)
+	case LintFormatGitHub:
+		err = writeLintGitHub(output, reports, f.ruleNames())
+	default:
+		err = writeLintHuman(output, reports)
+	}
+
+	return errors.Wrap(err, "failed to write the lint results")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/KEINOS/go-hostpital/hostpital"
+	"github.com/pkg/errors"
+)
+
+// URISARIFSchema is the JSON schema of SARIF 2.1.0 for the "$schema" property.
+const URISARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// URIInformation is the URI of the project for the tool information of SARIF.
+const URIInformation = "https://github.com/KEINOS/go-hostpital"
+
+// ----------------------------------------------------------------------------
+//  Type: lintIssue
+// ----------------------------------------------------------------------------
+
+// lintIssue is an issue of the "json" format.
+type lintIssue struct {
+	File     string `json:"file"`
+	RuleID   string `json:"rule_id"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Snippet  string `json:"snippet"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// ----------------------------------------------------------------------------
+//  Types of SARIF 2.1.0
+// ----------------------------------------------------------------------------
+
+// sarifLog is the root object of SARIF. Only the properties used are defined.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	Message   sarifMessage    `json:"message"`
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// ----------------------------------------------------------------------------
+//  Functions
+// ----------------------------------------------------------------------------
+
+// escapeGitHub escapes the value of the workflow commands of GitHub Actions. If
+// isProperty is true, it escapes the value as a property such as "file=".
+func escapeGitHub(value string, isProperty bool) string {
+	pairs := []string{"%", "%25", "\r", "%0D", "\n", "%0A"}
+
+	if isProperty {
+		pairs = append(pairs, ":", "%3A", ",", "%2C")
+	}
+
+	return strings.NewReplacer(pairs...).Replace(value)
+}
+
+// levelGitHub returns the command of the workflow annotation of the severity.
+func levelGitHub(severity hostpital.Severity) string {
+	switch severity {
+	case hostpital.SeverityError:
+		return "error"
+	case hostpital.SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// levelSARIF returns the level of SARIF of the severity.
+func levelSARIF(severity hostpital.Severity) string {
+	switch severity {
+	case hostpital.SeverityError:
+		return "error"
+	case hostpital.SeverityWarning:
+		return "warning"
+	case hostpital.SeverityInfo:
+		return "note"
+	default:
+		return "none"
+	}
+}
+
+// writeLintGitHub writes the issues as the workflow annotations of GitHub
+// Actions. Such as "::error file=hosts,line=1,col=1,title=HP002 indent::...".
+func writeLintGitHub(output io.Writer, reports []hostpital.Report, names map[string]string) error {
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			title := strings.TrimSpace(issue.RuleID + " " + names[issue.RuleID])
+
+			_, err := fmt.Fprintf(output, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+				levelGitHub(issue.Severity),
+				escapeGitHub(report.Source, true),
+				issue.Line,
+				issue.Column,
+				escapeGitHub(title, true),
+				escapeGitHub(issue.Message, false),
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to write the annotation")
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeLintHuman writes the issues in the form of "file:line:column: severity
+// [rule] message" followed by the summary.
+func writeLintHuman(output io.Writer, reports []hostpital.Report) error {
+	count := map[hostpital.Severity]int{}
+	builder := new(strings.Builder)
+
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			count[issue.Severity]++
+
+			fmt.Fprintf(builder, "%s:%d:%d: %s [%s] %s\n",
+				report.Source, issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+		}
+	}
+
+	fmt.Fprintf(builder, "%d error(s), %d warning(s) and %d info(s) in %d file(s)\n",
+		count[hostpital.SeverityError], count[hostpital.SeverityWarning], count[hostpital.SeverityInfo], len(reports))
+
+	_, err := io.WriteString(output, builder.String())
+
+	return errors.Wrap(err, "failed to write the issues")
+}
+
+// writeLintJSON writes the issues as a JSON array of the issues.
+func writeLintJSON(output io.Writer, reports []hostpital.Report, names map[string]string) error {
+	issues := []lintIssue{}
+
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			issues = append(issues, lintIssue{
+				File:     report.Source,
+				RuleID:   issue.RuleID,
+				Rule:     names[issue.RuleID],
+				Severity: issue.Severity.String(),
+				Message:  issue.Message,
+				Snippet:  issue.Snippet,
+				Line:     issue.Line,
+				Column:   issue.Column,
+			})
+		}
+	}
+
+	return writeJSON(output, issues)
+}
+
+// writeLintSARIF writes the issues as a SARIF 2.1.0 log for the code scanning.
+// All the rules of the validator are listed with the severities in effect.
+func writeLintSARIF(output io.Writer, reports []hostpital.Report, validator *hostpital.Validator) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           NameAppDefault,
+			InformationURI: URIInformation,
+			Version:        version,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, rule := range validator.Rules() {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			DefaultConfiguration: sarifConfiguration{Level: levelSARIF(validator.Severity(rule.ID()))},
+			ShortDescription:     sarifMessage{Text: rule.Name()},
+			ID:                   rule.ID(),
+			Name:                 rule.Name(),
+		})
+	}
+
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(report.Source)},
+				Region:           sarifRegion{StartLine: issue.Line, StartColumn: issue.Column},
+			}}
+
+			run.Results = append(run.Results, sarifResult{
+				Message:   sarifMessage{Text: issue.Message},
+				RuleID:    issue.RuleID,
+				Level:     levelSARIF(issue.Severity),
+				Locations: []sarifLocation{location},
+			})
+		}
+	}
+
+	return writeJSON(output, sarifLog{
+		Schema:  URISARIFSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// writeJSON writes the value as an indented JSON.
+func writeJSON(output io.Writer, value any) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return errors.Wrap(encoder.Encode(value), "failed to encode to JSON")
+}
@@ -0,0 +1,366 @@
+//nolint:paralleltest // do not parallelize due to capturing STDOUT and STDERR
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenizh/go-capturer"
+)
+
+// ============================================================================
+//  Golden Cases
+// ============================================================================
+
+func TestLint_golden_human(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "# comment\n  0.0.0.0 foo_bar.example.com\n127.0.0.1\n")
+
+	var output bytes.Buffer
+
+	status := Lint([]string{pathFile}, &output)
+
+	require.Equal(t, LintExitIssues, status, "it should exit with 1 if errors are found")
+	assert.Equal(t, heredoc.Doc(`
+		%[1]s:2:1: error [HP002] indent is not allowed
+		%[1]s:2:11: error [HP003] label "foo_bar": underscore outside service label
+		%[1]s:3:1: error [HP005] IP address only line is not allowed
+		3 error(s), 0 warning(s) and 0 info(s) in 1 file(s)
+	`), strings.ReplaceAll(output.String(), pathFile, "%[1]s"))
+}
+
+func TestLint_golden_json(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "0.0.0.0 foo_bar.example.com\n")
+
+	var output bytes.Buffer
+
+	status := Lint([]string{"--format", "json", "--severity", "underscore=warning", pathFile}, &output)
+
+	require.Equal(t, LintExitClean, status, "warnings should not fail the lint")
+
+	issues := []lintIssue{}
+
+	require.NoError(t, json.Unmarshal(output.Bytes(), &issues))
+	assert.Equal(t, []lintIssue{{
+		File:     pathFile,
+		RuleID:   "HP003",
+		Rule:     "underscore",
+		Severity: "warning",
+		Message:  `label "foo_bar": underscore outside service label`,
+		Snippet:  "0.0.0.0 foo_bar.example.com",
+		Line:     1,
+		Column:   9,
+	}}, issues)
+}
+
+func TestLint_golden_sarif(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "0.0.0.0 foo_bar.example.com\n127.0.0.1\n")
+
+	var output bytes.Buffer
+
+	status := Lint([]string{"-f", "sarif", "--severity", "HP003=info", pathFile}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+
+	var log sarifLog
+
+	require.NoError(t, json.Unmarshal(output.Bytes(), &log))
+	assert.Equal(t, URISARIFSchema, log.Schema)
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+
+	assert.Equal(t, NameAppDefault, run.Tool.Driver.Name)
+	assert.Contains(t, run.Tool.Driver.Rules, sarifRule{
+		DefaultConfiguration: sarifConfiguration{Level: "note"},
+		ShortDescription:     sarifMessage{Text: "underscore"},
+		ID:                   "HP003",
+		Name:                 "underscore",
+	})
+	assert.Contains(t, run.Tool.Driver.Rules, sarifRule{
+		DefaultConfiguration: sarifConfiguration{Level: "none"},
+		ShortDescription:     sarifMessage{Text: "empty-line"},
+		ID:                   "HP004",
+		Name:                 "empty-line",
+	}, "rules turned off should be listed with the level 'none'")
+
+	require.Len(t, run.Results, 2)
+	assert.Equal(t, "note", run.Results[0].Level)
+	assert.Equal(t, "HP003", run.Results[0].RuleID)
+	assert.Equal(t, "error", run.Results[1].Level)
+	assert.Equal(t, "IP address only line is not allowed", run.Results[1].Message.Text)
+	assert.Equal(t, sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(pathFile)},
+		Region:           sarifRegion{StartLine: 2, StartColumn: 1},
+	}, run.Results[1].Locations[0].PhysicalLocation)
+}
+
+func TestLint_golden_github(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts,1", "0.0.0.0 foo_bar.example.jp example.com\n  0.0.0.0 ab_c.example.jp\n")
+
+	var output bytes.Buffer
+
+	status := Lint([]string{
+		"--format=github",
+		"--mode", "hosts-file",
+		"--severity", "HP003=warning",
+		"--severity", "special-use=info",
+		pathFile,
+	}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+
+	pathEscaped := strings.ReplaceAll(pathFile, ",", "%2C")
+
+	assert.Equal(t, heredoc.Doc(`
+		::warning file=%[1]s,line=1,col=9,title=HP003 underscore::label "foo_bar": underscore outside service label
+		::notice file=%[1]s,line=1,col=28,title=HP030 special-use::"example.com" is a special-use domain name (example)
+		::error file=%[1]s,line=2,col=1,title=HP002 indent::indent is not allowed
+		::warning file=%[1]s,line=2,col=11,title=HP003 underscore::label "ab_c": underscore outside service label
+	`), strings.ReplaceAll(output.String(), pathEscaped, "%[1]s"))
+}
+
+func TestLint_golden_sinkhole(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "0.0.0.0 ads.example.com\n203.0.113.7 login.example.com\n")
+
+	var output bytes.Buffer
+
+	require.Equal(t, LintExitClean, Lint([]string{pathFile}, &output), "it should be off by default")
+
+	output.Reset()
+
+	status := Lint([]string{"--sinkhole", pathFile}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+	assert.Equal(t, heredoc.Doc(`
+		%[1]s:2:1: error [HP040] "203.0.113.7" is not an allowed sinkhole address
+		1 error(s), 0 warning(s) and 0 info(s) in 1 file(s)
+	`), strings.ReplaceAll(output.String(), pathFile, "%[1]s"))
+
+	output.Reset()
+
+	status = Lint([]string{"--sinkhole", "--sinkhole-ip", "0.0.0.0,203.0.113.0/24", pathFile}, &output)
+
+	require.Equal(t, LintExitClean, status, "the given sinkholes should be allowed")
+}
+
+func TestLint_golden_conflicts(t *testing.T) {
+	pathFirst := writeTempHosts(t, "hosts", "127.0.0.1 localhost\n10.0.0.1 db.example.com\n")
+	pathSecond := writeTempHosts(t, "hosts.local", "::1 localhost\n10.0.0.1 db.example.com\n10.0.0.2 db.example.com\n")
+
+	var output bytes.Buffer
+
+	require.Equal(t, LintExitClean, Lint([]string{pathFirst, pathSecond}, &output))
+	assert.NotContains(t, output.String(), "HP050", "it should be off by default")
+
+	output.Reset()
+
+	status := Lint([]string{"--conflicts", "--severity", "conflicting-address=error", pathFirst, pathSecond}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+	assert.Equal(t, heredoc.Doc(`
+		%[2]s:3:10: error [HP050] "db.example.com" points to "10.0.0.2" but "10.0.0.1" at %[1]s:2 wins
+		1 error(s), 0 warning(s) and 0 info(s) in 2 file(s)
+	`), strings.NewReplacer(pathFirst, "%[1]s", pathSecond, "%[2]s").Replace(output.String()))
+}
+
+func TestLint_golden_confusable(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "0.0.0.0 www.xn--80ak6aa92e.com\n0.0.0.0 login.pаypal.com\n")
+
+	var output bytes.Buffer
+
+	require.Equal(t, LintExitClean, Lint([]string{pathFile}, &output), "the homographs should be warnings by default")
+	assert.Equal(t, heredoc.Doc(`
+		%[1]s:1:9: warning [HP060] "www.xn--80ak6aa92e.com" ("www.аррӏе.com") imitates "www.apple.com"
+		%[1]s:2:9: warning [HP060] "login.pаypal.com" imitates "login.paypal.com"
+		%[1]s:2:15: warning [HP061] label "pаypal" mixes the scripts Cyrillic, Latin
+		0 error(s), 3 warning(s) and 0 info(s) in 1 file(s)
+	`), strings.ReplaceAll(output.String(), pathFile, "%[1]s"))
+
+	output.Reset()
+
+	status := Lint([]string{"--severity", "confusable=error", "--severity", "mixed-script=off", pathFile}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+	assert.Contains(t, output.String(), "2 error(s), 0 warning(s)")
+}
+
+func TestLint_golden_public_suffix(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "0.0.0.0 ads.example.co.uk\n0.0.0.0 co.uk\n0.0.0.0 example.test\n")
+
+	var output bytes.Buffer
+
+	require.Equal(t, LintExitClean, Lint([]string{"--mode", "hosts-file", pathFile}, &output),
+		"the public suffixes should be warnings by default")
+	assert.Equal(t, heredoc.Doc(`
+		%[1]s:2:9: warning [HP070] "co.uk" is a public suffix (ICANN)
+		0 error(s), 1 warning(s) and 0 info(s) in 1 file(s)
+	`), strings.ReplaceAll(output.String(), pathFile, "%[1]s"))
+
+	output.Reset()
+
+	status := Lint([]string{"--mode", "hosts-file", "--severity", "public-suffix=error", pathFile}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+	assert.Contains(t, output.String(), "1 error(s), 0 warning(s)")
+
+	// Public Suffix List of the newer copy
+	pathPSL := writeTempHosts(t, "public_suffix_list.dat", "// ===BEGIN ICANN DOMAINS===\ntest\n")
+
+	output.Reset()
+
+	status = Lint([]string{"--mode", "hosts-file", "--psl-file", pathPSL, pathFile}, &output)
+
+	require.Equal(t, LintExitClean, status)
+	assert.Equal(t, "0 error(s), 0 warning(s) and 0 info(s) in 1 file(s)\n", output.String(),
+		"co.uk should not be listed in the given list")
+}
+
+func TestLint_golden_directory(t *testing.T) {
+	pathDir := t.TempDir()
+
+	writeTempFile(t, filepath.Join(pathDir, "hosts"), "example.com\n")
+	writeTempFile(t, filepath.Join(pathDir, "sub", "hosts.txt"), "example.net\n")
+	writeTempFile(t, filepath.Join(pathDir, "blocklist.txt"), "127.0.0.1\n")
+
+	var output bytes.Buffer
+
+	status := Lint([]string{pathDir}, &output)
+
+	require.Equal(t, LintExitClean, status, "files not matching the pattern should be skipped")
+	assert.Equal(t, "0 error(s), 0 warning(s) and 0 info(s) in 2 file(s)\n", output.String())
+
+	output.Reset()
+
+	status = Lint([]string{"--pattern", "*.txt", pathDir}, &output)
+
+	require.Equal(t, LintExitIssues, status)
+	assert.Contains(t, output.String(), "blocklist.txt:1:1: error [HP005]")
+	assert.Contains(t, output.String(), "in 2 file(s)")
+}
+
+func TestLint_show_help(t *testing.T) {
+	status := 0
+
+	out := capturer.CaptureStdout(func() {
+		status = Lint([]string{"--help"}, new(bytes.Buffer))
+	})
+
+	require.Equal(t, LintExitClean, status)
+	assert.Contains(t, out, "lint - Validate hosts file(s) and report the issues.")
+	assert.Contains(t, out, "Exit status:")
+	assert.Contains(t, out, "--format")
+}
+
+func Test_main_lint(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	pathFile := writeTempHosts(t, "hosts", "127.0.0.1\n")
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "lint", pathFile}
+
+	capturedCode := -1 // captured exit code
+
+	// Mock osExit
+	osExit = func(code int) {
+		capturedCode = code
+	}
+
+	out := capturer.CaptureStdout(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	require.Equal(t, LintExitIssues, capturedCode, "it should exit with the status of the lint command")
+	assert.Contains(t, out, "HP005")
+}
+
+// ============================================================================
+//  Error Cases
+// ============================================================================
+
+func TestLint_failures(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "example.com\n")
+	pathLong := writeTempHosts(t, "hosts.long", strings.Repeat("a", 1024*1024+1)+"\n")
+
+	for index, test := range []struct {
+		expect string
+		args   []string
+	}{
+		{args: []string{"--unknown", pathFile}, expect: "unknown flag: --unknown"},
+		{args: []string{"--format", "xml", pathFile}, expect: `unknown format "xml"`},
+		{args: []string{"--mode", "unknown", pathFile}, expect: `unknown mode "unknown"`},
+		{args: []string{"--severity", "HP003", pathFile}, expect: `malformed severity "HP003"`},
+		{args: []string{"--severity", "HP003=fatal", pathFile}, expect: `unknown severity "fatal"`},
+		{args: []string{"--severity", "HP999=off", pathFile}, expect: `unknown rule "HP999"`},
+		{args: []string{"--sinkhole", "--sinkhole-ip", "::/129", pathFile}, expect: `invalid sinkhole address "::/129"`},
+		{args: []string{"--psl-file", pathFile + ".missing", pathFile}, expect: "failed to open the file"},
+		{args: []string{}, expect: "No file or directory path(s) given"},
+		{args: []string{filepath.Join(t.TempDir(), "missing")}, expect: "failed to lint the path"},
+		{args: []string{"--pattern", "none*", t.TempDir()}, expect: `no files matching "none*"`},
+		{args: []string{pathLong}, expect: "failed to read from reader"},
+	} {
+		status := 0
+		output := new(bytes.Buffer)
+
+		errOut := capturer.CaptureStderr(func() {
+			status = Lint(test.args, output)
+		})
+
+		require.Equal(t, LintExitFailure, status, "test #%d should fail", index+1)
+		assert.Contains(t, errOut, test.expect, "test #%d failed", index+1)
+	}
+}
+
+func TestLint_write_error(t *testing.T) {
+	pathFile := writeTempHosts(t, "hosts", "127.0.0.1\n")
+
+	for _, format := range formatsLint {
+		status := 0
+
+		errOut := capturer.CaptureStderr(func() {
+			status = Lint([]string{"--format", format, pathFile}, new(DummyFile))
+		})
+
+		require.Equal(t, LintExitFailure, status, "format %s should fail to write", format)
+		assert.Contains(t, errOut, "failed to write the lint results", "format %s", format)
+		assert.Contains(t, errOut, "dummy error to write", "format %s", format)
+	}
+}
+
+func Test_escapeGitHub(t *testing.T) {
+	assert.Equal(t, "a%25b%0D%0Ac:d,e", escapeGitHub("a%b\r\nc:d,e", false))
+	assert.Equal(t, "a%25b%0D%0Ac%3Ad%2Ce", escapeGitHub("a%b\r\nc:d,e", true))
+}
+
+// ============================================================================
+//  Helper Functions for Testing
+// ============================================================================
+
+// writeTempFile writes the content to pathFile creating the directories.
+func writeTempFile(t *testing.T, pathFile, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(pathFile), 0o700))
+	require.NoError(t, os.WriteFile(pathFile, []byte(content), 0o600))
+}
+
+// writeTempHosts writes the content to the named file in a temporary directory
+// and returns its path.
+func writeTempHosts(t *testing.T, name, content string) string {
+	t.Helper()
+
+	pathFile := filepath.Join(t.TempDir(), name)
+
+	writeTempFile(t, pathFile, content)
+
+	return pathFile
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -21,10 +22,17 @@ import (
 // the host file.
 type Flags struct {
 	Args       []string
+	Sinkholes  []string // Addresses acceptable as sinkholes for --drop-hijack.
+	Dedupe     string
+	LongLine   string
 	PathIntput string
 	PathOutput string
+	PSLFile    string // Path of the Public Suffix List to use instead of the embedded one.
 	FlagSet    *pflag.FlagSet
 	Parser     *hostpital.Parser
+	Diff       bool
+	DropHijack bool
+	Fix        bool
 	ShowHelp   bool
 	ShowVerion bool
 }
@@ -36,6 +44,13 @@ type IOFile interface {
 	Read(b []byte) (n int, err error)
 }
 
+// mergedSource is a file merged into the temporary file by MergeFiles and the
+// number of lines it has. See sourceOf.
+type mergedSource struct {
+	path     string
+	numLines int
+}
+
 // NameAppDefault is the name of the application for fallback. Usually the name
 // is taken from the executable name.
 const NameAppDefault = "hostpital"
@@ -53,6 +68,8 @@ var (
 	osExecutable = os.Executable
 	// osCreateTemp is a copy of os.CreateTemp to ease testing.
 	osCreateTemp = os.CreateTemp
+	// osStdin is the input to read from if "-" is given as the file path.
+	osStdin io.Reader = os.Stdin
 )
 
 // ----------------------------------------------------------------------------
@@ -60,6 +77,12 @@ var (
 // ----------------------------------------------------------------------------
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == NameCmdLint {
+		osExit(Lint(os.Args[2:], os.Stdout))
+
+		return
+	}
+
 	flags, err := ParseFlags()
 	ExitOnError(err)
 
@@ -89,12 +112,26 @@ func main() {
 		ExitOnError(err)
 	}
 
-	pathTmp, cleanup, err := MergeFiles(listFiles)
-	ExitOnError(err)
+	if flags.Fix || flags.Diff {
+		ExitOnError(flags.FixFiles(listFiles))
 
-	defer func() {
-		ExitOnError(cleanup())
-	}()
+		return
+	}
+
+	var sources []mergedSource
+
+	pathTmp := ""
+
+	if !flags.IsStdin() {
+		var cleanup func() error
+
+		pathTmp, sources, cleanup, err = mergeFiles(listFiles)
+		ExitOnError(err)
+
+		defer func() {
+			ExitOnError(cleanup())
+		}()
+	}
 
 	outFile := os.Stdout
 
@@ -114,15 +151,49 @@ func main() {
 		}()
 	}
 
-	ExitOnError(flags.Parser.ParseFileTo(pathTmp, outFile))
+	if flags.IsStdin() {
+		ExitOnError(flags.Parser.ParseReader(osStdin, outFile))
+	} else {
+		ExitOnError(flags.Parser.ParseFileTo(pathTmp, outFile))
+	}
+
+	if flags.Parser.Deduplicate != hostpital.DedupeNone {
+		_, _ = fmt.Fprintln(os.Stderr, "Duplicates dropped:", flags.Parser.Stats().Duplicates)
+	}
+
+	if longLines := flags.Parser.Stats().LongLines; len(longLines) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Long lines (%s): %d\n", flags.Parser.LongLine, len(longLines))
+	}
+
+	if hijacks := flags.Parser.Stats().Hijacks; len(hijacks) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "Hijacking entries dropped:", len(hijacks))
+
+		for _, hijack := range hijacks {
+			pathFile, numLine := sourceOf(sources, hijack.Line)
+
+			_, _ = fmt.Fprintf(os.Stderr, "  %s:%d: %s %s\n",
+				pathFile, numLine, hijack.Address, strings.Join(hijack.Hostnames, " "))
+		}
+	}
+
+	if unconverted := flags.Parser.Stats().Unconverted; len(unconverted) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "Warning: host names left in punycode:", len(unconverted))
+
+		for _, failure := range unconverted {
+			_, _ = fmt.Fprintf(os.Stderr, "  %s (%v)\n", failure.Hostname, failure.Err)
+		}
+	}
 }
 
 // -----------------------------------------------------------------------------
 //  Functions (methods follows below)
 // -----------------------------------------------------------------------------
 
-func appendFileTo(inputFile string, outFile IOFile) error {
+// appendFileTo appends the content of inputFile to outFile and returns the
+// number of line breaks appended.
+func appendFileTo(inputFile string, outFile IOFile) (int, error) {
 	buf := make([]byte, bufio.MaxScanTokenSize)
+	numLines := 0
 
 	// Do not clean empty paths to preserve error message "no such file or directory"
 	if inputFile != "" {
@@ -131,7 +202,7 @@ func appendFileTo(inputFile string, outFile IOFile) error {
 
 	inFile, err := os.Open(inputFile)
 	if err != nil {
-		return errors.Wrap(err, "failed to open the file")
+		return 0, errors.Wrap(err, "failed to open the file")
 	}
 
 	defer func() {
@@ -144,15 +215,17 @@ func appendFileTo(inputFile string, outFile IOFile) error {
 		if err == nil {
 			_, err = outFile.Write(buf[:n])
 			if err == nil {
+				numLines += bytes.Count(buf[:n], []byte{byte(hostpital.LF)})
+
 				continue
 			}
 		}
 
 		if errors.Is(err, io.EOF) {
-			return nil
+			return numLines, nil
 		}
 
-		return errors.Wrap(err, "failed to write to the file")
+		return numLines, errors.Wrap(err, "failed to write to the file")
 	}
 }
 
@@ -188,9 +261,18 @@ func getVersion() string {
 // MergeFiles merges the given files into a temporary file and returns the path
 // to the temporary file and a function to remove the temporary file.
 func MergeFiles(paths []string) (string, func() error, error) {
+	pathFileTmp, _, cleanup, err := mergeFiles(paths)
+
+	return pathFileTmp, cleanup, err
+}
+
+// mergeFiles is the implementation of MergeFiles which also returns the files
+// merged with their number of lines to locate the lines of the temporary file
+// in them. See sourceOf.
+func mergeFiles(paths []string) (string, []mergedSource, func() error, error) {
 	outFile, err := osCreateTemp(os.TempDir(), "hostpital-*")
 	if err != nil {
-		return "", nil, errors.Wrap(err, "failed to create a temporary file")
+		return "", nil, nil, errors.Wrap(err, "failed to create a temporary file")
 	}
 
 	defer func() {
@@ -198,19 +280,22 @@ func MergeFiles(paths []string) (string, func() error, error) {
 	}()
 
 	pathFileTmp := outFile.Name()
+	sources := make([]mergedSource, 0, len(paths))
 
 	for _, pathFile := range paths {
-		err := appendFileTo(pathFile, outFile)
+		numLines, err := appendFileTo(pathFile, outFile)
 		if err != nil {
-			return "", nil, errors.Wrap(err, "failed to append the file")
+			return "", nil, nil, errors.Wrap(err, "failed to append the file")
 		}
+
+		sources = append(sources, mergedSource{path: pathFile, numLines: numLines})
 	}
 
 	cleanup := func() error {
 		return errors.Wrap(os.Remove(pathFileTmp), "failed to remove the temporary file")
 	}
 
-	return pathFileTmp, cleanup, nil
+	return pathFileTmp, sources, cleanup, nil
 }
 
 // NameExec returns the name of the executable.
@@ -243,11 +328,29 @@ func ParseFlags() (*Flags, error) {
 	flags.FlagSet = pflag.NewFlagSet(NameExec(), pflag.ContinueOnError)
 	flags.Parser = hostpital.NewParser()
 
+	flags.FlagSet.BoolVar(&flags.Parser.AnnotateIDN, "annotate-idn", flags.Parser.AnnotateIDN,
+		"append the other form of the internationalized host names as a comment. e.g. 'xn--gpher-jua.com # göpher.com'")
+	flags.FlagSet.StringVar(&flags.Dedupe, "dedupe", flags.Parser.Deduplicate.String(),
+		"remove duplicates. 'none', 'line' (same lines), 'host' (same host names) or 'host-ip' (same host names per IP)")
+	flags.FlagSet.BoolVar(&flags.Diff, "diff", flags.Diff,
+		"preview the changes of --fix as a unified diff without modifying the files")
 	flags.FlagSet.StringVarP(&flags.PathIntput, "dir", "d", flags.PathOutput,
 		"set directory path to search for hosts files")
+	flags.FlagSet.BoolVar(&flags.DropHijack, "drop-hijack", flags.DropHijack,
+		"drop the entries pointing to the addresses other than --sinkhole-ip. e.g. '203.0.113.7 login.example.com'")
+	flags.FlagSet.BoolVar(&flags.Parser.DropSpecialUse, "drop-special-use", flags.Parser.DropSpecialUse,
+		"drop special-use domain names such as 'localhost', '*.local' and '*.test'")
+	flags.FlagSet.BoolVar(&flags.Fix, "fix", flags.Fix,
+		"fix the hosts files in place instead of merging them. The issues left are printed to stderr")
 	flags.FlagSet.BoolVarP(&flags.ShowHelp, "help", "h", flags.ShowHelp, "show this message")
+	flags.FlagSet.StringVar(&flags.LongLine, "long-line", flags.Parser.LongLine.String(),
+		"policy for the lines longer than --max-line-length. 'fail', 'skip' or 'split' (split into lines repeating the IP)")
+	flags.FlagSet.IntVar(&flags.Parser.MaxLineLength, "max-line-length", flags.Parser.MaxLineLength,
+		"set maximum length of a line in bytes")
 	flags.FlagSet.StringVarP(&flags.PathOutput, "out", "o", flags.PathOutput,
 		"set output file path (default: stdout)")
+	flags.FlagSet.StringVar(&flags.PSLFile, "psl-file", flags.PSLFile,
+		"load the Public Suffix List from the file instead of the embedded snapshot for --sortdomain")
 	flags.FlagSet.BoolVarP(&flags.Parser.IDNACompatible, "punycode", "p", flags.Parser.IDNACompatible,
 		"convert unicode host names to ASCII/punycode")
 	flags.FlagSet.BoolVarP(&flags.Parser.TrimComment, "remove-comment", "c", flags.Parser.TrimComment,
@@ -260,10 +363,16 @@ func ParseFlags() (*Flags, error) {
 		"remove leading space(s) from the output")
 	flags.FlagSet.BoolVar(&flags.Parser.TrimTrailingSpace, "remove-space-tail", flags.Parser.TrimTrailingSpace,
 		"remove trailing space(s) from the output")
+	flags.FlagSet.StringSliceVar(&flags.Sinkholes, "sinkhole-ip", hostpital.DefaultSinkholeAddresses,
+		"addresses or ranges in CIDR acceptable as sinkholes for --drop-hijack")
 	flags.FlagSet.BoolVarP(&flags.Parser.SortAfterParse, "sorthost", "s", flags.Parser.SortAfterParse,
 		"sort the output by the host name")
 	flags.FlagSet.BoolVarP(&flags.Parser.SortAsReverseDNS, "sortlabel", "l", flags.Parser.SortAsReverseDNS,
 		"sort the output by the reversed labels of the DNS hosts. e.g. 'com.example.www'")
+	flags.FlagSet.BoolVar(&flags.Parser.SortByDomain, "sortdomain", flags.Parser.SortByDomain,
+		"sort the output by the registrable domain without the public suffix. e.g. 'example' of 'www.example.co.uk'")
+	flags.FlagSet.BoolVar(&flags.Parser.OutputUnicode, "unicode", flags.Parser.OutputUnicode,
+		"convert punycode host names to unicode. The ones failing to convert are left as is and printed to stderr")
 	flags.FlagSet.StringVarP(&flags.Parser.UseIPAddress, "use-ip", "i", flags.Parser.UseIPAddress,
 		"set IP address to be replaced (suitable for sinkhole)")
 	flags.FlagSet.BoolVarP(&flags.ShowVerion, "version", "v", flags.ShowVerion,
@@ -279,6 +388,22 @@ func ParseFlags() (*Flags, error) {
 		err = flags.FlagSet.Parse(os.Args[1:])
 	}
 
+	if err == nil {
+		flags.Parser.Deduplicate, err = hostpital.ParseDedupeMode(flags.Dedupe)
+	}
+
+	if err == nil {
+		flags.Parser.LongLine, err = hostpital.ParseLongLinePolicy(flags.LongLine)
+	}
+
+	if err == nil && flags.DropHijack {
+		flags.Parser.Sinkhole, err = hostpital.NewSinkholePolicy(flags.Sinkholes...)
+	}
+
+	if err == nil && flags.PSLFile != "" {
+		flags.Parser.PublicSuffixes, err = hostpital.LoadPublicSuffixList(flags.PSLFile)
+	}
+
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse the flags")
 	}
@@ -298,10 +423,83 @@ func ShowVerApp() {
 	osExit(0)
 }
 
+// sourceOf returns the path of the file and the line number in it of the line
+// in the temporary file merged from the sources. The path is "-" if no sources
+// are given as the lines are read from stdin.
+func sourceOf(sources []mergedSource, numLine int) (string, int) {
+	for index, source := range sources {
+		// The last line of a file without the trailing line break counts for
+		// the file.
+		if numLine <= source.numLines || index == len(sources)-1 {
+			return source.path, numLine
+		}
+
+		numLine -= source.numLines
+	}
+
+	return "-", numLine
+}
+
+// writeDiff writes the fixes as a unified diff without context lines.
+func writeDiff(output io.Writer, pathFile string, fixes []hostpital.Fix) {
+	if len(fixes) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(output, "--- %s\n+++ %s\n", pathFile, pathFile)
+
+	offset := 0 // Difference of the line numbers between the input and the output.
+
+	for _, fix := range fixes {
+		if fix.Removed {
+			// The range of no lines starts after the line before.
+			_, _ = fmt.Fprintf(output, "@@ -%d +%d,0 @@\n-%s\n", fix.Line, fix.Line+offset-1, fix.Before)
+			offset--
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(output, "@@ -%d +%d @@\n-%s\n+%s\n", fix.Line, fix.Line+offset, fix.Before, fix.After)
+	}
+}
+
 // -----------------------------------------------------------------------------
 //  Methods
 // -----------------------------------------------------------------------------
 
+// FixFiles applies the safe fixes of hostpital.Validator to the files in place.
+// The fixed input is printed to stdout if "-" is given as the file path. If
+// Diff is true, it prints the changes as a unified diff to stdout instead.
+//
+// The issues left, such as the unused suppression directives, are printed to
+// stderr.
+func (f *Flags) FixFiles(paths []string) error {
+	validator := hostpital.NewValidator()
+	validator.AllowComment = true
+
+	for _, pathFile := range paths {
+		report, err := f.fixFile(validator, pathFile)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range report.Issues {
+			_, _ = fmt.Fprintf(os.Stderr, "%s:%d:%d: %s [%s] %s\n",
+				pathFile, issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+		}
+
+		_, _ = fmt.Fprintf(os.Stderr, "Fixed lines (%s): %d\n", pathFile, len(report.Fixes))
+	}
+
+	return nil
+}
+
+// IsStdin returns true if the hosts file is to be read from stdin. Which is
+// when "-" is given as the only file path.
+func (f *Flags) IsStdin() bool {
+	return f.PathIntput == "" && len(f.Args) == 1 && f.Args[0] == "-"
+}
+
 // ShowHelpAndExitIfTrue shows help and the msg to STDERR if isTrue is true.
 // Then exits with status 1.
 func (f *Flags) ShowHelpAndExitIfTrue(isTrue bool, msg string) {
@@ -314,6 +512,48 @@ func (f *Flags) ShowHelpAndExitIfTrue(isTrue bool, msg string) {
 	osExit(1)
 }
 
+// fixFile applies the fixes to the file or stdin if pathFile is "-". See
+// FixFiles for the details.
+func (f *Flags) fixFile(validator *hostpital.Validator, pathFile string) (hostpital.Report, error) {
+	var (
+		input  io.Reader = osStdin
+		output bytes.Buffer
+		perm   os.FileMode
+	)
+
+	if pathFile != "-" {
+		info, err := os.Stat(pathFile)
+		if err != nil {
+			return hostpital.Report{}, errors.Wrap(err, "failed to fix the file")
+		}
+
+		content, err := os.ReadFile(filepath.Clean(pathFile))
+		if err != nil {
+			return hostpital.Report{}, errors.Wrap(err, "failed to fix the file")
+		}
+
+		input, perm = bytes.NewReader(content), info.Mode().Perm()
+	}
+
+	report, err := validator.Fix(input, &output)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to fix the file")
+	}
+
+	report.Source = pathFile
+
+	switch {
+	case f.Diff:
+		writeDiff(os.Stdout, pathFile, report.Fixes)
+	case pathFile == "-":
+		_, err = os.Stdout.Write(output.Bytes())
+	case len(report.Fixes) > 0:
+		err = os.WriteFile(pathFile, output.Bytes(), perm)
+	}
+
+	return report, errors.Wrap(err, "failed to write the fixed lines")
+}
+
 func (f *Flags) getExamples() string {
 	examples := heredoc.Doc(`
 		Examples:
@@ -325,6 +565,51 @@ func (f *Flags) getExamples() string {
 		  $ %%NAME_EXEC%% -s ./path/to/hosts ./path/to/hosts.txt ./path/to/another/file.txt
 		  $ %%NAME_EXEC%% --sorthost ./path/to/hosts ./path/to/hosts.txt ./path/to/another/file.txt
 
+		  $ # Merge multiple hosts files into one and group them by the registrable
+		  $ # domains regardless of the public suffixes. Such as "example.com" and
+		  $ # "example.co.uk".
+		  $ %%NAME_EXEC%% --sortdomain ./path/to/hosts ./path/to/hosts.txt
+
+		  $ # Merge multiple hosts files into one but keep only the first occurrence
+		  $ # of each host name. The number of duplicates dropped is printed to stderr.
+		  $ %%NAME_EXEC%% --dedupe host ./path/to/hosts ./path/to/hosts.txt
+
+		  $ # Split the lines longer than 4096 bytes into multiple lines instead of
+		  $ # failing. The number of long lines is printed to stderr.
+		  $ %%NAME_EXEC%% --long-line split --max-line-length 4096 ./path/to/hosts
+
+		  $ # Drop the entries pointing to the addresses other than the sinkholes
+		  $ # (0.0.0.0, 127.0.0.1, :: and ::1 by default) which hijack the host names.
+		  $ # Use "%%NAME_EXEC%% lint --sinkhole" to find their files and lines.
+		  $ %%NAME_EXEC%% --drop-hijack ./path/to/hosts
+		  $ %%NAME_EXEC%% --drop-hijack --sinkhole-ip 0.0.0.0,127.0.0.0/8 ./path/to/hosts
+
+		  $ # Drop the special-use domain names such as "localhost.localdomain" and
+		  $ # "printer.local" which break the blocklists.
+		  $ %%NAME_EXEC%% --drop-special-use ./path/to/hosts
+
+		  $ # Review the internationalized host names in unicode with the punycode
+		  $ # as a comment. Such as "göpher.com # xn--gpher-jua.com".
+		  $ %%NAME_EXEC%% --unicode --annotate-idn ./path/to/hosts
+
+		  $ # Fix the hosts files in place. Such as the indents, upper cases and
+		  $ # invalid host names. Use --diff to preview the changes.
+		  $ %%NAME_EXEC%% --fix ./path/to/hosts ./path/to/hosts.txt
+		  $ %%NAME_EXEC%% --diff ./path/to/hosts
+
+		  $ # Validate the hosts files and report the issues. The exit status is 1
+		  $ # if errors are found. See "%%NAME_EXEC%% lint -h" for the options.
+		  $ %%NAME_EXEC%% lint --mode hosts-file ./path/to/hosts ./path/to/dir
+		  $ %%NAME_EXEC%% lint --format sarif ./path/to/dir > results.sarif
+		  $ %%NAME_EXEC%% lint --conflicts --mode hosts-file /etc/hosts ./path/to/hosts
+
+		  $ # Fail on the lookalikes of ASCII host names such as "www.аррӏе.com" in
+		  $ # Cyrillic. They are reported as warnings by default.
+		  $ %%NAME_EXEC%% lint --severity confusable=error --severity mixed-script=error ./path/to/hosts
+
+		  $ # Read the hosts file from stdin by giving "-" as the file path.
+		  $ curl -sSL https://example.com/hosts.txt | %%NAME_EXEC%% -
+
 		  $ # Merge multiple hosts files into one and output to a file.
 		  $ %%NAME_EXEC%% ./path/to/hosts ./path/to/hosts.txt -o ./path/to/output/merged_hosts.txt
 
@@ -396,6 +681,7 @@ func (f *Flags) showHelp(output *os.File, msg string) {
 	_, _ = fmt.Fprintln(output, "Usage:")
 	_, _ = fmt.Fprintf(output, "  %s [options] <file path> [<file path(s)> ...]\n", NameExec())
 	_, _ = fmt.Fprintf(output, "  %s [options] -d <directory path> [<search pattern>]\n", NameExec())
+	_, _ = fmt.Fprintf(output, "  %s %s [options] <file or directory path> [<path(s)> ...]\n", NameExec(), NameCmdLint)
 
 	_, _ = fmt.Fprintln(output, "Options:")
 
@@ -2,8 +2,10 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc"
@@ -165,6 +167,407 @@ func Test_main_golden_search_dir(t *testing.T) {
 	`))
 }
 
+func Test_main_golden_dedupe(t *testing.T) {
+	// Backup and defer restore os.Args and osExit
+	defer backupAndRestore(t)()
+
+	const pathDirFile = "testdata"
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),           // dummy app name
+		"--dedupe", "host", // remove duplicate host names
+		filepath.Join(pathDirFile, "host1.txt"), // target file1
+		filepath.Join(pathDirFile, "host1.txt"), // same file to duplicate
+	}
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	t.Log(out) // log in case of panic
+
+	require.Equal(t, heredoc.Doc(`
+		badboy1.example.com
+		badboy2.example.com badboy3.example.com
+		Duplicates dropped: 3
+	`), out)
+}
+
+func Test_main_golden_stdin(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(), // dummy app name
+		"-",      // read from stdin
+	}
+
+	// Mock osStdin with a non-seekable reader
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		_, _ = io.WriteString(pipeWriter, "127.0.0.0 badboy1.example.com # comment\n\n127.0.0.0 badboy2.example.com\n")
+		_ = pipeWriter.Close()
+	}()
+
+	osStdin = pipeReader
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	t.Log(out) // log in case of panic
+
+	require.Equal(t, heredoc.Doc(`
+		badboy1.example.com
+		badboy2.example.com
+	`), out)
+}
+
+func Test_main_golden_long_line_split(t *testing.T) {
+	// Backup and defer restore os.Args and osExit
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),               // dummy app name
+		"--long-line", "split", // split the long lines
+		"--max-line-length", "40", // short enough to split
+		"--remove-ip-head=false", // keep the IP address to see it repeated
+		filepath.Join("testdata", "host1.txt"),
+	}
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	t.Log(out) // log in case of panic
+
+	require.Equal(t, heredoc.Doc(`
+		127.0.0.0 badboy1.example.com
+		127.0.0.0 badboy2.example.com
+		127.0.0.0 badboy3.example.com
+		Long lines (split): 4
+	`), out)
+}
+
+func Test_main_golden_drop_special_use(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),             // dummy app name
+		"--drop-special-use", // drop special-use domain names
+		"-",                  // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader(heredoc.Doc(`
+		127.0.0.1 localhost localhost.localdomain
+		0.0.0.0 printer.local ads.example.co.jp
+		0.0.0.0 tracker.example.co.jp
+	`))
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	// The dropped name leaves its space
+	require.Equal(t, heredoc.Doc(`
+		 ads.example.co.jp
+		tracker.example.co.jp
+	`), out)
+}
+
+func Test_main_golden_drop_hijack(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),                            // dummy app name
+		"--drop-hijack",                     // drop the hijacking entries
+		"--sinkhole-ip=0.0.0.0,127.0.0.0/8", // acceptable sinkholes
+		"-",                                 // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader(heredoc.Doc(`
+		0.0.0.0 ads.example.com
+		203.0.113.7 login.example.com www.example.com
+		127.0.0.2 tracker.example.com
+		::1 ipv6.example.com
+	`))
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	capturedErr := ""
+	capturedOut := capturer.CaptureStdout(func() {
+		capturedErr = capturer.CaptureStderr(func() {
+			assert.NotPanics(t, func() { main() })
+		})
+	})
+
+	require.Equal(t, heredoc.Doc(`
+		ads.example.com
+		tracker.example.com
+	`), capturedOut)
+	require.Equal(t, heredoc.Doc(`
+		Hijacking entries dropped: 2
+		  -:2: 203.0.113.7 login.example.com www.example.com
+		  -:4: ::1 ipv6.example.com
+	`), capturedErr)
+}
+
+func Test_main_golden_drop_hijack_files(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	pathDir := t.TempDir()
+	pathFile1 := filepath.Join(pathDir, "hosts1")
+	pathFile2 := filepath.Join(pathDir, "hosts2")
+
+	require.NoError(t, os.WriteFile(pathFile1, []byte("0.0.0.0 ads.example.com\n203.0.113.7 login.example.com\n"), 0o600))
+	require.NoError(t, os.WriteFile(pathFile2, []byte("# comment\n\n198.51.100.1 www.example.com"), 0o600))
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "--drop-hijack", pathFile1, pathFile2}
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	capturedErr := ""
+	_ = capturer.CaptureStdout(func() {
+		capturedErr = capturer.CaptureStderr(func() {
+			assert.NotPanics(t, func() { main() })
+		})
+	})
+
+	// The lines are located in the files given rather than the merged file
+	require.Equal(t, heredoc.Doc(`
+		Hijacking entries dropped: 2
+		  %[1]s:2: 203.0.113.7 login.example.com
+		  %[2]s:3: 198.51.100.1 www.example.com
+	`), strings.NewReplacer(pathFile1, "%[1]s", pathFile2, "%[2]s").Replace(capturedErr))
+}
+
+func Test_main_golden_sortdomain(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	pathPSL := writeTempHosts(t, "public_suffix_list.dat", "com\nuk\nco.uk\n")
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),                // dummy app name
+		"--sortdomain",          // sort by the registrable domain
+		"--psl-file=" + pathPSL, // use the given Public Suffix List
+		"-",                     // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader(heredoc.Doc(`
+		0.0.0.0 www.example.com
+		0.0.0.0 ads.example.co.uk
+		0.0.0.0 cdn.another.com
+		0.0.0.0 example.co.uk
+	`))
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	require.Equal(t, heredoc.Doc(`
+		cdn.another.com
+		www.example.com
+		example.co.uk
+		ads.example.co.uk
+	`), out)
+}
+
+func Test_main_golden_unicode(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(),         // dummy app name
+		"--unicode",      // convert punycode to unicode
+		"--annotate-idn", // append the punycode as a comment
+		"--punycode=false",
+		"-", // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader(heredoc.Doc(`
+		0.0.0.0 xn--gpher-jua.com
+		0.0.0.0 www.example.com xn--zz.com
+	`))
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	capturedErr := ""
+	capturedOut := capturer.CaptureStdout(func() {
+		capturedErr = capturer.CaptureStderr(func() {
+			assert.NotPanics(t, func() { main() })
+		})
+	})
+
+	require.Equal(t, heredoc.Doc(`
+		göpher.com # xn--gpher-jua.com
+		www.example.com xn--zz.com
+	`), capturedOut)
+	require.Equal(t, heredoc.Doc(`
+		Warning: host names left in punycode: 1
+		  xn--zz.com (failed to convert host name to Unicode with the lookup profile: idna: invalid label "zz")
+	`), capturedErr)
+}
+
+func Test_main_golden_fix(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+
+	require.NoError(t, os.WriteFile(pathFile, []byte(heredoc.Doc(`
+		# Blocklist
+		  0.0.0.0   Ads.Example.com
+		0.0.0.0 münchen.example.com foo_bar.example.com
+		0.0.0.0
+	`)), 0o600))
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(), // dummy app name
+		"--fix",  // fix the file in place
+		pathFile, // target file
+	}
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureOutput(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	assert.Equal(t, "Fixed lines ("+pathFile+"): 3\n", out)
+
+	fixed, err := os.ReadFile(pathFile)
+	require.NoError(t, err)
+
+	require.Equal(t, heredoc.Doc(`
+		# Blocklist
+		0.0.0.0 ads.example.com
+		0.0.0.0 xn--mnchen-3ya.example.com
+	`), string(fixed))
+}
+
+func Test_main_golden_fix_stdin(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(), // dummy app name
+		"--fix",  // fix the input
+		"-",      // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader("0.0.0.0 Example.COM  \n")
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureStdout(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	require.Equal(t, "0.0.0.0 example.com\n", out)
+}
+
+func Test_main_golden_diff(t *testing.T) {
+	// Backup and defer restore os.Args, osExit and osStdin
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(), // dummy app name
+		"--diff", // preview the fixes
+		"-",      // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader(heredoc.Doc(`
+		127.0.0.1
+		0.0.0.0 example.com
+		  0.0.0.0 Example.NET
+		0.0.0.0 -invalid.example.com
+		0.0.0.0 example.org
+	`))
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	out := capturer.CaptureStdout(func() {
+		assert.NotPanics(t, func() { main() })
+	})
+
+	require.Equal(t, heredoc.Doc(`
+		--- -
+		+++ -
+		@@ -1 +0,0 @@
+		-127.0.0.1
+		@@ -3 +2 @@
+		-  0.0.0.0 Example.NET
+		+0.0.0.0 example.net
+		@@ -4 +2,0 @@
+		-0.0.0.0 -invalid.example.com
+	`), out)
+}
+
 // ============================================================================
 //  Error Cases
 // ============================================================================
@@ -174,7 +577,7 @@ func Test_main_golden_search_dir(t *testing.T) {
 // ----------------------------------------------------------------------------
 
 func Test_appendFileTo_input_is_empty(t *testing.T) {
-	err := appendFileTo("", nil)
+	_, err := appendFileTo("", nil)
 
 	require.Error(t, err, "it should return error on empty input")
 	assert.Contains(t, err.Error(), "failed to open the file")
@@ -184,7 +587,7 @@ func Test_appendFileTo_outfile_fails_to_write(t *testing.T) {
 	pathFile := filepath.Join("testdata", "host1.txt")
 	dummyFile := new(DummyFile) // dummy implementation to force error
 
-	err := appendFileTo(pathFile, dummyFile)
+	_, err := appendFileTo(pathFile, dummyFile)
 
 	require.Error(t, err, "it should return if fails to write")
 	assert.Contains(t, err.Error(), "failed to write to the file", "it should contain the error reason")
@@ -282,6 +685,72 @@ func Test_main_out_file_is_dir(t *testing.T) {
 	require.Contains(t, capturedOut, "failed to create the output file")
 }
 
+func Test_main_fix_issues_left(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{
+		t.Name(), // dummy app name
+		"--diff", // preview the fixes
+		"-",      // read from stdin
+	}
+
+	// Mock osStdin
+	osStdin = strings.NewReader("example.com # hostpital:ignore\n")
+
+	// Mock osExit to force panic instead of os.Exit
+	osExit = func(_ int) {
+		panic("os.Exit called")
+	}
+
+	capturedErr := ""
+	capturedOut := capturer.CaptureStdout(func() {
+		capturedErr = capturer.CaptureStderr(func() {
+			assert.NotPanics(t, func() { main() })
+		})
+	})
+
+	require.Empty(t, capturedOut, "no diff should be printed if nothing to fix")
+	require.Equal(t, heredoc.Doc(`
+		-:1:13: warning [HP090] unused suppression "ignore"
+		Fixed lines (-): 0
+	`), capturedErr)
+}
+
+func Test_main_fix_errors(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	for index, test := range []struct {
+		pathFile string
+		stdin    io.Reader
+		expect   string
+	}{
+		{pathFile: filepath.Join(t.TempDir(), "missing"), expect: "no such file or directory"},
+		{pathFile: t.TempDir(), expect: "is a directory"},
+		{pathFile: "-", stdin: new(DummyFile), expect: "dummy error to read"},
+	} {
+		// Mock os.Args
+		os.Args = []string{t.Name(), "--fix", test.pathFile}
+
+		// Mock osStdin
+		osStdin = test.stdin
+
+		// Mock osExit to force panic instead of os.Exit
+		osExit = func(_ int) {
+			panic("os.Exit called")
+		}
+
+		capturedOut := capturer.CaptureOutput(func() {
+			assert.Panics(t, func() { main() }, "test #%d should exit", index+1)
+		})
+
+		require.Contains(t, capturedOut, "failed to fix the file", "test #%d failed", index+1)
+		require.Contains(t, capturedOut, test.expect, "test #%d failed", index+1)
+	}
+}
+
 func Test_main_show_help(t *testing.T) {
 	// Backup and defer restore os.Args and function variables
 	defer backupAndRestore(t)()
@@ -386,6 +855,35 @@ func TestMergeFiles_fail_append_to_file(t *testing.T) {
 	assert.Nil(t, fnTest, "it should return nil function on error")
 }
 
+func Test_sourceOf(t *testing.T) {
+	sources := []mergedSource{
+		{path: "hosts1", numLines: 2},
+		{path: "hosts2", numLines: 3},
+	}
+
+	for _, test := range []struct {
+		expectPath string
+		numLine    int
+		expectLine int
+	}{
+		{numLine: 1, expectPath: "hosts1", expectLine: 1},
+		{numLine: 2, expectPath: "hosts1", expectLine: 2},
+		{numLine: 3, expectPath: "hosts2", expectLine: 1},
+		{numLine: 5, expectPath: "hosts2", expectLine: 3},
+		{numLine: 6, expectPath: "hosts2", expectLine: 4}, // last line without the trailing line break
+	} {
+		pathFile, numLine := sourceOf(sources, test.numLine)
+
+		assert.Equal(t, test.expectPath, pathFile, "line: %d", test.numLine)
+		assert.Equal(t, test.expectLine, numLine, "line: %d", test.numLine)
+	}
+
+	pathFile, numLine := sourceOf(nil, 7)
+
+	assert.Equal(t, "-", pathFile, "stdin should be shown as -")
+	assert.Equal(t, 7, numLine)
+}
+
 // ----------------------------------------------------------------------------
 //  NameExec()
 // ----------------------------------------------------------------------------
@@ -450,10 +948,70 @@ func TestParseFlags(t *testing.T) {
 	assert.Nil(t, flags, "it should return nil flags on error")
 }
 
+func TestParseFlags_unknown_dedupe_mode(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "--dedupe", "unknown", "hosts.txt"}
+
+	flags, err := ParseFlags()
+
+	require.Error(t, err, "it should return error on unknown dedupe mode")
+	assert.Contains(t, err.Error(), "failed to parse the flags")
+	assert.Contains(t, err.Error(), `unknown dedupe mode "unknown"`)
+	assert.Nil(t, flags, "it should return nil flags on error")
+}
+
+func TestParseFlags_unknown_long_line_policy(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "--long-line", "unknown", "hosts.txt"}
+
+	flags, err := ParseFlags()
+
+	require.Error(t, err, "it should return error on unknown long line policy")
+	assert.Contains(t, err.Error(), "failed to parse the flags")
+	assert.Contains(t, err.Error(), `unknown long line policy "unknown"`)
+	assert.Nil(t, flags, "it should return nil flags on error")
+}
+
+func TestParseFlags_invalid_sinkhole_ip(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "--drop-hijack", "--sinkhole-ip", "localhost", "hosts.txt"}
+
+	flags, err := ParseFlags()
+
+	require.Error(t, err, "it should return error on invalid sinkhole address")
+	assert.Contains(t, err.Error(), "failed to parse the flags")
+	assert.Contains(t, err.Error(), `invalid sinkhole address "localhost"`)
+	assert.Nil(t, flags, "it should return nil flags on error")
+}
+
 // ----------------------------------------------------------------------------
 //  ShowVerApp
 // ----------------------------------------------------------------------------
 
+func TestParseFlags_invalid_psl_file(t *testing.T) {
+	// Backup and defer restore os.Args and function variables
+	defer backupAndRestore(t)()
+
+	// Mock os.Args
+	os.Args = []string{t.Name(), "--sortdomain", "--psl-file", filepath.Join(t.TempDir(), "missing"), "hosts.txt"}
+
+	flags, err := ParseFlags()
+
+	require.Error(t, err, "it should return error on missing Public Suffix List")
+	assert.Contains(t, err.Error(), "failed to parse the flags")
+	assert.Contains(t, err.Error(), "failed to open the file")
+	assert.Nil(t, flags, "it should return nil flags on error")
+}
+
 func TestShowVerApp(t *testing.T) {
 	// Backup and defer restore os.Args and function variables
 	defer backupAndRestore(t)()
@@ -528,6 +1086,7 @@ func backupAndRestore(t *testing.T) func() {
 	oldOsExit := osExit
 	oldOsExecutable := osExecutable
 	oldOsCreateTemp := osCreateTemp
+	oldOsStdin := osStdin
 	oldVersion := version
 
 	return func() {
@@ -535,6 +1094,7 @@ func backupAndRestore(t *testing.T) func() {
 		osExit = oldOsExit
 		osExecutable = oldOsExecutable
 		osCreateTemp = oldOsCreateTemp
+		osStdin = oldOsStdin
 		version = oldVersion
 	}
 }
@@ -6,12 +6,94 @@
 package hostpital_test
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/KEINOS/go-hostpital/hostpital"
 )
 
+// BenchmarkDomainSet compares the DomainSet with a plain map of the host names
+// which looks up the parents one by one for the suffix match.
+func BenchmarkDomainSet(b *testing.B) {
+	const numNames = 1_000_000
+
+	names := genHostNames(numNames)
+	queries := make([]string, len(names))
+
+	// Half of the queries are the subdomains of the listed host names
+	for index, name := range names {
+		queries[index] = name
+
+		if index%2 == 0 {
+			queries[index] = "cdn." + name
+		}
+	}
+
+	set := hostpital.NewDomainSet(names...)
+	plain := make(map[string]struct{}, len(names))
+
+	for _, name := range names {
+		plain[name] = struct{}{}
+	}
+
+	b.Run(fmt.Sprintf("names=%d/build/map", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			built := make(map[string]struct{})
+			for _, name := range names {
+				built[name] = struct{}{}
+			}
+		}
+	})
+
+	b.Run(fmt.Sprintf("names=%d/build/domain_set", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			_ = hostpital.NewDomainSet(names...)
+		}
+	})
+
+	b.Run(fmt.Sprintf("names=%d/contains/map", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for index := range b.N {
+			_, _ = plain[queries[index%len(queries)]]
+		}
+	})
+
+	b.Run(fmt.Sprintf("names=%d/contains/domain_set", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for index := range b.N {
+			_ = set.Contains(queries[index%len(queries)])
+		}
+	})
+
+	b.Run(fmt.Sprintf("names=%d/match_suffix/map", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for index := range b.N {
+			_, _ = matchSuffixMap(plain, queries[index%len(queries)])
+		}
+	})
+
+	b.Run(fmt.Sprintf("names=%d/match_suffix/domain_set", numNames), func(b *testing.B) {
+		b.ReportAllocs()
+
+		for index := range b.N {
+			_, _ = set.MatchSuffix(queries[index%len(queries)])
+		}
+	})
+}
+
 func BenchmarkParser(b *testing.B) {
 	const wantIP = "0.0.0.0"
 
@@ -31,8 +113,142 @@ func BenchmarkParser(b *testing.B) {
 	}
 }
 
+// BenchmarkParser_ParseReader compares the bounded worker pool of the Parser
+// with the former implementation which started one goroutine per line.
+func BenchmarkParser_ParseReader(b *testing.B) {
+	for _, numLines := range []int{10_000, 1_000_000, 5_000_000} {
+		input := genHostsLines(numLines)
+
+		b.Run(fmt.Sprintf("lines=%d/goroutine_per_line", numLines), func(b *testing.B) {
+			parser := hostpital.NewParser()
+
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+
+			for range b.N {
+				err := parseGoroutinePerLine(parser, bytes.NewReader(input), io.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("lines=%d/worker_pool", numLines), func(b *testing.B) {
+			parser := hostpital.NewParser()
+
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+
+			for range b.N {
+				err := parser.ParseReader(bytes.NewReader(input), io.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkReverseDNS(b *testing.B) {
 	for range b.N {
 		_ = hostpital.ReverseDNS("www.example.com")
 	}
 }
+
+// ----------------------------------------------------------------------------
+//  Helper functions
+// ----------------------------------------------------------------------------
+
+// genHostNames generates numNames host names of the different depths sharing
+// the parents. Such as "host1.sub1.example1.com".
+func genHostNames(numNames int) []string {
+	names := make([]string, numNames)
+
+	for index := range names {
+		names[index] = fmt.Sprintf("host%d.sub%d.example%d.com", index, index%100, index%1000)
+
+		if index%3 == 0 {
+			names[index] = fmt.Sprintf("example%d.net", index)
+		}
+	}
+
+	return names
+}
+
+// genHostsLines generates a hosts file of numLines lines with comments and
+// empty lines mixed in.
+func genHostsLines(numLines int) []byte {
+	var buf bytes.Buffer
+
+	for index := range numLines {
+		switch index % 10 {
+		case 0:
+			buf.WriteString("# comment line\n")
+		case 5:
+			buf.WriteString("\n")
+		default:
+			fmt.Fprintf(&buf, "0.0.0.0 host%d.example.com # inline comment\n", index)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// matchSuffixMap is the suffix match with a plain map which looks up the host
+// name and then its parents one by one.
+func matchSuffixMap(names map[string]struct{}, hostName string) (string, bool) {
+	for name := hostName; ; {
+		if _, ok := names[name]; ok {
+			return name, true
+		}
+
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok {
+			return "", false
+		}
+
+		name = parent
+	}
+}
+
+// parseGoroutinePerLine is the former implementation of the Parser which
+// started one goroutine per line and kept all the lines in memory.
+func parseGoroutinePerLine(parser *hostpital.Parser, input io.Reader, output io.Writer) error {
+	lines := []string{}
+	scanBuf := bufio.NewScanner(input)
+
+	for scanBuf.Scan() {
+		lines = append(lines, scanBuf.Text())
+	}
+
+	if err := scanBuf.Err(); err != nil {
+		return err
+	}
+
+	wgrp := new(sync.WaitGroup)
+
+	for index, line := range lines {
+		wgrp.Add(1)
+
+		go func(line string, index int) {
+			defer wgrp.Done()
+
+			// Omitted lines are returned as empty
+			lines[index], _ = parser.ParseLine(line)
+		}(line, index)
+	}
+
+	wgrp.Wait()
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		if _, err := io.WriteString(output, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
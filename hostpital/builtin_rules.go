@@ -0,0 +1,452 @@
+package hostpital
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+// ----------------------------------------------------------------------------
+//  Built-in rules
+// ----------------------------------------------------------------------------
+
+// builtinRules is the registry of the built-in rules in the order of checks.
+//
+//nolint:gochecknoglobals // read-only table
+var builtinRules = []builtinRule{
+	{id: RuleIndent, name: "indent", check: checkIndent},
+	{id: RuleTrailingSpace, name: "trailing-space", check: checkTrailingSpace},
+	{id: RuleEmptyLine, name: "empty-line", check: checkEmptyLine},
+	{id: RuleLineBreak, name: "line-break", check: checkLineBreak},
+	{id: RuleIPAddressOnly, name: "ip-only", check: checkIPAddressOnly},
+	{id: RuleUnderscore, name: "underscore", check: checkLabels(RuleUnderscore)},
+	{id: RuleRFC6125, name: "not-rfc6125", check: checkRFC6125},
+	{id: RuleIDNA2008, name: "not-idna2008", check: checkIDNA2008},
+	{id: RuleLabelTooLong, name: "label-too-long", check: checkLabels(RuleLabelTooLong)},
+	{id: RuleNameTooLong, name: "name-too-long", check: checkLabels(RuleNameTooLong)},
+	{id: RuleLabelHyphen, name: "label-hyphen", check: checkLabels(RuleLabelHyphen)},
+	{id: RuleReservedLDH, name: "reserved-ldh", check: checkLabels(RuleReservedLDH)},
+	{id: RuleNumericTLD, name: "numeric-tld", check: checkLabels(RuleNumericTLD)},
+	{id: RuleEmptyLabel, name: "empty-label", check: checkLabels(RuleEmptyLabel)},
+	{id: RuleUppercase, name: "uppercase", check: checkUppercase},
+	{id: RuleTrailingDot, name: "trailing-dot", check: checkTrailingDot},
+	{id: RuleMalformedAddress, name: "malformed-address", check: checkMalformedAddress},
+	{id: RuleMissingAddress, name: "missing-address", check: checkMissingAddress},
+	{id: RuleHostnameInAddressColumn, name: "hostname-in-address-column", check: checkHostnameInAddressColumn},
+	{id: RuleAddressWithoutHostname, name: "address-without-hostname", check: checkAddressWithoutHostname},
+	{id: RuleAddressInHostnameColumn, name: "address-in-hostname-column", check: checkAddressInHostnameColumn},
+	{id: RuleSpecialUse, name: "special-use", check: checkSpecialUse, severity: SeverityOff},
+	{id: RulePublicSuffix, name: "public-suffix", check: checkPublicSuffix, severity: SeverityWarning},
+	{id: RuleSinkhole, name: "non-sinkhole-address", check: checkSinkhole},
+	{id: RuleConfusable, name: "confusable", check: checkConfusable, severity: SeverityWarning},
+	{id: RuleMixedScript, name: "mixed-script", check: checkMixedScript, severity: SeverityWarning},
+	{id: RuleConflict, name: "conflicting-address", check: checkNone, severity: SeverityWarning},
+	{id: RuleUnusedSuppression, name: "unused-suppression", check: checkNone, severity: SeverityWarning},
+}
+
+// idnaLabelProfile is the IDNA2008 profile for registration to check the
+// characters of the labels. The positions of hyphens and the lengths are left
+// to LabelValidator.
+//
+//nolint:gochecknoglobals // read-only profile
+var idnaLabelProfile = idna.New(
+	idna.ValidateForRegistration(),
+	idna.CheckHyphens(false),
+	idna.VerifyDNSLength(false),
+)
+
+// newLineIssue returns a lineIssue of the message at the column. If errLegacy
+// is nil, the message is used as the error.
+func newLineIssue(column int, msg string, errLegacy error) lineIssue {
+	if errLegacy == nil {
+		errLegacy = errors.New(msg)
+	}
+
+	return lineIssue{
+		err:   errLegacy,
+		Issue: Issue{Message: msg, Column: column},
+	}
+}
+
+// checkAddressInHostnameColumn is the check of RuleAddressInHostnameColumn.
+func checkAddressInHostnameColumn(_ *Validator, line LineInfo) []lineIssue {
+	texts, columns := line.Fields()
+	issues := []lineIssue{}
+
+	if len(texts) == 0 || !isHostsAddress(texts[0]) {
+		// Left to RuleHostnameInAddressColumn
+		return issues
+	}
+
+	for index := 1; index < len(texts); index++ {
+		if isHostsAddress(texts[index]) {
+			issues = append(issues, newLineIssue(columns[index], "address in hostname column", nil))
+		}
+	}
+
+	return issues
+}
+
+// checkAddressWithoutHostname is the check of RuleAddressWithoutHostname.
+func checkAddressWithoutHostname(_ *Validator, line LineInfo) []lineIssue {
+	texts, columns := line.Fields()
+	if len(texts) != 1 || !isHostsAddress(texts[0]) {
+		return nil
+	}
+
+	return []lineIssue{newLineIssue(columns[0], "address without hostname", nil)}
+}
+
+// checkConfusable is the check of RuleConfusable. The host names in Unicode or
+// punycode that look like an ASCII host name are reported.
+func checkConfusable(v *Validator, line LineInfo) []lineIssue {
+	texts, columns := hostFields(v, line)
+	issues := []lineIssue{}
+
+	for index, chunk := range texts {
+		homograph := Confusable(chunk)
+		if homograph.Imitates == "" {
+			continue
+		}
+
+		msg := fmt.Sprintf("%#v imitates %#v", chunk, homograph.Imitates)
+		if strings.ToLower(chunk) != homograph.Host {
+			msg = fmt.Sprintf("%#v (%#v) imitates %#v", chunk, homograph.Host, homograph.Imitates)
+		}
+
+		issues = append(issues, newLineIssue(columns[index], msg, nil))
+	}
+
+	return issues
+}
+
+// checkEmptyLine is the check of RuleEmptyLine.
+func checkEmptyLine(_ *Validator, line LineInfo) []lineIssue {
+	if line.Body != "" || line.IsComment {
+		return nil
+	}
+
+	msg := "empty line is not allowed"
+
+	return []lineIssue{newLineIssue(1, msg, errors.Wrap(errors.New(msg), "failed to trim line"))}
+}
+
+// checkHostnameInAddressColumn is the check of RuleHostnameInAddressColumn.
+func checkHostnameInAddressColumn(_ *Validator, line LineInfo) []lineIssue {
+	texts, columns := line.Fields()
+	if len(texts) == 0 || looksLikeAddress(texts[0]) || !slices.ContainsFunc(texts[1:], isHostsAddress) {
+		return nil
+	}
+
+	return []lineIssue{newLineIssue(columns[0], "hostname in address column", nil)}
+}
+
+// checkIDNA2008 is the check of RuleIDNA2008. The labels must consist of the
+// characters allowed by IDNA2008 for registration. The positions of hyphens,
+// underscores and the lengths are left to the rules of LabelValidator.
+//
+// If the IDNA profile of the Validator is set, the labels are checked by it.
+func checkIDNA2008(v *Validator, line LineInfo) []lineIssue {
+	profile := idnaLabelProfile
+
+	if v.IDNA != nil {
+		profile = v.IDNA.labelProfile()
+	}
+
+	return checkFields(v, line, func(chunk string) error {
+		for _, label := range strings.Split(chunk, ".") {
+			if isLDHLabel(label) || isReservedLDH(label) {
+				continue
+			}
+
+			if _, err := profile.ToASCII(label); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%#v is not IDNA2008 compatible", chunk))
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkIndent is the check of RuleIndent.
+func checkIndent(_ *Validator, line LineInfo) []lineIssue {
+	if strings.TrimLeft(line.Raw, " \t") == line.Raw {
+		return nil
+	}
+
+	msg := "indent is not allowed"
+
+	return []lineIssue{newLineIssue(1, msg, errors.Wrap(errors.New(msg), "failed to trim line"))}
+}
+
+// checkIPAddressOnly is the check of RuleIPAddressOnly.
+func checkIPAddressOnly(_ *Validator, line LineInfo) []lineIssue {
+	if !IsIPAddress(line.Body) {
+		return nil
+	}
+
+	return []lineIssue{newLineIssue(line.Offset+1, "IP address only line is not allowed", nil)}
+}
+
+// checkLineBreak is the check of RuleLineBreak.
+func checkLineBreak(_ *Validator, line LineInfo) []lineIssue {
+	index := strings.IndexRune(line.Raw, LF)
+	if index < 0 {
+		return nil
+	}
+
+	msg := "line break found"
+
+	return []lineIssue{newLineIssue(index+1, msg, errors.Wrap(errors.New(msg), "failed to trim comment"))}
+}
+
+// checkMalformedAddress is the check of RuleMalformedAddress.
+func checkMalformedAddress(_ *Validator, line LineInfo) []lineIssue {
+	texts, columns := line.Fields()
+	if len(texts) == 0 || isHostsAddress(texts[0]) || !looksLikeAddress(texts[0]) {
+		return nil
+	}
+
+	return []lineIssue{newLineIssue(columns[0], fmt.Sprintf("malformed address %#v", texts[0]), nil)}
+}
+
+// checkMissingAddress is the check of RuleMissingAddress.
+func checkMissingAddress(_ *Validator, line LineInfo) []lineIssue {
+	texts, columns := line.Fields()
+	if len(texts) == 0 || looksLikeAddress(texts[0]) || slices.ContainsFunc(texts[1:], isHostsAddress) {
+		return nil
+	}
+
+	return []lineIssue{newLineIssue(columns[0], "missing address", nil)}
+}
+
+// checkMixedScript is the check of RuleMixedScript. The issues are reported at
+// the columns of the labels mixing the scripts.
+func checkMixedScript(v *Validator, line LineInfo) []lineIssue {
+	texts, columns := hostFields(v, line)
+	issues := []lineIssue{}
+
+	for index, chunk := range texts {
+		offset := 0
+
+		for _, label := range strings.Split(chunk, ".") {
+			for _, mixed := range Confusable(label).MixedScript {
+				issues = append(issues, newLineIssue(columns[index]+offset, fmt.Sprintf("label %#v mixes the scripts %s",
+					mixed, strings.Join(labelScripts(mixed), ", ")), nil))
+			}
+
+			offset += len(label) + 1
+		}
+	}
+
+	return issues
+}
+
+// checkNone is the check of the rules that are not about a single line. Such as
+// RuleConflict and RuleUnusedSuppression which are checked while reading the
+// lines.
+func checkNone(_ *Validator, _ LineInfo) []lineIssue {
+	return nil
+}
+
+// checkPublicSuffix is the check of RulePublicSuffix.
+func checkPublicSuffix(v *Validator, line LineInfo) []lineIssue {
+	list := v.publicSuffixes()
+
+	return checkFields(v, line, func(chunk string) error {
+		if !list.IsPublicSuffix(chunk) {
+			return nil
+		}
+
+		_, section := list.PublicSuffix(chunk)
+
+		return errors.Errorf("%#v is a public suffix (%s)", chunk, section)
+	})
+}
+
+// checkRFC6125 is the check of RuleRFC6125. The labels must consist of the
+// ASCII letters, digits, hyphens and underscores as RFC 6125 2.2 leniently
+// accepts legacy values.
+func checkRFC6125(v *Validator, line LineInfo) []lineIssue {
+	return checkFields(v, line, func(chunk string) error {
+		for _, label := range strings.Split(chunk, ".") {
+			if !isLDHLabel(label) {
+				return errors.Errorf("%#v is not RFC 6125 2.2 compatible", chunk)
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkSinkhole is the check of RuleSinkhole. It is off unless the Sinkhole
+// policy of the Validator is set.
+func checkSinkhole(v *Validator, line LineInfo) []lineIssue {
+	violation, isHijack := v.Sinkhole.Check(line.Body)
+	if !isHijack {
+		return nil
+	}
+
+	_, columns := line.Fields()
+
+	return []lineIssue{newLineIssue(columns[0], fmt.Sprintf("%#v is not an allowed sinkhole address", violation.Address), nil)}
+}
+
+// checkSpecialUse is the check of RuleSpecialUse. It is off by default.
+func checkSpecialUse(v *Validator, line LineInfo) []lineIssue {
+	texts, columns := hostFields(v, line)
+	issues := []lineIssue{}
+
+	for index, chunk := range texts {
+		if category := Classify(chunk); category != SpecialUseNone {
+			issues = append(issues, newLineIssue(columns[index],
+				fmt.Sprintf("%#v is a special-use domain name (%s)", chunk, category), nil))
+		}
+	}
+
+	return issues
+}
+
+// checkTrailingDot is the check of RuleTrailingDot. The host name of only a dot
+// is left to RuleEmptyLabel.
+func checkTrailingDot(v *Validator, line LineInfo) []lineIssue {
+	return checkFields(v, line, func(chunk string) error {
+		if chunk == string(DelimDNS) || !strings.HasSuffix(chunk, string(DelimDNS)) {
+			return nil
+		}
+
+		return errors.Errorf("%#v ends with a dot", chunk)
+	})
+}
+
+// checkTrailingSpace is the check of RuleTrailingSpace.
+func checkTrailingSpace(_ *Validator, line LineInfo) []lineIssue {
+	trimmed := strings.TrimRight(line.Raw, " \t")
+	if trimmed == line.Raw {
+		return nil
+	}
+
+	msg := "trailing space is not allowed"
+
+	return []lineIssue{
+		newLineIssue(len(trimmed)+1, msg, errors.Wrap(errors.New(msg), "failed to trim line")),
+	}
+}
+
+// checkUppercase is the check of RuleUppercase. The labels other than the LDH
+// labels are left to RuleIDNA2008.
+func checkUppercase(v *Validator, line LineInfo) []lineIssue {
+	return checkFields(v, line, func(chunk string) error {
+		for _, label := range strings.Split(chunk, ".") {
+			if isLDHLabel(label) && strings.ToLower(label) != label {
+				return errors.Errorf("%#v has uppercase letters", chunk)
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkFields returns the issues of the host fields in the line that validate
+// fails.
+func checkFields(v *Validator, line LineInfo, validate func(chunk string) error) []lineIssue {
+	texts, columns := hostFields(v, line)
+	issues := []lineIssue{}
+
+	for index, chunk := range texts {
+		err := validate(chunk)
+		if err == nil {
+			continue
+		}
+
+		issues = append(issues, newLineIssue(columns[index], err.Error(),
+			errors.Wrap(err, "failed to validate chunk/part of line")))
+	}
+
+	return issues
+}
+
+// checkLabels returns the check of the rule of LabelValidator. The issues are
+// reported at the columns of the labels.
+func checkLabels(ruleID string) func(v *Validator, line LineInfo) []lineIssue {
+	return func(v *Validator, line LineInfo) []lineIssue {
+		texts, columns := hostFields(v, line)
+		issues := []lineIssue{}
+		standard := "IDNA2008"
+
+		if !v.IDNACompatible {
+			standard = "RFC 6125 2.2"
+		}
+
+		for index, chunk := range texts {
+			for _, labelErr := range new(LabelValidator).Validate(chunk) {
+				if labelErr.RuleID != ruleID {
+					continue
+				}
+
+				errLegacy := errors.Wrap(labelErr, fmt.Sprintf("%#v is not %s compatible", chunk, standard))
+
+				issues = append(issues, newLineIssue(columns[index]+labelErr.Index, labelErr.Error(),
+					errors.Wrap(errLegacy, "failed to validate chunk/part of line")))
+			}
+		}
+
+		return issues
+	}
+}
+
+// hostFields returns the fields of the line to be validated as host names. The
+// IP addresses are skipped. In ModeHostsFile, the fields meant to be addresses
+// are left to the rules of hosts(5) as well.
+func hostFields(v *Validator, line LineInfo) ([]string, []int) {
+	texts, columns := line.Fields()
+	hostTexts := []string{}
+	hostColumns := []int{}
+
+	for index, text := range texts {
+		if !IsIPAddress(text) && (v.Mode != ModeHostsFile || !looksLikeAddress(text)) {
+			hostTexts = append(hostTexts, text)
+			hostColumns = append(hostColumns, columns[index])
+		}
+	}
+
+	return hostTexts, hostColumns
+}
+
+// isLDHLabel returns true if the label consists of ASCII letters, digits,
+// hyphens and underscores.
+func isLDHLabel(label string) bool {
+	for _, char := range label {
+		switch {
+		case 'a' <= char && char <= 'z', 'A' <= char && char <= 'Z', '0' <= char && char <= '9':
+		case char == '-', char == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isHostsAddress returns true if the field is an IPv4 or IPv6 address allowed
+// in the address column of hosts(5). IPv6 addresses can have a zone.
+func isHostsAddress(field string) bool {
+	_, err := netip.ParseAddr(field)
+
+	return err == nil
+}
+
+// looksLikeAddress returns true if the field is an address or meant to be one.
+// Such as "127.0.0.256" and "fe80::zz" but not "1e100.net". Host names never
+// contain colons, so the fields with colons are taken as IPv6 addresses.
+func looksLikeAddress(field string) bool {
+	if isHostsAddress(field) || strings.Contains(field, ":") {
+		return true
+	}
+
+	return strings.Contains(field, ".") && strings.Trim(field, "0123456789.") == ""
+}
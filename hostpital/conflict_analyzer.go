@@ -0,0 +1,205 @@
+package hostpital
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: ConflictAnalyzer
+// ----------------------------------------------------------------------------
+
+// ConflictAnalyzer finds the host names mapped to different addresses across
+// the lines and the sources. Such as "10.0.0.1 db.example.com" in one file and
+// "10.0.0.2 db.example.com" in another, which changes the resolution depending
+// on the order of the lines.
+//
+// The sources are taken as concatenated in the order analyzed, and the first
+// entry of a host name wins as glibc's "files" backend returns the first match.
+// The addresses of different families do not conflict since they are looked up
+// separately. Such as "127.0.0.1 localhost" and "::1 localhost". Host names
+// are compared case-insensitively.
+//
+// It is safe for concurrent use. Use NewConflictAnalyzer to create one.
+type ConflictAnalyzer struct {
+	entries map[string][]ConflictEntry // Entries by the family and the host name.
+	order   []string                   // Keys of entries in the order of appearance.
+	mutx    sync.Mutex
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Conflict
+// ----------------------------------------------------------------------------
+
+// Conflict is a host name mapped to two or more distinct addresses of the same
+// family.
+type Conflict struct {
+	Hostname string          // The host name in lowercase.
+	Entries  []ConflictEntry // All the entries of the host name in the order read. The first one wins.
+}
+
+// Winner returns the entry used by the resolver. Which is the first one.
+func (c Conflict) Winner() ConflictEntry {
+	return c.Entries[0]
+}
+
+// ----------------------------------------------------------------------------
+//  Type: ConflictEntry
+// ----------------------------------------------------------------------------
+
+// ConflictEntry is a location of a host name mapped to an address.
+type ConflictEntry struct {
+	Source   string // Path of the file the entry was read from. Empty if unknown.
+	Address  string // The address the host name points to.
+	Hostname string // The host name as is.
+	Line     int    // Line number of the entry. Starts from 1.
+	Column   int    // Column of the host name in bytes. Starts from 1.
+}
+
+// String returns the location of the entry. Such as "hosts:12" or "line 12" if
+// the source is unknown.
+func (e ConflictEntry) String() string {
+	if e.Source == "" {
+		return fmt.Sprintf("line %d", e.Line)
+	}
+
+	return fmt.Sprintf("%s:%d", e.Source, e.Line)
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// NewConflictAnalyzer returns a new ConflictAnalyzer with no entries.
+func NewConflictAnalyzer() *ConflictAnalyzer {
+	return &ConflictAnalyzer{entries: map[string][]ConflictEntry{}}
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// Analyze records the entries of the lines read from input as of the source.
+// The source is the name to locate the entries, such as the file path, and can
+// be empty. The lines without a leading address are ignored.
+func (a *ConflictAnalyzer) Analyze(source string, input io.Reader) error {
+	reader := newLineReader(input, DefaultMaxLineLength, LongLineFail)
+
+	for reader.Scan() {
+		a.AddLine(source, reader.Line(), reader.Text())
+	}
+
+	return errors.Wrap(reader.Err(), "failed to read from reader")
+}
+
+// AnalyzeFile is like Analyze but reads the file and uses its path as the
+// source.
+func (a *ConflictAnalyzer) AnalyzeFile(pathFile string) error {
+	return a.AnalyzeFS(osFS{}, filepath.Clean(pathFile))
+}
+
+// AnalyzeFS is like AnalyzeFile but reads the named file from the given file
+// system. Such as embed.FS, zip.Reader and fstest.MapFS.
+func (a *ConflictAnalyzer) AnalyzeFS(fsys fs.FS, name string) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the file")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return a.Analyze(name, file)
+}
+
+// AddLine records the entries of the line numbered number of the source and
+// returns the conflicts of them with the entries recorded before. Each Conflict
+// holds the winner followed by the entry of the line.
+func (a *ConflictAnalyzer) AddLine(source string, number int, line string) []Conflict {
+	body, _, _ := strings.Cut(line, string(DelimComnt))
+	fields := splitFields(body)
+	conflicts := []Conflict{}
+
+	if len(fields) < 2 {
+		return conflicts
+	}
+
+	addr, err := netip.ParseAddr(fields[0].text)
+	if err != nil {
+		return conflicts
+	}
+
+	a.mutx.Lock()
+	defer a.mutx.Unlock()
+
+	for _, field := range fields[1:] {
+		if isHostsAddress(field.text) {
+			// Left to RuleAddressInHostnameColumn
+			continue
+		}
+
+		hostname := strings.ToLower(field.text)
+		key := fmt.Sprintf("%t %s", addr.Is4(), hostname)
+		entry := ConflictEntry{
+			Source:   source,
+			Address:  fields[0].text,
+			Hostname: field.text,
+			Line:     number,
+			Column:   field.index + 1,
+		}
+
+		found, ok := a.entries[key]
+		if !ok {
+			a.order = append(a.order, key)
+		} else if winner := found[0]; !sameAddress(winner.Address, addr) {
+			conflicts = append(conflicts, Conflict{Hostname: hostname, Entries: []ConflictEntry{winner, entry}})
+		}
+
+		a.entries[key] = append(found, entry)
+	}
+
+	return conflicts
+}
+
+// Conflicts returns the host names mapped to two or more distinct addresses
+// of the same family in the order of their first appearance.
+func (a *ConflictAnalyzer) Conflicts() []Conflict {
+	a.mutx.Lock()
+	defer a.mutx.Unlock()
+
+	conflicts := []Conflict{}
+
+	for _, key := range a.order {
+		entries := a.entries[key]
+		winner := netip.MustParseAddr(entries[0].Address)
+
+		for _, entry := range entries[1:] {
+			if !sameAddress(entry.Address, winner) {
+				conflicts = append(conflicts, Conflict{
+					Hostname: strings.ToLower(entries[0].Hostname),
+					Entries:  append([]ConflictEntry{}, entries...),
+				})
+
+				break
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// sameAddress returns true if the address is the same as addr. Such as "::1"
+// and "0::1".
+func sameAddress(address string, addr netip.Addr) bool {
+	parsed, err := netip.ParseAddr(address)
+
+	return err == nil && parsed == addr
+}
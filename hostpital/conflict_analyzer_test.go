@@ -0,0 +1,187 @@
+package hostpital
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  ConflictAnalyzer.AddLine()
+// ----------------------------------------------------------------------------
+
+func TestConflictAnalyzer_AddLine(t *testing.T) {
+	t.Parallel()
+
+	analyzer := NewConflictAnalyzer()
+
+	assert.Empty(t, analyzer.AddLine("a", 1, "10.0.0.1 db.example.com www.example.com # primary"))
+	assert.Empty(t, analyzer.AddLine("a", 2, "10.0.0.1 DB.example.com"), "the same address should not conflict")
+	assert.Empty(t, analyzer.AddLine("a", 3, "::1 db.example.com"), "different families should not conflict")
+	assert.Empty(t, analyzer.AddLine("a", 4, "::0:1 db.example.com"), "the same address in other forms should not conflict")
+
+	for _, line := range []string{
+		"# 10.0.0.9 db.example.com",
+		"db.example.com",
+		"10.0.0.9",
+		"10.0.0.256 db.example.com",
+		"",
+	} {
+		assert.Empty(t, analyzer.AddLine("a", 5, line), "line %q should be ignored", line)
+	}
+
+	conflicts := analyzer.AddLine("b", 6, "  10.0.0.2 Db.Example.com 10.0.0.3 www.example.com")
+
+	assert.Equal(t, []Conflict{
+		{Hostname: "db.example.com", Entries: []ConflictEntry{
+			{Source: "a", Address: "10.0.0.1", Hostname: "db.example.com", Line: 1, Column: 10},
+			{Source: "b", Address: "10.0.0.2", Hostname: "Db.Example.com", Line: 6, Column: 12},
+		}},
+		{Hostname: "www.example.com", Entries: []ConflictEntry{
+			{Source: "a", Address: "10.0.0.1", Hostname: "www.example.com", Line: 1, Column: 25},
+			{Source: "b", Address: "10.0.0.2", Hostname: "www.example.com", Line: 6, Column: 36},
+		}},
+	}, conflicts, "the addresses in the host name column should be skipped")
+}
+
+// ----------------------------------------------------------------------------
+//  ConflictAnalyzer.Analyze()
+// ----------------------------------------------------------------------------
+
+func TestConflictAnalyzer_Analyze(t *testing.T) {
+	t.Parallel()
+
+	analyzer := NewConflictAnalyzer()
+
+	require.NoError(t, analyzer.Analyze("hosts", strings.NewReader(heredoc.Doc(`
+		127.0.0.1 localhost
+		::1 localhost
+		10.0.0.1 db.example.com
+		10.0.0.5 app.example.com
+		10.0.0.1 db.example.com
+	`))))
+
+	pathFile := filepath.Join(t.TempDir(), "hosts.local")
+	require.NoError(t, os.WriteFile(pathFile, []byte("10.0.0.2 db.example.com\n10.0.0.5 app.example.com\n"), 0o600))
+	require.NoError(t, analyzer.AnalyzeFile(pathFile))
+
+	conflicts := analyzer.Conflicts()
+
+	require.Len(t, conflicts, 1, "only db.example.com should conflict")
+	assert.Equal(t, "db.example.com", conflicts[0].Hostname)
+	assert.Len(t, conflicts[0].Entries, 3, "all the entries should be listed")
+	assert.Equal(t, "hosts:3", conflicts[0].Winner().String())
+	assert.Equal(t, pathFile+":1", conflicts[0].Entries[2].String())
+}
+
+func TestConflictAnalyzer_AnalyzeFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"lists/a": {Data: []byte("10.0.0.1 db.example.com\n")},
+		"lists/b": {Data: []byte("# comment\n10.0.0.2 db.example.com\n")},
+	}
+
+	analyzer := NewConflictAnalyzer()
+
+	require.NoError(t, analyzer.AnalyzeFS(fsys, "lists/a"))
+	require.NoError(t, analyzer.AnalyzeFS(fsys, "lists/b"))
+
+	conflicts := analyzer.Conflicts()
+
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "lists/a:1", conflicts[0].Winner().String())
+	assert.Equal(t, "lists/b:2", conflicts[0].Entries[1].String(), "it should use the name as the source")
+
+	err := analyzer.AnalyzeFS(fsys, "not_exist")
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestConflictAnalyzer_Analyze_failures(t *testing.T) {
+	t.Parallel()
+
+	analyzer := NewConflictAnalyzer()
+
+	err := analyzer.Analyze("", strings.NewReader(strings.Repeat("a", DefaultMaxLineLength+1)))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read from reader")
+
+	err = analyzer.AnalyzeFile(filepath.Join(t.TempDir(), "missing"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open the file")
+	assert.Empty(t, analyzer.Conflicts())
+}
+
+func TestConflictEntry_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "line 3", ConflictEntry{Line: 3}.String(), "the source should be omitted if unknown")
+	assert.Equal(t, "hosts:3", ConflictEntry{Source: "hosts", Line: 3}.String())
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.Conflicts
+// ----------------------------------------------------------------------------
+
+func TestValidator_Validate_conflicts(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowComment = true
+	input := heredoc.Doc(`
+		10.0.0.1 db.example.com
+		10.0.0.2 db.example.com
+		10.0.0.3 db.example.com # hostpital:ignore conflicting-address
+	`)
+
+	assert.Equal(t, SeverityOff, validator.Severity(RuleConflict), "it should be off without an analyzer")
+	assert.Equal(t, []string{
+		`3:25: HP090 unused suppression "ignore" of HP050`,
+	}, summarizeIssues(validator.ValidateString(input).Issues))
+
+	validator.Conflicts = NewConflictAnalyzer()
+
+	assert.Equal(t, SeverityWarning, validator.Severity(RuleConflict))
+	assert.Equal(t, []string{
+		`2:10: HP050 "db.example.com" points to "10.0.0.2" but "10.0.0.1" at line 1 wins`,
+	}, summarizeIssues(validator.ValidateString(input).Issues), "the suppressed entries should be recorded")
+	assert.Len(t, validator.Conflicts.Conflicts()[0].Entries, 3)
+}
+
+func TestValidator_ValidateFileReport_conflicts(t *testing.T) {
+	t.Parallel()
+
+	pathDir := t.TempDir()
+	pathFirst := filepath.Join(pathDir, "hosts")
+	pathSecond := filepath.Join(pathDir, "hosts.local")
+
+	require.NoError(t, os.WriteFile(pathFirst, []byte("10.0.0.1 db.example.com\n"), 0o600))
+	require.NoError(t, os.WriteFile(pathSecond, []byte("10.0.0.2 db.example.com\n"), 0o600))
+
+	validator := NewValidator()
+	validator.Conflicts = NewConflictAnalyzer()
+
+	require.NoError(t, validator.SetSeverity(RuleConflict, SeverityError))
+	assert.True(t, validator.ValidateFile(pathFirst))
+
+	report := validator.ValidateFileReport(pathSecond)
+
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{
+		`1:10: HP050 "db.example.com" points to "10.0.0.2" but "10.0.0.1" at ` + pathFirst + `:1 wins`,
+	}, summarizeIssues(report.Issues), "the conflicts across the files should be reported")
+
+	require.NoError(t, validator.SetSeverity(RuleConflict, SeverityOff))
+	assert.True(t, validator.ValidateFile(pathSecond), "it should not be reported if the rule is off")
+}
@@ -0,0 +1,237 @@
+package hostpital
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables is the table of the characters confusable with the lowercase
+// ASCII letters and the hyphen. It is a subset of confusables.txt of Unicode
+// Technical Standard #39 for the host names. The uppercase characters are left
+// out since the host names are lowercased before the lookup.
+//
+// Ref: https://www.unicode.org/Public/security/latest/confusables.txt
+//
+//nolint:gochecknoglobals // read-only table
+var confusables = map[rune]rune{
+	'\u0430': 'a', '\u0251': 'a', '\u03B1': 'a', '\u237A': 'a', // а ɑ α ⍺
+	'\u0184': 'b', '\u13CF': 'b', '\u15AF': 'b', // Ƅ Ꮟ ᖯ
+	'\u0441': 'c', '\u03F2': 'c', '\u1D04': 'c', '\u217D': 'c', '\u2CA5': 'c', // с ϲ ᴄ ⅽ ⲥ
+	'\u0501': 'd', '\u13E7': 'd', '\u146F': 'd', '\u217E': 'd', // ԁ Ꮷ ᑯ ⅾ
+	'\u0435': 'e', '\u04BD': 'e', '\u212E': 'e', '\u212F': 'e', '\u2147': 'e', '\uAB32': 'e', // е ҽ ℮ ℯ ⅇ ꬲ
+	'\u017F': 'f', '\u1E9D': 'f', '\uA799': 'f', // ſ ẝ ꞙ
+	'\u0261': 'g', '\u0581': 'g', '\u018D': 'g', '\u1D83': 'g', // ɡ ց ƍ ᶃ
+	'\u04BB': 'h', '\u0570': 'h', '\u13C2': 'h', '\u210E': 'h', // һ հ Ꮒ ℎ
+	'\u0456': 'i', '\u0131': 'i', '\u03B9': 'i', '\u0269': 'i', '\u2170': 'i', '\u2373': 'i', // і ı ι ɩ ⅰ ⍳
+	'\u0458': 'j', '\u03F3': 'j', '\u2149': 'j', // ј ϳ ⅉ
+	'\u04CF': 'l', '\u01C0': 'l', '\u05C0': 'l', '\u0627': 'l', '\u217C': 'l', '\u2223': 'l', // ӏ ǀ ׀ ا ⅼ ∣
+	'\u217F': 'm',                // ⅿ
+	'\u0578': 'n', '\u057C': 'n', // ո ռ
+	'\u043E': 'o', '\u03BF': 'o', '\u03C3': 'o', '\u0585': 'o', '\u05E1': 'o', '\u0647': 'o', // о ο σ օ ס ه
+	'\u0665': 'o', '\u0966': 'o', '\u0D20': 'o', '\u0E50': 'o', '\u1D0F': 'o', '\u2134': 'o', // ٥ ० ഠ ๐ ᴏ ℴ
+	'\u0440': 'p', '\u03C1': 'p', '\u03F1': 'p', '\u2374': 'p', '\u2CA3': 'p', // р ρ ϱ ⍴ ⲣ
+	'\u051B': 'q', '\u0563': 'q', '\u0566': 'q', // ԛ գ զ
+	'\u0433': 'r', '\u1D26': 'r', '\u2C85': 'r', '\uAB47': 'r', // г ᴦ ⲅ ꭇ
+	'\u0455': 's', '\u01BD': 's', '\uA731': 's', // ѕ ƽ ꜱ
+	'\u057D': 'u', '\u028B': 'u', '\u03C5': 'u', '\u1D1C': 'u', '\uA79F': 'u', // ս ʋ υ ᴜ ꞟ
+	'\u03BD': 'v', '\u0475': 'v', '\u05D8': 'v', '\u1D20': 'v', '\u2174': 'v', '\u2228': 'v', // ν ѵ ט ᴠ ⅴ ∨
+	'\u051D': 'w', '\u0561': 'w', '\u026F': 'w', '\u0461': 'w', '\u1D21': 'w', // ԝ ա ɯ ѡ ᴡ
+	'\u0445': 'x', '\u00D7': 'x', '\u157D': 'x', '\u2179': 'x', '\u292B': 'x', // х × ᕽ ⅹ ⤫
+	'\u0443': 'y', '\u04AF': 'y', '\u03B3': 'y', '\u0263': 'y', '\u10E7': 'y', '\u1EFF': 'y', // у ү γ ɣ ყ ỿ
+	'\u1D22': 'z', '\uAB93': 'z', // ᴢ ꮓ
+	'\u2010': '-', '\u2011': '-', '\u2012': '-', '\u2013': '-', '\u2043': '-', '\u02D7': '-', '\u2212': '-', // ‐ ‑ ‒ – ⁃ ˗ −
+}
+
+// scriptsCJK are the sets of the scripts allowed to be mixed in a label along
+// with Latin as of the "Highly Restrictive" level of UTS #39. Such as Japanese.
+//
+//nolint:gochecknoglobals // read-only table
+var scriptsCJK = [][]string{
+	{"Han", "Hiragana", "Katakana"},
+	{"Han", "Bopomofo"},
+	{"Han", "Hangul"},
+}
+
+// scriptsOrdered are the scripts to look up the characters in order. The
+// scripts common in the host names come first followed by the rest in the
+// order of the names. Common and Inherited are left out.
+//
+//nolint:gochecknoglobals // read-only table
+var scriptsOrdered = orderScripts(
+	"Latin", "Cyrillic", "Greek", "Han", "Hiragana", "Katakana", "Hangul", "Arabic", "Hebrew",
+	"Thai", "Devanagari", "Armenian", "Georgian", "Bopomofo", "Cherokee", "Canadian_Aboriginal",
+)
+
+// namedScript is a script of unicode.Scripts with its name.
+type namedScript struct {
+	table *unicode.RangeTable
+	name  string
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Homograph
+// ----------------------------------------------------------------------------
+
+// Homograph is the result of Confusable. It tells if the host name looks like
+// another one by the confusable characters or by mixing the scripts.
+type Homograph struct {
+	Host        string   // The host name in lowercase Unicode. Such as "www.аррӏе.com" for "www.xn--80ak6aa92e.com".
+	Skeleton    string   // The host name with the confusable characters replaced by the ASCII ones they look like.
+	Imitates    string   // The ASCII host name imitated. Such as "www.apple.com". Empty if Host is ASCII or looks like none.
+	MixedScript []string // The labels mixing the scripts. Such as "pаypal" of Latin and Cyrillic.
+}
+
+// IsSuspicious returns true if the host name imitates an ASCII host name or has
+// the labels mixing the scripts.
+func (h Homograph) IsSuspicious() bool {
+	return h.Imitates != "" || len(h.MixedScript) > 0
+}
+
+// ----------------------------------------------------------------------------
+//  Functions
+// ----------------------------------------------------------------------------
+
+// Confusable analyzes the host name for the homograph attacks. Such as
+// "www.аррӏе.com" in Cyrillic which looks like "www.apple.com" in Latin. The
+// host name can be in punycode.
+//
+// The skeleton is a simplified one of UTS #39 for the host names. The host name
+// is lowercased and decomposed by NFD, then the characters confusable with the
+// ASCII letters and the hyphen are replaced by them. Unlike UTS #39, the ASCII
+// characters are left as they are and the result is composed by NFC.
+//
+// The labels mixing the scripts are reported as MixedScript. Latin mixed with
+// Chinese, Japanese or Korean is allowed as of the "Highly Restrictive" level.
+//
+// Ref: https://www.unicode.org/reports/tr39/
+func Confusable(hostName string) Homograph {
+	host := strings.ToLower(hostName)
+
+	if isASCII(host) && !hasACELabel(host) {
+		// Nothing to look alike. Such as "www.example.com".
+		return Homograph{Host: host, Skeleton: host, MixedScript: []string{}}
+	}
+
+	if hostUnicode, err := TransformToUnicode(host); err == nil {
+		host = hostUnicode
+	}
+
+	result := Homograph{Host: host, Skeleton: skeleton(host), MixedScript: []string{}}
+
+	if !isASCII(host) && isASCII(result.Skeleton) {
+		result.Imitates = result.Skeleton
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if scripts := labelScripts(label); len(scripts) > 1 && !isAllowedScriptMix(scripts) {
+			result.MixedScript = append(result.MixedScript, label)
+		}
+	}
+
+	return result
+}
+
+// isAllowedScriptMix returns true if the scripts are allowed to be mixed. Which
+// are Latin and one of scriptsCJK.
+func isAllowedScriptMix(scripts []string) bool {
+	others := slices.DeleteFunc(slices.Clone(scripts), func(script string) bool {
+		return script == "Latin"
+	})
+
+	for _, allowed := range scriptsCJK {
+		if !slices.ContainsFunc(others, func(script string) bool { return !slices.Contains(allowed, script) }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isASCII returns true if the text consists of ASCII characters only.
+func isASCII(text string) bool {
+	for _, char := range text {
+		if char > unicode.MaxASCII {
+			return false
+		}
+	}
+
+	return true
+}
+
+// labelScripts returns the sorted names of the scripts used in the label. The
+// characters of Common and Inherited, such as digits, hyphens and combining
+// marks, are not counted.
+func labelScripts(label string) []string {
+	scripts := []string{}
+
+	for _, char := range label {
+		name := scriptOf(char)
+		if name != "" && !slices.Contains(scripts, name) {
+			scripts = append(scripts, name)
+		}
+	}
+
+	slices.Sort(scripts)
+
+	return scripts
+}
+
+// orderScripts returns the scripts of unicode.Scripts with the given names
+// first and the rest in the order of the names. See scriptsOrdered.
+func orderScripts(first ...string) []namedScript {
+	names := slices.Sorted(maps.Keys(unicode.Scripts))
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return name == "Common" || name == "Inherited" || slices.Contains(first, name)
+	})
+
+	scripts := make([]namedScript, 0, len(first)+len(names))
+
+	for _, name := range slices.Concat(first, names) {
+		scripts = append(scripts, namedScript{table: unicode.Scripts[name], name: name})
+	}
+
+	return scripts
+}
+
+// scriptOf returns the name of the script of the character. It returns empty
+// for Common, Inherited and the unassigned characters.
+func scriptOf(char rune) string {
+	if char <= unicode.MaxASCII {
+		if unicode.IsLetter(char) {
+			return "Latin"
+		}
+
+		return ""
+	}
+
+	for _, script := range scriptsOrdered {
+		if unicode.Is(script.table, char) {
+			return script.name
+		}
+	}
+
+	return ""
+}
+
+// skeleton returns the host name with the confusable characters replaced by
+// the ASCII ones. The fullwidth forms are replaced as well. The result is
+// composed by NFC to compare and print as is.
+func skeleton(host string) string {
+	mapped := strings.Map(func(char rune) rune {
+		if ascii, ok := confusables[char]; ok {
+			return ascii
+		}
+
+		if '\uFF01' <= char && char <= '\uFF5E' {
+			return char - '\uFF01' + '!'
+		}
+
+		return char
+	}, norm.NFD.String(host))
+
+	return norm.NFC.String(mapped)
+}
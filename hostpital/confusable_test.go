@@ -0,0 +1,122 @@
+package hostpital
+
+import (
+	"slices"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  Confusable()
+// ----------------------------------------------------------------------------
+
+func TestConfusable(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		input  string
+		expect Homograph
+	}{
+		// Whole-script confusable in Cyrillic
+		{input: "www.аррӏе.com", expect: Homograph{
+			Host: "www.аррӏе.com", Skeleton: "www.apple.com", Imitates: "www.apple.com", MixedScript: []string{},
+		}},
+		// Same as above in punycode
+		{input: "WWW.xn--80ak6aa92e.com", expect: Homograph{
+			Host: "www.аррӏе.com", Skeleton: "www.apple.com", Imitates: "www.apple.com", MixedScript: []string{},
+		}},
+		// Cyrillic "а" in Latin
+		{input: "login.pаypal.com", expect: Homograph{
+			Host: "login.pаypal.com", Skeleton: "login.paypal.com", Imitates: "login.paypal.com",
+			MixedScript: []string{"pаypal"},
+		}},
+		// Greek "ο" with the en dash in Latin
+		{input: "gοοgle–ads.com", expect: Homograph{
+			Host: "gοοgle–ads.com", Skeleton: "google-ads.com", Imitates: "google-ads.com",
+			MixedScript: []string{"gοοgle–ads"},
+		}},
+		// Mixed but not imitating due to the diacritic
+		{input: "pаypäl.com", expect: Homograph{
+			Host: "pаypäl.com", Skeleton: "paypäl.com", MixedScript: []string{"pаypäl"},
+		}},
+		// Fullwidth forms left by the failure of the conversion
+		{input: "ｅxample_.com", expect: Homograph{
+			Host: "ｅxample_.com", Skeleton: "example_.com", Imitates: "example_.com", MixedScript: []string{},
+		}},
+		// Not suspicious
+		{input: "www.example.com", expect: Homograph{
+			Host: "www.example.com", Skeleton: "www.example.com", MixedScript: []string{},
+		}},
+		{input: "göpher.com", expect: Homograph{
+			Host: "göpher.com", Skeleton: "göpher.com", MixedScript: []string{},
+		}},
+		{input: "пример.рф", expect: Homograph{
+			Host: "пример.рф", Skeleton: "пpимep.pф", MixedScript: []string{},
+		}},
+		// Latin with Chinese, Japanese and Korean is allowed
+		{input: "日本語abc.jp", expect: Homograph{
+			Host: "日本語abc.jp", Skeleton: "日本語abc.jp", MixedScript: []string{},
+		}},
+		{input: "ドメイン名例.jp", expect: Homograph{
+			Host: "ドメイン名例.jp", Skeleton: "ドメイン名例.jp", MixedScript: []string{},
+		}},
+		{input: "한국abc.kr", expect: Homograph{
+			Host: "한국abc.kr", Skeleton: "한국abc.kr", MixedScript: []string{},
+		}},
+	} {
+		homograph := Confusable(test.input)
+
+		assert.Equal(t, test.expect, homograph, "input: %q", test.input)
+		assert.Equal(t, test.expect.Imitates != "" || len(test.expect.MixedScript) > 0, homograph.IsSuspicious(),
+			"input: %q", test.input)
+	}
+}
+
+func Test_labelScripts(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"Cyrillic", "Greek", "Latin"}, labelScripts("aаα"))
+	assert.Equal(t, []string{}, labelScripts("123-́\U000E0000"), "Common, Inherited and unassigned should be skipped")
+	assert.Equal(t, []string{"Ethiopic", "Latin"}, labelScripts("aሀ"), "the scripts not listed first should be found")
+}
+
+func Test_scriptsOrdered(t *testing.T) {
+	t.Parallel()
+
+	require.Len(t, scriptsOrdered, len(unicode.Scripts)-2, "all but Common and Inherited")
+	assert.Equal(t, "Latin", scriptsOrdered[0].name)
+	assert.Same(t, unicode.Latin, scriptsOrdered[0].table)
+
+	for index, script := range scriptsOrdered {
+		assert.NotContains(t, []string{"Common", "Inherited"}, script.name)
+		assert.Equal(t, index, slices.IndexFunc(scriptsOrdered, func(other namedScript) bool {
+			return other.name == script.name
+		}), "script %s should be listed once", script.name)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Validator (RuleConfusable and RuleMixedScript)
+// ----------------------------------------------------------------------------
+
+func TestValidator_Validate_confusable(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := "0.0.0.0 www.xn--80ak6aa92e.com login.pаypal.com göpher.com\n"
+
+	assert.Equal(t, SeverityWarning, validator.Severity(RuleConfusable))
+	assert.Equal(t, SeverityWarning, validator.Severity(RuleMixedScript))
+
+	report := validator.ValidateString(input)
+
+	assert.True(t, report.OK(), "warnings should not fail the validation")
+	assert.Equal(t, []string{
+		`1:9: HP060 "www.xn--80ak6aa92e.com" ("www.аррӏе.com") imitates "www.apple.com"`,
+		`1:32: HP060 "login.pаypal.com" imitates "login.paypal.com"`,
+		`1:38: HP061 label "pаypal" mixes the scripts Cyrillic, Latin`,
+	}, summarizeIssues(report.Issues))
+}
@@ -0,0 +1,10 @@
+package hostpital
+
+// ConversionFailure is a host name left as is for failing to convert. Such as
+// "xn--invalid.com" for Parser.OutputUnicode.
+type ConversionFailure struct {
+	Err      error  // Reason of the failure.
+	Source   string // Path of the file the host name was read from. Empty if unknown.
+	Hostname string // The host name left as is.
+	Line     int    // Line number of the host name. Starts from 1. Zero if unknown.
+}
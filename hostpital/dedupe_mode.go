@@ -0,0 +1,124 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: DedupeMode
+// ----------------------------------------------------------------------------
+
+// DedupeMode is the strategy of the Parser to remove duplicates.
+type DedupeMode int
+
+const (
+	// DedupeNone keeps all the lines as is.
+	DedupeNone DedupeMode = iota
+	// DedupeLine removes the lines that are exactly the same as a previous line.
+	DedupeLine
+	// DedupeHost keeps the first occurrence of each host name regardless of the
+	// IP address. Lines without host names left are removed.
+	DedupeHost
+	// DedupeHostIP keeps the first occurrence of each host name per IP address.
+	// Lines without host names left are removed.
+	DedupeHostIP
+)
+
+// namesDedupeMode is the list of the names of DedupeMode in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesDedupeMode = []string{"none", "line", "host", "host-ip"}
+
+// ParseDedupeMode returns the DedupeMode of the given name. Such as "none",
+// "line", "host" and "host-ip".
+func ParseDedupeMode(name string) (DedupeMode, error) {
+	index := slices.Index(namesDedupeMode, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return DedupeNone, errors.Errorf("unknown dedupe mode %#v. It must be one of: %s",
+			name, strings.Join(namesDedupeMode, ", "))
+	}
+
+	return DedupeMode(index), nil
+}
+
+// String returns the name of the mode.
+func (m DedupeMode) String() string {
+	if m < 0 || int(m) >= len(namesDedupeMode) {
+		return "invalid"
+	}
+
+	return namesDedupeMode[m]
+}
+
+// ----------------------------------------------------------------------------
+//  Type: deduper
+// ----------------------------------------------------------------------------
+
+// deduper holds the state to remove duplicates during a single parse.
+type deduper struct {
+	seen       map[string]struct{}
+	mode       DedupeMode
+	numDropped int
+}
+
+// newDeduper returns a new deduper of the given mode.
+func newDeduper(mode DedupeMode) *deduper {
+	return &deduper{
+		seen: map[string]struct{}{},
+		mode: mode,
+	}
+}
+
+// filter returns the entry without the duplicates seen before. The line is
+// the rendered entry. It returns false if nothing is left to output. Lines
+// without host names, such as comments and empty lines, are always kept.
+func (d *deduper) filter(entry Entry, line string) (Entry, bool) {
+	if d.mode == DedupeNone || len(entry.Hostnames) == 0 {
+		return entry, true
+	}
+
+	if d.mode == DedupeLine {
+		if d.isSeen(line) {
+			d.numDropped++
+
+			return entry, false
+		}
+
+		return entry, true
+	}
+
+	hostsLeft := make([]string, 0, len(entry.Hostnames))
+
+	for _, host := range entry.Hostnames {
+		key := strings.ToLower(host)
+		if d.mode == DedupeHostIP {
+			key = entry.IP + " " + key
+		}
+
+		if d.isSeen(key) {
+			d.numDropped++
+
+			continue
+		}
+
+		hostsLeft = append(hostsLeft, host)
+	}
+
+	entry.Hostnames = hostsLeft
+
+	return entry, len(hostsLeft) > 0
+}
+
+// isSeen returns true if the key was seen before and marks it as seen.
+func (d *deduper) isSeen(key string) bool {
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+
+	d.seen[key] = struct{}{}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  ParseDedupeMode()
+// ----------------------------------------------------------------------------
+
+func TestParseDedupeMode(t *testing.T) {
+	t.Parallel()
+
+	for name, expect := range map[string]DedupeMode{
+		"none":      DedupeNone,
+		"line":      DedupeLine,
+		" Host ":    DedupeHost,
+		"host-ip":   DedupeHostIP,
+		"HOST-IP\n": DedupeHostIP,
+	} {
+		actual, err := ParseDedupeMode(name)
+
+		require.NoError(t, err, "name: %q", name)
+		assert.Equal(t, expect, actual, "name: %q", name)
+	}
+}
+
+func TestParseDedupeMode_unknown(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseDedupeMode("unknown")
+
+	require.Error(t, err)
+	assert.Equal(t, DedupeNone, mode, "it should return DedupeNone on error")
+	assert.Contains(t, err.Error(), `unknown dedupe mode "unknown"`)
+	assert.Contains(t, err.Error(), "none, line, host, host-ip", "it should list the available modes")
+}
+
+// ----------------------------------------------------------------------------
+//  DedupeMode.String()
+// ----------------------------------------------------------------------------
+
+func TestDedupeMode_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "host-ip", DedupeHostIP.String())
+	assert.Equal(t, "invalid", DedupeMode(-1).String())
+	assert.Equal(t, "invalid", DedupeMode(99).String())
+}
@@ -0,0 +1,572 @@
+package hostpital
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: NodeKind
+// ----------------------------------------------------------------------------
+
+// NodeKind is the kind of a line in a Document.
+type NodeKind int
+
+const (
+	// NodeBlank is an empty or white space only line.
+	NodeBlank NodeKind = iota
+	// NodeComment is a comment line.
+	NodeComment
+	// NodeEntry is a line with an IP address and host names. Such as "127.0.0.1 localhost".
+	NodeEntry
+	// NodeUnknown is a line that is not a hosts entry. Such as a host name without an IP address.
+	NodeUnknown
+)
+
+// String returns the name of the kind.
+func (k NodeKind) String() string {
+	switch k {
+	case NodeBlank:
+		return "blank"
+	case NodeComment:
+		return "comment"
+	case NodeEntry:
+		return "entry"
+	case NodeUnknown:
+		return "unknown"
+	}
+
+	return "invalid"
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Node
+// ----------------------------------------------------------------------------
+
+// Node is a line of a Document.
+type Node struct {
+	Text  string   // Original line without the line break.
+	EOL   string   // Original line break. "\n", "\r\n" or empty if the line had none.
+	Entry Entry    // Parsed entry of the line. Only set if Kind is NodeEntry.
+	Kind  NodeKind // Kind of the line.
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Document
+// ----------------------------------------------------------------------------
+
+// Document is a hosts file parsed into nodes of lines. Unlike Parser, it keeps
+// the original bytes of each line such as comments, blank lines, indentation
+// and alignment. Therefore, writing back an unchanged Document reproduces the
+// input byte-for-byte.
+//
+// Use ParseDocument() or ReadDocument() to create a new Document due to the
+// default values.
+type Document struct {
+	Source          string // Path of the file the document was read from. Empty if unknown.
+	Nodes           []Node // Lines of the document in order.
+	MaxHostsPerLine int    // If greater than 0, AddHost does not append to lines with this many host names (default: 0).
+	AppendAlias     bool   // If true, AddHost appends the names to an existing line of the same IP (default: true).
+}
+
+// ----------------------------------------------------------------------------
+//  Constructors
+// ----------------------------------------------------------------------------
+
+// ParseDocument reads all the lines from the given io.Reader and returns them
+// as a Document.
+func ParseDocument(input io.Reader) (*Document, error) {
+	doc := new(Document)
+	doc.AppendAlias = true
+
+	reader := bufio.NewReader(input)
+
+	for numLine := 1; ; numLine++ {
+		line, err := reader.ReadString(byte(LF))
+		if line != "" {
+			doc.Nodes = append(doc.Nodes, newNode(line, numLine))
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the document")
+		}
+	}
+
+	return doc, nil
+}
+
+// ReadDocument reads the file from pathFile and returns it as a Document.
+func ReadDocument(pathFile string) (*Document, error) {
+	pathFile = filepath.Clean(pathFile)
+
+	osFile, err := osOpen(pathFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the file")
+	}
+
+	defer func() {
+		_ = osFile.Close()
+	}()
+
+	doc, err := ParseDocument(osFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the file")
+	}
+
+	doc.Source = pathFile
+
+	for index := range doc.Nodes {
+		doc.Nodes[index].Entry.Source = pathFile
+	}
+
+	return doc, nil
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// AddHost adds the given host names to the IP address. Names that are already
+// mapped to the IP address are ignored.
+//
+// If AppendAlias is true, the names are appended to the last line of the same
+// IP address. Otherwise, or if there is no such line, a new line is added to
+// the end of the document. Each name must be IDNA2008 compatible after the
+// conversion to ASCII/punycode, the same as Parser does.
+func (d *Document) AddHost(ipAddr string, hostNames ...string) error {
+	if !IsIPAddress(ipAddr) {
+		return errors.Errorf("%#v is not a valid IP address", ipAddr)
+	}
+
+	if len(hostNames) == 0 {
+		return errors.New("no host names given")
+	}
+
+	namesNew := make([]string, 0, len(hostNames))
+
+	for _, hostName := range hostNames {
+		hostASCII, err := toIDNA2008(nil, hostName)
+		if err != nil {
+			return errors.Wrap(err, "invalid host name")
+		}
+
+		if !d.hasHost(ipAddr, hostASCII) && !slices.Contains(namesNew, hostASCII) {
+			namesNew = append(namesNew, hostASCII)
+		}
+	}
+
+	if len(namesNew) == 0 {
+		return nil
+	}
+
+	if index := d.findLineToAppend(ipAddr, len(namesNew)); index >= 0 {
+		node := &d.Nodes[index]
+		node.setEntry(node.Entry.IP, append(slices.Clone(node.Entry.Hostnames), namesNew...))
+
+		return nil
+	}
+
+	d.appendNode(ipAddr, namesNew)
+
+	return nil
+}
+
+// RemoveHost removes the host name from all the lines. Lines that have no host
+// names left are removed. It returns an error if the host name is not found.
+func (d *Document) RemoveHost(hostName string) error {
+	found := false
+	nodesLeft := make([]Node, 0, len(d.Nodes))
+
+	for _, node := range d.Nodes {
+		if node.Kind == NodeEntry && slices.ContainsFunc(node.Entry.Hostnames, isSameHostFunc(hostName)) {
+			found = true
+
+			hostsLeft := slices.DeleteFunc(slices.Clone(node.Entry.Hostnames), isSameHostFunc(hostName))
+			if len(hostsLeft) == 0 {
+				continue // remove the line
+			}
+
+			node.setEntry(node.Entry.IP, hostsLeft)
+		}
+
+		nodesLeft = append(nodesLeft, node)
+	}
+
+	if !found {
+		return errors.Errorf("host name %#v not found", hostName)
+	}
+
+	d.Nodes = nodesLeft
+
+	return nil
+}
+
+// RemoveIP removes all the lines of the given IP address. It returns an error
+// if no lines are found.
+func (d *Document) RemoveIP(ipAddr string) error {
+	lenBefore := len(d.Nodes)
+
+	d.Nodes = slices.DeleteFunc(d.Nodes, func(node Node) bool {
+		return node.Kind == NodeEntry && isSameIP(node.Entry.IP, ipAddr)
+	})
+
+	if len(d.Nodes) == lenBefore {
+		return errors.Errorf("IP address %#v not found", ipAddr)
+	}
+
+	return nil
+}
+
+// RenameHost renames the host name in all the lines. The new name must be
+// IDNA2008 compatible after the conversion to ASCII/punycode. It returns an
+// error if the old host name is not found.
+func (d *Document) RenameHost(hostNameOld, hostNameNew string) error {
+	hostASCII, err := toIDNA2008(nil, hostNameNew)
+	if err != nil {
+		return errors.Wrap(err, "invalid host name")
+	}
+
+	found := false
+
+	for index := range d.Nodes {
+		node := &d.Nodes[index]
+		if node.Kind != NodeEntry || !slices.ContainsFunc(node.Entry.Hostnames, isSameHostFunc(hostNameOld)) {
+			continue
+		}
+
+		found = true
+		hostsNew := make([]string, 0, len(node.Entry.Hostnames))
+
+		for _, host := range node.Entry.Hostnames {
+			if isSameHost(host, hostNameOld) {
+				host = hostASCII
+			}
+
+			if !slices.ContainsFunc(hostsNew, isSameHostFunc(host)) {
+				hostsNew = append(hostsNew, host)
+			}
+		}
+
+		node.setEntry(node.Entry.IP, hostsNew)
+	}
+
+	if !found {
+		return errors.Errorf("host name %#v not found", hostNameOld)
+	}
+
+	return nil
+}
+
+// SetIP changes the IP address of the host name in all the lines. If the line
+// has other host names, the line is split and the host name is moved to a new
+// line right after it. It returns an error if the host name is not found.
+func (d *Document) SetIP(hostName, ipAddr string) error {
+	if !IsIPAddress(ipAddr) {
+		return errors.Errorf("%#v is not a valid IP address", ipAddr)
+	}
+
+	found := false
+
+	for index := 0; index < len(d.Nodes); index++ {
+		node := &d.Nodes[index]
+		if node.Kind != NodeEntry || !slices.ContainsFunc(node.Entry.Hostnames, isSameHostFunc(hostName)) {
+			continue
+		}
+
+		found = true
+
+		if len(node.Entry.Hostnames) == 1 {
+			node.setEntry(ipAddr, node.Entry.Hostnames)
+
+			continue
+		}
+
+		hostMoved := node.Entry.Hostnames[slices.IndexFunc(node.Entry.Hostnames, isSameHostFunc(hostName))]
+		node.setEntry(node.Entry.IP, slices.DeleteFunc(slices.Clone(node.Entry.Hostnames), isSameHostFunc(hostName)))
+
+		nodeNew := d.newEntryNode(node.indent(), ipAddr, []string{hostMoved}, node.EOL)
+
+		d.Nodes = slices.Insert(d.Nodes, index+1, nodeNew)
+		index++ // skip the inserted line
+	}
+
+	if !found {
+		return errors.Errorf("host name %#v not found", hostName)
+	}
+
+	return nil
+}
+
+// String returns the document as a string.
+func (d *Document) String() string {
+	var builder strings.Builder
+
+	_, _ = d.WriteTo(&builder)
+
+	return builder.String()
+}
+
+// WriteTo writes the document to the given io.Writer. It implements the
+// io.WriterTo interface.
+func (d *Document) WriteTo(output io.Writer) (int64, error) {
+	lines := make([]string, len(d.Nodes))
+
+	for index, node := range d.Nodes {
+		lines[index] = node.Text + node.EOL
+	}
+
+	return writeLines(output, lines...)
+}
+
+// appendNode adds a new entry line to the end of the document.
+func (d *Document) appendNode(ipAddr string, hostNames []string) {
+	eol := string(LF)
+
+	if len(d.Nodes) > 0 {
+		last := &d.Nodes[len(d.Nodes)-1]
+		if last.EOL == "" {
+			last.EOL = d.lineBreak()
+		}
+
+		eol = last.EOL
+	}
+
+	d.Nodes = append(d.Nodes, d.newEntryNode("", ipAddr, hostNames, eol))
+}
+
+// findLineToAppend returns the index of the last entry line of the IP address
+// that can have numHosts more host names. It returns -1 if not found.
+func (d *Document) findLineToAppend(ipAddr string, numHosts int) int {
+	if !d.AppendAlias {
+		return -1
+	}
+
+	for index := len(d.Nodes) - 1; index >= 0; index-- {
+		node := d.Nodes[index]
+		if node.Kind != NodeEntry || !isSameIP(node.Entry.IP, ipAddr) {
+			continue
+		}
+
+		if d.MaxHostsPerLine > 0 && len(node.Entry.Hostnames)+numHosts > d.MaxHostsPerLine {
+			continue
+		}
+
+		return index
+	}
+
+	return -1
+}
+
+// hasHost returns true if the host name is mapped to the IP address.
+func (d *Document) hasHost(ipAddr, hostName string) bool {
+	return slices.ContainsFunc(d.Nodes, func(node Node) bool {
+		return node.Kind == NodeEntry &&
+			isSameIP(node.Entry.IP, ipAddr) &&
+			slices.ContainsFunc(node.Entry.Hostnames, isSameHostFunc(hostName))
+	})
+}
+
+// lineBreak returns the line break used the most in the document. It defaults
+// to LF.
+func (d *Document) lineBreak() string {
+	numCRLF := 0
+
+	for _, node := range d.Nodes {
+		if node.EOL == string(CR)+string(LF) {
+			numCRLF++
+		} else if node.EOL == string(LF) {
+			numCRLF--
+		}
+	}
+
+	if numCRLF > 0 {
+		return string(CR) + string(LF)
+	}
+
+	return string(LF)
+}
+
+// newEntryNode returns a new entry line. The gap between the IP address and
+// the host names follows the first entry line of the document.
+func (d *Document) newEntryNode(indent, ipAddr string, hostNames []string, eol string) Node {
+	gapIP := " "
+
+	for _, nodeExisting := range d.Nodes {
+		if nodeExisting.Kind == NodeEntry {
+			gapIP, _, _ = nodeExisting.layout()
+
+			break
+		}
+	}
+
+	node := Node{
+		Text: indent + ipAddr + gapIP + hostNames[0],
+		EOL:  eol,
+		Kind: NodeEntry,
+	}
+
+	node.setEntry(ipAddr, hostNames)
+
+	return node
+}
+
+// indent returns the leading white spaces of the line.
+func (n *Node) indent() string {
+	return n.Text[:len(n.Text)-len(strings.TrimLeft(n.Text, Cutset))]
+}
+
+// layout returns the gap after the IP address, the gap between host names and
+// the trailing part of the line including the comment.
+func (n *Node) layout() (string, string, string) {
+	body, _, hasComment := strings.Cut(n.Text, string(DelimComnt))
+	trailer := n.Text[len(strings.TrimRight(body, Cutset)):]
+
+	if !hasComment {
+		trailer = ""
+	}
+
+	body = strings.TrimSpace(body)
+	gaps := []string{}
+
+	for body != "" {
+		posGap := strings.IndexAny(body, Cutset)
+		if posGap < 0 {
+			break
+		}
+
+		rest := strings.TrimLeft(body[posGap:], Cutset)
+		gaps = append(gaps, body[posGap:len(body)-len(rest)])
+		body = rest
+	}
+
+	gapIP, gapHost := " ", " "
+
+	if len(gaps) > 0 {
+		gapIP = gaps[0]
+	}
+
+	if len(gaps) > 1 {
+		gapHost = gaps[1]
+	}
+
+	return gapIP, gapHost, trailer
+}
+
+// setEntry updates the entry of the line and renders the line keeping the
+// indentation, the gaps and the comment of the original line.
+func (n *Node) setEntry(ipAddr string, hostNames []string) {
+	gapIP, gapHost, trailer := n.layout()
+
+	n.Entry.IP = ipAddr
+	n.Entry.Hostnames = hostNames
+	n.Text = n.indent() + ipAddr + gapIP + strings.Join(hostNames, gapHost) + trailer
+	n.Entry.Raw = n.Text
+}
+
+// ----------------------------------------------------------------------------
+//  Functions (Private)
+// ----------------------------------------------------------------------------
+
+// isSameHost returns true if the host names are the same regardless of the
+// case and the Unicode/punycode representation.
+func isSameHost(hostA, hostB string) bool {
+	return normalizeHost(hostA) == normalizeHost(hostB)
+}
+
+// isSameHostFunc returns a function that reports whether the given host name
+// is the same as hostName. Suitable for the slices package.
+func isSameHostFunc(hostName string) func(string) bool {
+	hostNorm := normalizeHost(hostName)
+
+	return func(host string) bool {
+		return normalizeHost(host) == hostNorm
+	}
+}
+
+// isSameIP returns true if the IP addresses are the same. Such as "::1" and
+// "0:0:0:0:0:0:0:1". The IPv4-mapped IPv6 addresses are the same as the IPv4
+// ones. It returns false if either of them is not an IP address.
+func isSameIP(ipA, ipB string) bool {
+	addrA, errA := netip.ParseAddr(ipA)
+	addrB, errB := netip.ParseAddr(ipB)
+
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return addrA.Unmap() == addrB.Unmap()
+}
+
+// normalizeHost returns the host name in lower case ASCII/punycode if possible.
+func normalizeHost(hostName string) string {
+	if hostASCII, err := TransformToASCII(hostName); err == nil {
+		return hostASCII
+	}
+
+	return strings.ToLower(hostName)
+}
+
+// newNode returns a Node of the given line. The line may end with a line break.
+func newNode(line string, numLine int) Node {
+	node := Node{Text: line}
+
+	switch {
+	case strings.HasSuffix(line, string(CR)+string(LF)):
+		node.EOL = string(CR) + string(LF)
+	case strings.HasSuffix(line, string(LF)):
+		node.EOL = string(LF)
+	}
+
+	node.Text = strings.TrimSuffix(line, node.EOL)
+
+	switch {
+	case strings.TrimSpace(node.Text) == "":
+		node.Kind = NodeBlank
+	case IsCommentLine(node.Text):
+		node.Kind = NodeComment
+	default:
+		node.Kind = NodeUnknown
+
+		if entry, ok := parseHostsLine(node.Text); ok {
+			node.Kind = NodeEntry
+			node.Entry = entry
+		}
+	}
+
+	node.Entry.Raw = node.Text
+	node.Entry.Line = numLine
+
+	return node
+}
+
+// parseHostsLine parses the line as hosts(5) format as is. It returns false if
+// the line does not begin with an IP address followed by at least one host name.
+func parseHostsLine(line string) (Entry, bool) {
+	body, comment, hasComment := strings.Cut(line, string(DelimComnt))
+	fields := strings.Fields(body)
+
+	if len(fields) < 2 || !IsIPAddress(fields[0]) {
+		return Entry{}, false
+	}
+
+	entry := Entry{
+		IP:        fields[0],
+		Hostnames: fields[1:],
+	}
+
+	if hasComment {
+		entry.Comment = comment
+	}
+
+	return entry, true
+}
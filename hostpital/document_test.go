@@ -0,0 +1,396 @@
+package hostpital
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  NodeKind.String()
+// ----------------------------------------------------------------------------
+
+func TestNodeKind_String(t *testing.T) {
+	t.Parallel()
+
+	for kind, expect := range map[NodeKind]string{
+		NodeBlank:    "blank",
+		NodeComment:  "comment",
+		NodeEntry:    "entry",
+		NodeUnknown:  "unknown",
+		NodeKind(99): "invalid",
+	} {
+		assert.Equal(t, expect, kind.String())
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  ParseDocument()
+// ----------------------------------------------------------------------------
+
+func TestParseDocument_round_trip(t *testing.T) {
+	t.Parallel()
+
+	for index, input := range []string{
+		"",
+		"\n",
+		"127.0.0.1\tlocalhost\n::1 localhost # IPv6\n",
+		"# comment\r\n\r\n   0.0.0.0    example.com   \r\nexample.com",
+		"\t# indented comment\n\xff\xfe invalid UTF-8\nno line break at the end",
+	} {
+		doc, err := ParseDocument(bytes.NewBufferString(input))
+		require.NoError(t, err, "test #%d", index+1)
+
+		var output bytes.Buffer
+
+		written, err := doc.WriteTo(&output)
+
+		require.NoError(t, err, "test #%d", index+1)
+		require.Equal(t, input, output.String(), "test #%d: it should reproduce the input byte-for-byte", index+1)
+		require.Equal(t, int64(len(input)), written, "test #%d", index+1)
+	}
+}
+
+func TestParseDocument_node_kinds(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseDocument(bytes.NewBufferString(
+		"# comment\n\n127.0.0.1  localhost loopback # in-line\r\nexample.com\n0.0.0.0\n",
+	))
+	require.NoError(t, err)
+	require.Len(t, doc.Nodes, 5)
+
+	kinds := make([]NodeKind, 0, len(doc.Nodes))
+	for _, node := range doc.Nodes {
+		kinds = append(kinds, node.Kind)
+	}
+
+	require.Equal(t, []NodeKind{NodeComment, NodeBlank, NodeEntry, NodeUnknown, NodeUnknown}, kinds)
+
+	entry := doc.Nodes[2].Entry
+
+	assert.Equal(t, "127.0.0.1", entry.IP)
+	assert.Equal(t, []string{"localhost", "loopback"}, entry.Hostnames)
+	assert.Equal(t, " in-line", entry.Comment)
+	assert.Equal(t, "127.0.0.1  localhost loopback # in-line", entry.Raw, "raw should not contain the line break")
+	assert.Equal(t, 3, entry.Line)
+	assert.Equal(t, "\r\n", doc.Nodes[2].EOL)
+}
+
+func TestParseDocument_read_error(t *testing.T) {
+	t.Parallel()
+
+	dummy := &dummyReader{
+		dummyFn: func(_ []byte) (int, error) {
+			return 0, errors.New("forced error")
+		},
+	}
+
+	doc, err := ParseDocument(dummy)
+
+	require.Error(t, err)
+	require.Nil(t, doc)
+	assert.Contains(t, err.Error(), "failed to read the document")
+	assert.Contains(t, err.Error(), "forced error")
+}
+
+// ----------------------------------------------------------------------------
+//  ReadDocument()
+// ----------------------------------------------------------------------------
+
+func TestReadDocument(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join("testdata", "default.txt")
+
+	expect, err := os.ReadFile(pathFile)
+	require.NoError(t, err)
+
+	doc, err := ReadDocument(pathFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, pathFile, doc.Source)
+	assert.Equal(t, pathFile, doc.Nodes[0].Entry.Source)
+	assert.Equal(t, string(expect), doc.String())
+}
+
+func TestReadDocument_errors(t *testing.T) {
+	t.Parallel()
+
+	{
+		doc, err := ReadDocument(filepath.Join(t.TempDir(), "not_exist"))
+
+		require.Error(t, err)
+		require.Nil(t, doc)
+		assert.Contains(t, err.Error(), "failed to open the file")
+	}
+	{
+		doc, err := ReadDocument(t.TempDir())
+
+		require.Error(t, err)
+		require.Nil(t, doc)
+		assert.Contains(t, err.Error(), "failed to parse the file")
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Document.WriteTo()
+// ----------------------------------------------------------------------------
+
+type dummyWriter struct{}
+
+func (d *dummyWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("forced error")
+}
+
+func TestDocument_WriteTo_write_error(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseDocument(bytes.NewBufferString("127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+
+	written, err := doc.WriteTo(new(dummyWriter))
+
+	require.Error(t, err)
+	assert.Zero(t, written)
+	assert.Contains(t, err.Error(), "failed to write to io.Writer")
+	assert.Contains(t, err.Error(), "forced error")
+}
+
+// ----------------------------------------------------------------------------
+//  Document.AddHost()
+// ----------------------------------------------------------------------------
+
+func TestDocument_AddHost(t *testing.T) {
+	t.Parallel()
+
+	const input = "# Local\r\n127.0.0.1\tlocalhost   # loopback\r\n"
+
+	{
+		doc := mustParseDocument(t, input)
+
+		err := doc.AddHost("127.0.0.1", "api.myapp.test", "LocalHost", "göpher.test")
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			"# Local\r\n127.0.0.1\tlocalhost api.myapp.test xn--gpher-jua.test   # loopback\r\n",
+			doc.String(), "it should append new names to the existing line keeping the layout")
+	}
+	{
+		doc := mustParseDocument(t, input)
+		doc.AppendAlias = false
+
+		err := doc.AddHost("127.0.0.1", "api.myapp.test")
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			input+"127.0.0.1\tapi.myapp.test\r\n",
+			doc.String(), "it should add a new line if AppendAlias is false")
+	}
+	{
+		doc := mustParseDocument(t, input)
+		doc.MaxHostsPerLine = 2
+
+		err := doc.AddHost("127.0.0.1", "api1.myapp.test", "api2.myapp.test")
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			input+"127.0.0.1\tapi1.myapp.test api2.myapp.test\r\n",
+			doc.String(), "it should add a new line if the existing line is full")
+	}
+	{
+		doc := mustParseDocument(t, input)
+
+		err := doc.AddHost("127.0.0.1", "localhost")
+		require.NoError(t, err)
+
+		assert.Equal(t, input, doc.String(), "it should ignore names already mapped to the IP address")
+	}
+}
+
+func TestDocument_AddHost_no_line_break_at_end(t *testing.T) {
+	t.Parallel()
+
+	{
+		doc := mustParseDocument(t, "")
+
+		require.NoError(t, doc.AddHost("::1", "localhost"))
+		assert.Equal(t, "::1 localhost\n", doc.String())
+	}
+	{
+		doc := mustParseDocument(t, "# comment\r\n# no line break")
+
+		require.NoError(t, doc.AddHost("::1", "localhost"))
+		assert.Equal(t, "# comment\r\n# no line break\r\n::1 localhost\r\n", doc.String(),
+			"it should follow the line break of the document")
+	}
+	{
+		doc := mustParseDocument(t, "# comment\r\n# comment\n# comment\n# no line break")
+
+		require.NoError(t, doc.AddHost("::1", "localhost"))
+		assert.Equal(t, "# comment\r\n# comment\n# comment\n# no line break\n::1 localhost\n", doc.String(),
+			"it should follow the line break used the most")
+	}
+}
+
+func TestDocument_AddHost_errors(t *testing.T) {
+	t.Parallel()
+
+	doc := mustParseDocument(t, "127.0.0.1 localhost\n")
+
+	for index, test := range []struct {
+		ipAddr    string
+		expectErr string
+		hostNames []string
+	}{
+		{ipAddr: "127.0.0.256", hostNames: []string{"example.com"}, expectErr: "is not a valid IP address"},
+		{ipAddr: "127.0.0.1", hostNames: nil, expectErr: "no host names given"},
+		{ipAddr: "127.0.0.1", hostNames: []string{"my_host.example.com"}, expectErr: "is not IDNA2008 compatible"},
+		{ipAddr: "127.0.0.1", hostNames: []string{"example.com", "xn--a.com"}, expectErr: "invalid host name"},
+		{ipAddr: "127.0.0.1", hostNames: []string{"example..com"}, expectErr: "is not IDNA2008 compatible"},
+	} {
+		err := doc.AddHost(test.ipAddr, test.hostNames...)
+
+		require.Error(t, err, "test #%d", index+1)
+		assert.Contains(t, err.Error(), test.expectErr, "test #%d", index+1)
+	}
+
+	assert.Equal(t, "127.0.0.1 localhost\n", doc.String(), "it should not change the document on error")
+}
+
+// ----------------------------------------------------------------------------
+//  Document.RemoveHost()
+// ----------------------------------------------------------------------------
+
+func TestDocument_RemoveHost(t *testing.T) {
+	t.Parallel()
+
+	doc := mustParseDocument(t, "127.0.0.1  localhost  api.test # dev\n# api.test\n0.0.0.0 API.test\n")
+
+	require.NoError(t, doc.RemoveHost("api.test"))
+	assert.Equal(t, "127.0.0.1  localhost # dev\n# api.test\n", doc.String(),
+		"it should remove the alias and the line without host names left")
+
+	err := doc.RemoveHost("xn--a.test") // invalid punycode is compared as is
+
+	require.Error(t, err, "it should error if the host name is not found")
+	assert.Contains(t, err.Error(), `host name "xn--a.test" not found`)
+}
+
+// ----------------------------------------------------------------------------
+//  Document.RemoveIP()
+// ----------------------------------------------------------------------------
+
+func TestDocument_RemoveIP(t *testing.T) {
+	t.Parallel()
+
+	doc := mustParseDocument(t, "::1 localhost\n127.0.0.1 localhost\n0:0:0:0:0:0:0:1 ip6-localhost\n")
+
+	require.NoError(t, doc.RemoveIP("::1"))
+	assert.Equal(t, "127.0.0.1 localhost\n", doc.String())
+
+	err := doc.RemoveIP("::1")
+
+	require.Error(t, err, "it should error if the IP address is not found")
+	assert.Contains(t, err.Error(), `IP address "::1" not found`)
+}
+
+// ----------------------------------------------------------------------------
+//  Document.RenameHost()
+// ----------------------------------------------------------------------------
+
+func TestDocument_RenameHost(t *testing.T) {
+	t.Parallel()
+
+	doc := mustParseDocument(t, "127.0.0.1\told.test new.test\n0.0.0.0 OLD.test # blocked\n")
+
+	require.NoError(t, doc.RenameHost("old.test", "new.test"))
+	assert.Equal(t, "127.0.0.1\tnew.test\n0.0.0.0 new.test # blocked\n", doc.String())
+
+	{
+		err := doc.RenameHost("old.test", "new.test")
+
+		require.Error(t, err, "it should error if the host name is not found")
+		assert.Contains(t, err.Error(), `host name "old.test" not found`)
+	}
+	{
+		err := doc.RenameHost("new.test", "new_host.test")
+
+		require.Error(t, err, "it should error if the new host name is invalid")
+		assert.Contains(t, err.Error(), "invalid host name")
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Document.SetIP()
+// ----------------------------------------------------------------------------
+
+func TestDocument_SetIP(t *testing.T) {
+	t.Parallel()
+
+	doc := mustParseDocument(t, "  127.0.0.1\tapi.test web.test # dev\r\n127.0.0.1 db.test\r\n")
+
+	require.NoError(t, doc.SetIP("web.test", "10.0.0.1"))
+	require.NoError(t, doc.SetIP("db.test", "10.0.0.2"))
+
+	assert.Equal(t,
+		"  127.0.0.1\tapi.test # dev\r\n  10.0.0.1\tweb.test\r\n10.0.0.2 db.test\r\n",
+		doc.String(), "it should split the line with aliases and change the IP of single host lines")
+
+	{
+		err := doc.SetIP("unknown.test", "10.0.0.3")
+
+		require.Error(t, err, "it should error if the host name is not found")
+		assert.Contains(t, err.Error(), `host name "unknown.test" not found`)
+	}
+	{
+		err := doc.SetIP("api.test", "10.0.0.256")
+
+		require.Error(t, err, "it should error if the IP address is invalid")
+		assert.Contains(t, err.Error(), "is not a valid IP address")
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  isSameIP()
+// ----------------------------------------------------------------------------
+
+func Test_isSameIP(t *testing.T) {
+	t.Parallel()
+
+	for index, test := range []struct {
+		ipA    string
+		ipB    string
+		expect bool
+	}{
+		{ipA: "::1", ipB: "0:0:0:0:0:0:0:1", expect: true},
+		{ipA: "::ffff:192.0.2.1", ipB: "192.0.2.1", expect: true},
+		{ipA: "fe80::1%eth0", ipB: "fe80::1%eth0", expect: true},
+		{ipA: "fe80::1%eth0", ipB: "fe80::1%eth1", expect: false},
+		{ipA: "127.0.0.1", ipB: "::1", expect: false},
+		{ipA: "127.0.0.1", ipB: "invalid", expect: false},
+		{ipA: "invalid", ipB: "invalid", expect: false},
+		{ipA: "", ipB: "", expect: false},
+	} {
+		assert.Equal(t, test.expect, isSameIP(test.ipA, test.ipB),
+			"test #%d: %#v vs %#v", index+1, test.ipA, test.ipB)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Helper functions
+// ----------------------------------------------------------------------------
+
+func mustParseDocument(t *testing.T, input string) *Document {
+	t.Helper()
+
+	doc, err := ParseDocument(bytes.NewBufferString(input))
+	require.NoError(t, err, "failed to parse the document for testing")
+
+	return doc
+}
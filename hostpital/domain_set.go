@@ -0,0 +1,358 @@
+package hostpital
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: DomainSet
+// ----------------------------------------------------------------------------
+
+// DomainSet is a set of host names to tell if a host name or any of its parents
+// is listed. Such as the blocklists. The host names are stored in a trie of the
+// labels in the reversed order as ReverseDNS does. For example,
+// "www.example.com" is stored as "com", "example" then "www" sharing the nodes
+// of the parents with the other host names.
+//
+// The host names are case-insensitive and the trailing dot is ignored. The zero
+// value is an empty set ready to use. It is not safe for concurrent use if any
+// goroutine modifies the set.
+type DomainSet struct {
+	root domainNode
+	size int
+}
+
+// domainNode is a label of the trie of DomainSet.
+type domainNode struct {
+	children map[string]*domainNode // Child nodes by label. Nil if none.
+	isListed bool                   // True if the host name ending at the node is in the set.
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// NewDomainSet returns a new DomainSet of the given host names.
+func NewDomainSet(hostNames ...string) *DomainSet {
+	set := new(DomainSet)
+
+	for _, hostName := range hostNames {
+		set.Add(hostName)
+	}
+
+	return set
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// Add adds the host name to the set. It returns false if the host name is empty
+// or already in the set.
+func (s *DomainSet) Add(hostName string) bool {
+	name := normalizeDomain(hostName)
+	if name == "" {
+		return false
+	}
+
+	node := &s.root
+
+	for _, label := range reversedLabels(name) {
+		child, ok := node.children[label]
+		if !ok {
+			if node.children == nil {
+				node.children = map[string]*domainNode{}
+			}
+
+			child = new(domainNode)
+			node.children[label] = child
+		}
+
+		node = child
+	}
+
+	if node.isListed {
+		return false
+	}
+
+	node.isListed = true
+	s.size++
+
+	return true
+}
+
+// All returns an iterator over the host names in the set in the order of
+// ReverseDNS. Such as "example.com", "example-a.com" then "www.example.com" as
+// "com.example" < "com.example-a" < "com.example.www".
+//
+// The set must not be modified during the iteration.
+func (s *DomainSet) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		s.walk(true, yield)
+	}
+}
+
+// Contains returns true if the host name is in the set. Unlike MatchSuffix, the
+// parents of the host name do not match.
+func (s *DomainSet) Contains(hostName string) bool {
+	node := s.find(normalizeDomain(hostName))
+
+	return node != nil && node.isListed
+}
+
+// Difference returns a new DomainSet of the host names in the set but not in
+// the other. A nil other is an empty set.
+func (s *DomainSet) Difference(other *DomainSet) *DomainSet {
+	difference := new(DomainSet)
+
+	s.walk(false, func(name string) bool {
+		if !other.Contains(name) {
+			difference.Add(name)
+		}
+
+		return true
+	})
+
+	return difference
+}
+
+// Intersect returns a new DomainSet of the host names in both the set and the
+// other. A nil other is an empty set.
+func (s *DomainSet) Intersect(other *DomainSet) *DomainSet {
+	intersection := new(DomainSet)
+
+	s.walk(false, func(name string) bool {
+		if other.Contains(name) {
+			intersection.Add(name)
+		}
+
+		return true
+	})
+
+	return intersection
+}
+
+// Len returns the number of the host names in the set.
+func (s *DomainSet) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return s.size
+}
+
+// MatchSuffix returns the most specific host name in the set which is the host
+// name itself or one of its parents. Such as "ads.example.com" for
+// "cdn.ads.example.com" if both "example.com" and "ads.example.com" are in the
+// set. It returns false if none of them is in the set.
+func (s *DomainSet) MatchSuffix(hostName string) (string, bool) {
+	name := normalizeDomain(hostName)
+	if s == nil || name == "" {
+		return "", false
+	}
+
+	node := &s.root
+	matched := -1
+
+	for begin, label := range reversedLabels(name) {
+		if node = node.children[label]; node == nil {
+			break
+		}
+
+		if node.isListed {
+			matched = begin
+		}
+	}
+
+	if matched < 0 {
+		return "", false
+	}
+
+	return name[matched:], true
+}
+
+// Remove removes the host name from the set. The nodes left with no host names
+// are removed as well. It returns false if the host name is not in the set.
+func (s *DomainSet) Remove(hostName string) bool {
+	name := normalizeDomain(hostName)
+	if name == "" {
+		return false
+	}
+
+	path := []*domainNode{&s.root}
+	labels := []string{}
+
+	for _, label := range reversedLabels(name) {
+		child, ok := path[len(path)-1].children[label]
+		if !ok {
+			return false
+		}
+
+		path = append(path, child)
+		labels = append(labels, label)
+	}
+
+	node := path[len(path)-1]
+	if !node.isListed {
+		return false
+	}
+
+	node.isListed = false
+	s.size--
+
+	// Prune the nodes from the leaf which are no longer in use.
+	for index := len(path) - 1; index > 0; index-- {
+		if path[index].isListed || len(path[index].children) > 0 {
+			break
+		}
+
+		delete(path[index-1].children, labels[index-1])
+	}
+
+	return true
+}
+
+// Union returns a new DomainSet of the host names in either the set or the
+// other. A nil other is an empty set.
+func (s *DomainSet) Union(other *DomainSet) *DomainSet {
+	union := new(DomainSet)
+	add := func(name string) bool {
+		union.Add(name)
+
+		return true
+	}
+
+	s.walk(false, add)
+	other.walk(false, add)
+
+	return union
+}
+
+// find returns the node of the normalized host name. It returns nil if the node
+// does not exist.
+func (s *DomainSet) find(name string) *domainNode {
+	if s == nil || name == "" {
+		return nil
+	}
+
+	node := &s.root
+
+	for _, label := range reversedLabels(name) {
+		if node = node.children[label]; node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+// walk passes the host names in the set to yield until it returns false. If
+// isSorted is true, the host names are passed in the order of ReverseDNS.
+func (s *DomainSet) walk(isSorted bool, yield func(name string) bool) {
+	if s == nil {
+		return
+	}
+
+	s.root.walk(nil, isSorted, yield)
+}
+
+// ----------------------------------------------------------------------------
+//  Methods (domainNode)
+// ----------------------------------------------------------------------------
+
+// walk passes the host names of the node and its descendants to yield. labels
+// are the labels from the root to the node. It returns false if yield stopped.
+func (n *domainNode) walk(labels []string, isSorted bool, yield func(name string) bool) bool {
+	if n.isListed && !yield(joinReversed(labels)) {
+		return false
+	}
+
+	return n.walkChildren(labels, isSorted, yield)
+}
+
+// walkChildren is like walk but passes the host names of the descendants only.
+func (n *domainNode) walkChildren(labels []string, isSorted bool, yield func(name string) bool) bool {
+	if !isSorted {
+		for label, child := range n.children {
+			if !child.walk(append(labels, label), isSorted, yield) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// The children are sorted by their labels and the descendants of them by
+	// the labels followed by the dot. So "example-a" comes between "example"
+	// and "www.example" as ReverseDNS does since "-" < ".".
+	keys := make([]string, 0, len(n.children)*2)
+	for label := range n.children {
+		keys = append(keys, label, label+string(DelimDNS))
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		label, isDescendants := strings.CutSuffix(key, string(DelimDNS))
+		child, childLabels := n.children[label], append(labels, label)
+
+		if isDescendants {
+			if !child.walkChildren(childLabels, isSorted, yield) {
+				return false
+			}
+
+			continue
+		}
+
+		if child.isListed && !yield(joinReversed(childLabels)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ----------------------------------------------------------------------------
+//  Functions (Private)
+// ----------------------------------------------------------------------------
+
+// joinReversed returns the host name of the labels in the reversed order. Such
+// as "www.example.com" for "com", "example" and "www".
+func joinReversed(labels []string) string {
+	var builder strings.Builder
+
+	for index := len(labels) - 1; index >= 0; index-- {
+		builder.WriteString(labels[index])
+
+		if index > 0 {
+			builder.WriteByte(DelimDNS)
+		}
+	}
+
+	return builder.String()
+}
+
+// normalizeDomain returns the host name in lowercase without the trailing dot.
+func normalizeDomain(hostName string) string {
+	return strings.ToLower(strings.TrimSuffix(hostName, string(DelimDNS)))
+}
+
+// reversedLabels returns an iterator over the labels of the host name from the
+// top level with the byte offsets where they begin. Such as (12, "com"),
+// (4, "example") then (0, "www") for "www.example.com".
+func reversedLabels(name string) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		end := len(name)
+
+		for {
+			begin := strings.LastIndexByte(name[:end], DelimDNS) + 1
+			if !yield(begin, name[begin:end]) || begin == 0 {
+				return
+			}
+
+			end = begin - 1
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------------------------------------------------------------------
+//  DomainSet.Add(), Contains() and Remove()
+// ----------------------------------------------------------------------------
+
+func TestDomainSet_Add_Contains_Remove(t *testing.T) {
+	t.Parallel()
+
+	var set DomainSet // The zero value should be ready to use
+
+	assert.True(t, set.Add("www.example.com"))
+	assert.True(t, set.Add("Example.COM."), "the parents should be added apart from the children")
+	assert.False(t, set.Add("example.com"), "the host names should be case-insensitive without the trailing dot")
+	assert.False(t, set.Add(""), "empty host names should be ignored")
+	assert.False(t, set.Add("."), "empty host names should be ignored")
+	assert.Equal(t, 2, set.Len())
+
+	assert.True(t, set.Contains("WWW.example.com"))
+	assert.True(t, set.Contains("example.com"))
+	assert.False(t, set.Contains("com"), "the nodes of the parents should not be listed")
+	assert.False(t, set.Contains("cdn.www.example.com"), "the children should not match")
+	assert.False(t, set.Contains(""))
+
+	assert.False(t, set.Remove("com"), "the nodes of the parents should not be removed")
+	assert.False(t, set.Remove("ads.example.com"))
+	assert.False(t, set.Remove(""))
+	assert.True(t, set.Remove("example.com"))
+	assert.False(t, set.Remove("example.com"))
+	assert.True(t, set.Contains("www.example.com"), "the children should be kept")
+	assert.Equal(t, 1, set.Len())
+
+	assert.True(t, set.Remove("www.example.com"))
+	assert.Equal(t, 0, set.Len())
+	assert.Empty(t, set.root.children, "the nodes no longer in use should be pruned")
+}
+
+// ----------------------------------------------------------------------------
+//  DomainSet.MatchSuffix()
+// ----------------------------------------------------------------------------
+
+func TestDomainSet_MatchSuffix(t *testing.T) {
+	t.Parallel()
+
+	set := NewDomainSet("example.com", "ads.example.com", "co.uk")
+
+	for input, expect := range map[string]string{
+		"cdn.ads.example.com": "ads.example.com",
+		"ADS.example.com":     "ads.example.com",
+		"www.example.com.":    "example.com",
+		"example.com":         "example.com",
+		"www.example.co.uk":   "co.uk",
+	} {
+		matched, ok := set.MatchSuffix(input)
+
+		assert.True(t, ok, "input: %q", input)
+		assert.Equal(t, expect, matched, "input: %q", input)
+	}
+
+	for _, input := range []string{"com", "example.net", "badexample.com", ""} {
+		matched, ok := set.MatchSuffix(input)
+
+		assert.False(t, ok, "input: %q", input)
+		assert.Empty(t, matched, "input: %q", input)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  DomainSet.All()
+// ----------------------------------------------------------------------------
+
+func TestDomainSet_All(t *testing.T) {
+	t.Parallel()
+
+	set := NewDomainSet("www.example.com", "example-a.com", "example.com", "example.net", "a..com")
+
+	assert.Equal(t, []string{
+		"a..com",
+		"example.com",
+		"example-a.com",
+		"www.example.com",
+		"example.net",
+	}, slices.Collect(set.All()), "it should be sorted as ReverseDNS")
+
+	for name := range set.All() {
+		assert.Equal(t, "a..com", name)
+
+		break
+	}
+}
+
+func TestDomainSet_All_reverse_dns_order(t *testing.T) {
+	t.Parallel()
+
+	// "a" is a prefix of "a-b". Sorting label by label gives "a.example",
+	// "x.a.example" then "a-b.example" which differs from ReverseDNS.
+	names := []string{
+		"x.a.example", "a-b.example", "a.example", "a_b.example", "a0.example",
+		"example", "b.a-b.example", "a..example", "a.example.net",
+	}
+
+	expect := slices.Clone(names)
+	slices.SortFunc(expect, func(a, b string) int {
+		return strings.Compare(ReverseDNS(a), ReverseDNS(b))
+	})
+
+	assert.Equal(t, expect, slices.Collect(NewDomainSet(names...).All()))
+	assert.Equal(t, []string{"a.example", "a-b.example", "x.a.example"},
+		slices.Collect(NewDomainSet("x.a.example", "a-b.example", "a.example").All()))
+}
+
+// ----------------------------------------------------------------------------
+//  DomainSet.Union(), Intersect() and Difference()
+// ----------------------------------------------------------------------------
+
+func TestDomainSet_set_algebra(t *testing.T) {
+	t.Parallel()
+
+	setA := NewDomainSet("a.example.com", "b.example.com", "example.net")
+	setB := NewDomainSet("b.example.com", "example.net", "c.example.org")
+
+	assert.Equal(t, []string{"a.example.com", "b.example.com", "example.net", "c.example.org"},
+		slices.Collect(setA.Union(setB).All()))
+	assert.Equal(t, []string{"b.example.com", "example.net"},
+		slices.Collect(setA.Intersect(setB).All()))
+	assert.Equal(t, []string{"a.example.com"},
+		slices.Collect(setA.Difference(setB).All()))
+	assert.Equal(t, 3, setA.Len(), "the sets should not be modified")
+	assert.Equal(t, 3, setB.Len(), "the sets should not be modified")
+
+	// Nil sets are empty
+	var setNil *DomainSet
+
+	assert.Equal(t, 3, setA.Union(setNil).Len())
+	assert.Equal(t, 0, setA.Intersect(setNil).Len())
+	assert.Equal(t, 3, setA.Difference(setNil).Len())
+	assert.Equal(t, 0, setNil.Len())
+	assert.False(t, setNil.Contains("example.net"))
+
+	_, ok := setNil.MatchSuffix("example.net")
+	assert.False(t, ok)
+	assert.Empty(t, slices.Collect(setNil.All()))
+
+	// Stop the walk without sorting
+	numVisited := 0
+
+	setA.walk(false, func(string) bool {
+		numVisited++
+
+		return false
+	})
+
+	assert.Equal(t, 1, numVisited, "it should stop when yield returns false")
+}
@@ -0,0 +1,50 @@
+package hostpital
+
+import "strings"
+
+// ----------------------------------------------------------------------------
+//  Type: Entry
+// ----------------------------------------------------------------------------
+
+// Entry is a single line of a hosts file parsed into its components.
+//
+// The fields reflect the settings of the Parser that produced it. For example,
+// if the Parser trims the IP address, IP will be empty or the value of
+// UseIPAddress. The original line is always kept in Raw.
+type Entry struct {
+	IP        string   // IP address of the line. Empty if none or trimmed.
+	Comment   string   // In-line comment without the leading "#". Empty if none or trimmed.
+	Source    string   // Path of the file the line was read from. Empty if unknown.
+	Raw       string   // Original line as read, without the line break.
+	Hostnames []string // Host names and aliases of the line.
+	Line      int      // Line number in the source. Starts from 1.
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// IsEmpty returns true if the entry has neither IP address, host names nor
+// comment.
+func (e Entry) IsEmpty() bool {
+	return e.IP == "" && len(e.Hostnames) == 0 && e.Comment == ""
+}
+
+// String returns the entry as a hosts file line. Such as:
+//
+//	"0.0.0.0 example.com www.example.com # comment"
+func (e Entry) String() string {
+	chunks := make([]string, 0, len(e.Hostnames)+2)
+
+	if e.IP != "" {
+		chunks = append(chunks, e.IP)
+	}
+
+	chunks = append(chunks, e.Hostnames...)
+
+	if e.Comment != "" {
+		chunks = append(chunks, string(DelimComnt)+e.Comment)
+	}
+
+	return strings.Join(chunks, " ")
+}
@@ -6,15 +6,26 @@ package hostpital_test
 
 import (
 	"bytes"
+	"context"
+	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"testing/fstest"
 
 	"github.com/Code-Hex/dd"
 	"github.com/KEINOS/go-hostpital/hostpital"
 )
 
+// testdata is the embedded file system of the test data to be used in the
+// examples of fs.FS.
+//
+//go:embed testdata
+var testdata embed.FS
+
 const (
 	hostExampleCom           = "example.com"
 	hostLeadingDotExampleCom = ".example.com"
@@ -23,6 +34,192 @@ const (
 	hostWWWExampleCom        = "www.example.com"
 )
 
+// ----------------------------------------------------------------------------
+//  Classify()
+// ----------------------------------------------------------------------------
+
+func ExampleClassify() {
+	for _, hostName := range []string{
+		"localhost.localdomain",
+		"printer.local",
+		"router.home.arpa",
+		"www.example.com",
+		"example.co.jp",
+	} {
+		fmt.Printf("%s: %s\n", hostName, hostpital.Classify(hostName))
+	}
+	// Output:
+	// localhost.localdomain: localhost
+	// printer.local: local
+	// router.home.arpa: home-arpa
+	// www.example.com: example
+	// example.co.jp: none
+}
+
+// ----------------------------------------------------------------------------
+//  Type: ConflictAnalyzer
+// ----------------------------------------------------------------------------
+
+func ExampleConflictAnalyzer() {
+	analyzer := hostpital.NewConflictAnalyzer()
+
+	// The sources are taken as concatenated in the order analyzed.
+	for _, source := range []struct{ name, hosts string }{
+		{name: "hosts", hosts: "127.0.0.1 localhost\n10.0.0.1 db.example.com\n"},
+		{name: "hosts.local", hosts: "::1 localhost\n10.0.0.2 db.example.com\n"},
+	} {
+		if err := analyzer.Analyze(source.name, strings.NewReader(source.hosts)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, conflict := range analyzer.Conflicts() {
+		fmt.Println(conflict.Hostname, "wins at", conflict.Winner())
+
+		for _, entry := range conflict.Entries {
+			fmt.Printf("  %s: %s\n", entry, entry.Address)
+		}
+	}
+	// Output:
+	// db.example.com wins at hosts:2
+	//   hosts:2: 10.0.0.1
+	//   hosts.local:2: 10.0.0.2
+}
+
+// ----------------------------------------------------------------------------
+//  Confusable()
+// ----------------------------------------------------------------------------
+
+func ExampleConfusable() {
+	for _, hostName := range []string{
+		"www.xn--80ak6aa92e.com", // Cyrillic lookalike of "www.apple.com"
+		"login.pаypal.com",       // Cyrillic "а" in Latin
+		"göpher.com",
+	} {
+		homograph := hostpital.Confusable(hostName)
+
+		fmt.Printf("%s: suspicious=%v imitates=%q mixed=%q\n",
+			homograph.Host, homograph.IsSuspicious(), homograph.Imitates, homograph.MixedScript)
+	}
+	// Output:
+	// www.аррӏе.com: suspicious=true imitates="www.apple.com" mixed=[]
+	// login.pаypal.com: suspicious=true imitates="login.paypal.com" mixed=["pаypal"]
+	// göpher.com: suspicious=false imitates="" mixed=[]
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Document
+// ----------------------------------------------------------------------------
+
+func ExampleParseDocument() {
+	hosts := "# Local development\n" +
+		"127.0.0.1\tlocalhost\n" +
+		"\n" +
+		"  127.0.0.1   api.myapp.test   # API server\n"
+
+	doc, err := hostpital.ParseDocument(strings.NewReader(hosts))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, node := range doc.Nodes {
+		fmt.Printf("%-7s %q\n", node.Kind, node.Entry.Hostnames)
+	}
+
+	// Unchanged documents are written back byte-for-byte
+	fmt.Println("same as input:", doc.String() == hosts)
+	// Output:
+	// comment []
+	// entry   ["localhost"]
+	// blank   []
+	// entry   ["api.myapp.test"]
+	// same as input: true
+}
+
+func ExampleDocument_AddHost() {
+	hosts := "# Local development\n" +
+		"127.0.0.1\tlocalhost        # loopback\n" +
+		"127.0.0.1\tapi.myapp.test   # API server\n"
+
+	doc, err := hostpital.ParseDocument(strings.NewReader(hosts))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Add an alias to the last line of the same IP address
+	if err := doc.AddHost("127.0.0.1", "web.myapp.test"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Move the alias to another IP address. The line will be split.
+	if err := doc.SetIP("web.myapp.test", "192.168.0.10"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Rename a host name. Unicode names are converted to punycode.
+	if err := doc.RenameHost("api.myapp.test", "äpi.myapp.test"); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := doc.WriteTo(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// # Local development
+	// 127.0.0.1	localhost        # loopback
+	// 127.0.0.1	xn--pi-uia.myapp.test   # API server
+	// 192.168.0.10	web.myapp.test
+}
+
+// ----------------------------------------------------------------------------
+//  Type: DomainSet
+// ----------------------------------------------------------------------------
+
+// This example blocks the host names if they or any of their parents are in the
+// blocklist.
+func ExampleDomainSet() {
+	parser := hostpital.NewParser()
+
+	entries, err := parser.ParseEntriesFrom(strings.NewReader(`0.0.0.0 ads.example.com
+0.0.0.0 tracker.example.net
+0.0.0.0 example.org
+`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blocklist := hostpital.NewDomainSet()
+
+	for _, entry := range entries {
+		for _, hostName := range entry.Hostnames {
+			blocklist.Add(hostName)
+		}
+	}
+
+	for _, hostName := range []string{"cdn.ads.example.com", "www.example.com", "www.example.org"} {
+		if listed, ok := blocklist.MatchSuffix(hostName); ok {
+			fmt.Printf("%s: blocked by %s\n", hostName, listed)
+
+			continue
+		}
+
+		fmt.Printf("%s: allowed\n", hostName)
+	}
+
+	// Sorted by the reversed labels
+	for hostName := range blocklist.Union(hostpital.NewDomainSet("www.example.com")).All() {
+		fmt.Println(hostName)
+	}
+	// Output:
+	// cdn.ads.example.com: blocked by ads.example.com
+	// www.example.com: allowed
+	// www.example.org: blocked by example.org
+	// ads.example.com
+	// www.example.com
+	// tracker.example.net
+	// example.org
+}
+
 // ----------------------------------------------------------------------------
 //  FileExists()
 // ----------------------------------------------------------------------------
@@ -72,10 +269,44 @@ func ExampleFindFile() {
 	// testdata/search_me/hosts/hosts
 }
 
+func ExampleFindFileSeq() {
+	pathDirSearch := filepath.Join("testdata", "search_me")
+
+	for file, err := range hostpital.FindFileSeq("hosts*", pathDirSearch) {
+		if err != nil {
+			log.Fatalf("failed to find files: %v", err)
+		}
+
+		fmt.Println(filepath.ToSlash(file))
+
+		break // Stop walking the directory at the first match
+	}
+	// Output:
+	// testdata/search_me/dir1/hosts
+}
+
 // ----------------------------------------------------------------------------
 //  IsCommentLine()
 // ----------------------------------------------------------------------------
 
+func ExampleFindFileFS() {
+	// Any fs.FS can be searched. Such as embed.FS, zip.Reader and fstest.MapFS.
+	foundFiles, err := hostpital.FindFileFS(testdata, "hosts*", "testdata/search_me")
+	if err != nil {
+		log.Fatalf("failed to find files: %v", err)
+	}
+
+	for _, file := range foundFiles {
+		fmt.Println(file)
+	}
+
+	// Output:
+	// testdata/search_me/dir1/hosts
+	// testdata/search_me/dir2/hosts.txt
+	// testdata/search_me/dir2/subdir2/hosts
+	// testdata/search_me/hosts/hosts
+}
+
 func ExampleIsCommentLine() {
 	for index, line := range []string{
 		"# This is a comment line",
@@ -274,6 +505,98 @@ func ExampleIsIPAddress() {
 	// IsIPAddress("0.0.0.0.0") --> false
 }
 
+// ----------------------------------------------------------------------------
+//  IsPublicSuffix()
+// ----------------------------------------------------------------------------
+
+func ExampleIsPublicSuffix() {
+	for _, hostName := range []string{
+		"co.uk",             // ICANN section
+		"github.io",         // Private section
+		"example.co.uk",     // Registrable domain
+		"localhost",         // Not listed
+		"www.ck",            // Exception of "*.ck"
+		"anything.ck",       // Wildcard of "*.ck"
+		"xn--55qx5d.cn",     // "公司.cn" in punycode
+		"CO.UK.",            // Case-insensitive with the trailing dot
+		"www.example.co.jp", // Subdomain
+	} {
+		fmt.Printf("%s: %v\n", hostName, hostpital.IsPublicSuffix(hostName))
+	}
+	// Output:
+	// co.uk: true
+	// github.io: true
+	// example.co.uk: false
+	// localhost: false
+	// www.ck: false
+	// anything.ck: true
+	// xn--55qx5d.cn: true
+	// CO.UK.: true
+	// www.example.co.jp: false
+}
+
+// ----------------------------------------------------------------------------
+//  Type: LabelValidator
+// ----------------------------------------------------------------------------
+
+func ExampleLabelValidator() {
+	validator := hostpital.NewLabelValidator()
+
+	for _, hostName := range []string{
+		"_dmarc.example.com", // service labels can begin with underscore
+		"foo_bar.example.com",
+		"-foo.example-.com",
+		"ab--cd.example.com",
+		"example.123",
+	} {
+		errs := validator.Validate(hostName)
+		if len(errs) == 0 {
+			fmt.Printf("%s: OK\n", hostName)
+		}
+
+		for _, err := range errs {
+			fmt.Printf("%s: %s at %d (%s)\n", hostName, err.Error(), err.Index, err.RuleID)
+		}
+	}
+	// Output:
+	// _dmarc.example.com: OK
+	// foo_bar.example.com: label "foo_bar": underscore outside service label at 0 (HP003)
+	// -foo.example-.com: label "-foo": label begins with hyphen at 0 (HP013)
+	// -foo.example-.com: label "example-": label ends with hyphen at 5 (HP013)
+	// ab--cd.example.com: label "ab--cd": label has "--" in the third and fourth positions but is not "xn--" at 0 (HP014)
+	// example.123: label "123": all-numeric top-level label at 8 (HP015)
+}
+
+// ----------------------------------------------------------------------------
+//  Type: LongLinePolicy
+// ----------------------------------------------------------------------------
+
+func ExampleLongLinePolicy() {
+	input := strings.NewReader(
+		"0.0.0.0 badboy1.example.com badboy2.example.com badboy3.example.com # comment\n",
+	)
+
+	parser := hostpital.NewParser()
+
+	parser.IDNACompatible = false // keep the lines as is
+	parser.TrimIPAddress = false
+	parser.TrimComment = false
+	parser.MaxLineLength = 50
+	parser.LongLine = hostpital.LongLineSplit // split instead of failing
+
+	err := parser.ParseReader(input, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Long lines:", parser.Stats().LongLines)
+	// Output:
+	// 0.0.0.0 badboy1.example.com badboy2.example.com
+	// 0.0.0.0 badboy3.example.com
+	// # comment
+	// Long lines: [1]
+}
+
 // ----------------------------------------------------------------------------
 //  Type: Parser
 // ----------------------------------------------------------------------------
@@ -303,6 +626,103 @@ func ExampleParser() {
 	// 0.0.0.0 dummy5.example.com dummy6.example.com
 }
 
+func ExampleParser_All() {
+	input := strings.NewReader(`# blocked hosts
+0.0.0.0 badboy1.example.com
+0.0.0.0 badboy2.example.com badboy3.example.com
+0.0.0.0 badboy4.example.com
+`)
+
+	parser := hostpital.NewParser()
+
+	for entry, err := range parser.All(input) {
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Stop reading at the first entry with aliases
+		if len(entry.Hostnames) > 1 {
+			fmt.Printf("line %d has aliases: %v\n", entry.Line, entry.Hostnames[1:])
+
+			break
+		}
+	}
+	// Output: line 3 has aliases: [badboy3.example.com]
+}
+
+func ExampleParser_ParseEntries() {
+	pathFile := filepath.Join("testdata", "default.txt")
+
+	parser := hostpital.NewParser()
+
+	// Keep the IP addresses and comments of the lines
+	parser.TrimIPAddress = false
+	parser.TrimComment = false
+	parser.OmitEmptyLine = true
+
+	entries, err := parser.ParseEntries(pathFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if len(entry.Hostnames) == 0 {
+			continue // skip comment and IP address only lines
+		}
+
+		fmt.Printf("line %d: IP=%q Hostnames=%q Comment=%q\n",
+			entry.Line, entry.IP, entry.Hostnames, entry.Comment)
+	}
+	// Output:
+	// line 4: IP="" Hostnames=["dummy1.example.com"] Comment=""
+	// line 7: IP="" Hostnames=["dummy2.example.com"] Comment=" in-line comment"
+	// line 9: IP="" Hostnames=["dummy3.example.com"] Comment=""
+	// line 13: IP="" Hostnames=["dummy4.example.com"] Comment=""
+	// line 14: IP="0.0.0.0" Hostnames=["127.0.0.1" "dummy5.example.com" "dummy6.example.com"] Comment=""
+}
+
+func ExampleParser_ParseEntriesFrom() {
+	hosts := `# this is a comment
+123.123.123.123 badboy1.example.com badboy2.example.com # in-line comment
+`
+
+	parser := hostpital.NewParser()
+
+	parser.UseIPAddress = "0.0.0.0"
+
+	entries, err := parser.ParseEntriesFrom(strings.NewReader(hosts))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		// String renders the entry as a hosts file line
+		fmt.Printf("line %d: %s\n", entry.Line, entry.String())
+	}
+	// Output:
+	// line 2: 0.0.0.0 badboy1.example.com badboy2.example.com
+}
+
+func ExampleParser_ParseFS() {
+	parser := hostpital.NewParser()
+
+	parser.SortAfterParse = true
+
+	// Parse the hosts file embedded in the binary
+	parsed, err := parser.ParseFS(testdata, "testdata/default.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print(parsed)
+	// Output:
+	// dummy1.example.com
+	// dummy2.example.com
+	// dummy3.example.com
+	// dummy4.example.com
+	// dummy5.example.com dummy6.example.com
+}
+
 func ExampleParser_ParseFileTo() {
 	pathFile := filepath.Join("testdata", "default.txt")
 
@@ -329,6 +749,41 @@ func ExampleParser_ParseFileTo() {
 	// dummy5.example.com dummy6.example.com
 }
 
+func ExampleParser_ParseReader() {
+	// Any io.Reader can be parsed. Such as os.Stdin, pipes or HTTP responses.
+	input := strings.NewReader(`# blocked hosts
+0.0.0.0 badboy1.example.com
+0.0.0.0 badboy2.example.com # comment
+`)
+
+	parser := hostpital.NewParser()
+	parser.UseIPAddress = "0.0.0.0"
+
+	// Since sorting is disabled, the lines are written to os.Stdout as they
+	// are parsed.
+	err := parser.ParseReader(input, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 0.0.0.0 badboy1.example.com
+	// 0.0.0.0 badboy2.example.com
+}
+
+func ExampleParser_ParseReaderContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel() // Cancel before parsing for the example
+
+	parser := hostpital.NewParser()
+
+	err := parser.ParseReaderContext(ctx, strings.NewReader("badboy1.example.com\n"), os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		fmt.Println(err)
+	}
+	// Output: canceled after 0 lines processed: context canceled
+}
+
 func ExampleParser_ParseString() {
 	hosts := `# this is a comment
 badboy5.example.com      badboy6.example.com
@@ -357,6 +812,82 @@ badboy5.example.com      badboy6.example.com
 	// 0.0.0.0 badboy5.example.com badboy6.example.com
 }
 
+func ExampleParser_Stats() {
+	hosts := `0.0.0.0 badboy1.example.com badboy2.example.com
+127.0.0.1 badboy2.example.com badboy3.example.com
+0.0.0.0 badboy1.example.com
+`
+
+	parser := hostpital.NewParser()
+
+	// Keep the first occurrence of each host name
+	parser.Deduplicate = hostpital.DedupeHost
+	parser.UseIPAddress = "0.0.0.0"
+
+	fmt.Println(strings.TrimSpace(parser.ParseString(hosts)))
+
+	// Stats of the last parse
+	stats := parser.Stats()
+
+	fmt.Println("lines read:", stats.LinesRead)
+	fmt.Println("duplicates dropped:", stats.Duplicates)
+	// Output:
+	// 0.0.0.0 badboy1.example.com badboy2.example.com
+	// 0.0.0.0 badboy3.example.com
+	// lines read: 4
+	// duplicates dropped: 2
+}
+
+func ExampleParser_outputUnicode() {
+	hosts := `0.0.0.0 xn--gpher-jua.com www.example.com
+0.0.0.0 xn--zz.com
+`
+
+	parser := hostpital.NewParser()
+
+	parser.IDNACompatible = false // Keep the invalid punycode for the example
+	parser.UseIPAddress = "0.0.0.0"
+	parser.OutputUnicode = true
+	parser.AnnotateIDN = true // Append the punycode as a comment
+
+	fmt.Println(strings.TrimSpace(parser.ParseString(hosts)))
+
+	// The host names failing to convert are left as is
+	for _, failure := range parser.Stats().Unconverted {
+		fmt.Printf("line %d: %s\n", failure.Line, failure.Hostname)
+	}
+	// Output:
+	// 0.0.0.0 göpher.com www.example.com # xn--gpher-jua.com
+	// 0.0.0.0 xn--zz.com
+	// line 2: xn--zz.com
+}
+
+// This example groups the host names by the registrable domains regardless of
+// the public suffixes.
+func ExampleParser_sortByDomain() {
+	hosts := `0.0.0.0 www.example.com
+0.0.0.0 ads.example.co.uk
+0.0.0.0 co.uk
+0.0.0.0 cdn.another.com
+0.0.0.0 example.co.uk
+0.0.0.0 tracker.example.net
+`
+
+	parser := hostpital.NewParser()
+
+	parser.UseIPAddress = "0.0.0.0"
+	parser.SortByDomain = true
+
+	fmt.Println(strings.TrimSpace(parser.ParseString(hosts)))
+	// Output:
+	// 0.0.0.0 co.uk
+	// 0.0.0.0 cdn.another.com
+	// 0.0.0.0 www.example.com
+	// 0.0.0.0 tracker.example.net
+	// 0.0.0.0 example.co.uk
+	// 0.0.0.0 ads.example.co.uk
+}
+
 // ----------------------------------------------------------------------------
 //  PickRandom()
 // ----------------------------------------------------------------------------
@@ -391,6 +922,76 @@ func ExamplePickRandom() {
 	// changed: true
 }
 
+// ----------------------------------------------------------------------------
+//  PublicSuffix()
+// ----------------------------------------------------------------------------
+
+func ExamplePublicSuffix() {
+	for _, hostName := range []string{
+		"www.example.co.uk",
+		"user.github.io",
+		"a.city.kawasaki.jp", // Exception of "*.kawasaki.jp"
+		"printer.local",      // The implicit rule "*"
+	} {
+		suffix, section := hostpital.PublicSuffix(hostName)
+
+		fmt.Printf("%s: %s (%s)\n", hostName, suffix, section)
+	}
+	// Output:
+	// www.example.co.uk: co.uk (ICANN)
+	// user.github.io: github.io (private)
+	// a.city.kawasaki.jp: kawasaki.jp (ICANN)
+	// printer.local: local (unlisted)
+}
+
+// ----------------------------------------------------------------------------
+//  Type: PublicSuffixList
+// ----------------------------------------------------------------------------
+
+func ExampleParsePublicSuffixList() {
+	// Use LoadPublicSuffixList to load a newer copy of the list from a file.
+	list, err := hostpital.ParsePublicSuffixList(strings.NewReader(`// ===BEGIN ICANN DOMAINS===
+uk
+co.uk
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+blogspot.co.uk
+// ===END PRIVATE DOMAINS===
+`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(list.RegistrableDomain("www.example.blogspot.co.uk"))
+
+	// Ignore the private section
+	list.ICANNOnly = true
+
+	fmt.Println(list.RegistrableDomain("www.example.blogspot.co.uk"))
+	// Output:
+	// example.blogspot.co.uk <nil>
+	// blogspot.co.uk <nil>
+}
+
+// ----------------------------------------------------------------------------
+//  RegistrableDomain()
+// ----------------------------------------------------------------------------
+
+func ExampleRegistrableDomain() {
+	// Unlike TrimDNSByLevel, the public suffixes are counted as the top level.
+	fmt.Println(hostpital.TrimDNSByLevel("a.b.example.co.uk", 1))
+	fmt.Println(hostpital.RegistrableDomain("a.b.example.co.uk"))
+
+	// Public suffixes have no registrable domain
+	_, err := hostpital.RegistrableDomain("co.uk")
+
+	fmt.Println(err)
+	// Output:
+	// co.uk
+	// example.co.uk <nil>
+	// "co.uk" is a public suffix
+}
+
 // ----------------------------------------------------------------------------
 //  ReverseDNS()
 // ----------------------------------------------------------------------------
@@ -405,6 +1006,39 @@ func ExampleReverseDNS() {
 	// www.example.com
 }
 
+// ----------------------------------------------------------------------------
+//  Type: SinkholePolicy
+// ----------------------------------------------------------------------------
+
+func ExampleSinkholePolicy() {
+	hosts := `0.0.0.0 ads.example.com
+203.0.113.7 login.example.com
+127.0.0.2 tracker.example.com
+`
+
+	// Allow the loopback range besides "0.0.0.0". With no arguments, the
+	// DefaultSinkholeAddresses are allowed.
+	policy, err := hostpital.NewSinkholePolicy("0.0.0.0", "127.0.0.0/8")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	parser := hostpital.NewParser()
+	parser.Sinkhole = policy
+
+	if err := parser.ParseReader(strings.NewReader(hosts), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, hijack := range parser.Stats().Hijacks {
+		fmt.Printf("dropped line %d: %s -> %s\n", hijack.Line, hijack.Hostnames, hijack.Address)
+	}
+	// Output:
+	// ads.example.com
+	// tracker.example.com
+	// dropped line 2: [login.example.com] -> 203.0.113.7
+}
+
 // ----------------------------------------------------------------------------
 //  TransformToASCII()
 // ----------------------------------------------------------------------------
@@ -418,6 +1052,28 @@ func ExampleTransformToASCII() {
 	// TransformToASCII("göpher.com") --> xn--gpher-jua.com <nil>
 }
 
+func ExampleTransformToASCIIWith() {
+	// Map the deviation characters as of IDNA2003 and allow the underscores.
+	// Set the same profile to Parser.IDNA and Validator.IDNA to agree on the
+	// host names.
+	profile := hostpital.NewIDNAProfile(hostpital.IDNALookup)
+	profile.Transitional = true
+	profile.STD3Rules = false
+
+	for _, hostName := range []string{"faß.de", "foo_bar.example.com"} {
+		hostASCII, err := hostpital.TransformToASCIIWith(profile, hostName)
+		fmt.Println(hostName, "-->", hostASCII, err)
+	}
+
+	// The registration profile rejects what lookup maps. Such as upper cases.
+	_, err := hostpital.TransformToASCIIWith(hostpital.NewIDNAProfile(hostpital.IDNARegistration), "Göpher.com")
+	fmt.Println(err)
+	// Output:
+	// faß.de --> fass.de <nil>
+	// foo_bar.example.com --> foo_bar.example.com <nil>
+	// failed to convert host name to ASCII with the registration profile: idna: disallowed rune U+0047
+}
+
 // ----------------------------------------------------------------------------
 //  TransformToUnicode()
 // ----------------------------------------------------------------------------
@@ -576,10 +1232,220 @@ func ExampleValidator() {
 	//   AllowTrailingSpace: false,
 	//   AllowUnderscore: false,
 	//   IDNACompatible: true,
+	//   Mode: 0,
 	//   isInitialized: true,
+	//   Conflicts: (*hostpital.ConflictAnalyzer)(nil),
+	//   IDNA: (*hostpital.IDNAProfile)(nil),
+	//   PublicSuffixes: (*hostpital.PublicSuffixList)(nil),
+	//   Sinkhole: (*hostpital.SinkholePolicy)(nil),
+	//   rules: ([]hostpital.Rule)(nil),
+	//   severities: (map[string]hostpital.Severity)(nil),
 	// }
 }
 
+// noWWWRule is a custom rule that reports hosts beginning with "www.".
+type noWWWRule struct{}
+
+func (noWWWRule) ID() string   { return "TEAM001" }
+func (noWWWRule) Name() string { return "no-www" }
+
+func (noWWWRule) Check(line hostpital.LineInfo) []hostpital.Issue {
+	issues := []hostpital.Issue{}
+	fields, columns := line.Fields()
+
+	for index, field := range fields {
+		if strings.HasPrefix(field, "www.") {
+			issues = append(issues, hostpital.Issue{
+				Message: "www prefix is redundant",
+				Column:  columns[index],
+			})
+		}
+	}
+
+	return issues
+}
+
+func ExampleValidator_AddRule() {
+	validator := hostpital.NewValidator()
+
+	// Plug in the team-specific rule as a warning
+	if err := validator.AddRule(noWWWRule{}, hostpital.SeverityWarning); err != nil {
+		log.Fatal(err)
+	}
+
+	// Custom rules come after the built-in rules
+	rules := validator.Rules()
+	custom := rules[len(rules)-1]
+
+	fmt.Println(custom.ID(), custom.Name(), validator.Severity(custom.Name()))
+
+	report := validator.ValidateString("0.0.0.0 www.example.com\n")
+
+	fmt.Println("OK:", report.OK())
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%d:%d: %s [%s] %s\n",
+			issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// TEAM001 no-www warning
+	// OK: true
+	// 1:9: warning [TEAM001] www prefix is redundant
+}
+
+func ExampleValidator_Fix() {
+	input := strings.NewReader(`  Example.COM
+münchen.de   foo_bar.example.com
+127.0.0.1
+`)
+
+	validator := hostpital.NewValidator()
+
+	var output bytes.Buffer
+
+	report, err := validator.Fix(input, &output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print(output.String())
+
+	for _, fix := range report.Fixes {
+		fmt.Printf("line %d: %q -> %q removed: %v fixed: %v\n",
+			fix.Line, fix.Before, fix.After, fix.Removed, fix.RuleIDs)
+	}
+
+	fmt.Println("OK:", report.OK())
+	// Output:
+	// example.com
+	// xn--mnchen-3ya.de
+	// line 1: "  Example.COM" -> "example.com" removed: false fixed: [HP002 HP017]
+	// line 2: "münchen.de   foo_bar.example.com" -> "xn--mnchen-3ya.de" removed: false fixed: [HP003]
+	// line 3: "127.0.0.1" -> "" removed: true fixed: [HP005]
+	// OK: true
+}
+
+func ExampleValidator_Issues() {
+	input := strings.NewReader(`example.com
+  indented.example.com
+127.0.0.1
+`)
+
+	validator := hostpital.NewValidator()
+
+	for issue := range validator.Issues(input) {
+		fmt.Printf("%d:%d: %s %s\n", issue.Line, issue.Column, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// 2:1: HP002 indent is not allowed
+	// 3:1: HP005 IP address only line is not allowed
+}
+
+func ExampleValidator_SetSeverity() {
+	validator := hostpital.NewValidator()
+
+	// Rules can be specified by ID or name
+	if err := validator.SetSeverity("trailing-space", hostpital.SeverityWarning); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := validator.SetSeverity(hostpital.RuleIndent, hostpital.SeverityOff); err != nil {
+		log.Fatal(err)
+	}
+
+	report := validator.ValidateString("  0.0.0.0 example.com  \n")
+
+	fmt.Println("OK:", report.OK())
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%d:%d: %s [%s] %s\n",
+			issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// OK: true
+	// 1:22: warning [HP001] trailing space is not allowed
+}
+
+func ExampleValidator_ValidateFS() {
+	fsys := fstest.MapFS{
+		"hosts": {Data: []byte("0.0.0.0 example.com\n0.0.0.0 example.net\n")},
+	}
+
+	validator := hostpital.NewValidator()
+
+	if validator.ValidateFS(fsys, "hosts") {
+		fmt.Println("The hostfile is valid.")
+	}
+	// Output: The hostfile is valid.
+}
+
+func ExampleValidator_Validate() {
+	input := strings.NewReader("0.0.0.0 example.com\n  0.0.0.0 example.net\n0.0.0.0 foo_bar.example.com\n")
+
+	validator := hostpital.NewValidator()
+
+	// Validate all the lines instead of stopping at the first invalid line
+	report := validator.Validate(input)
+
+	fmt.Println("OK:", report.OK())
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%d:%d: %s [%s] %s\n",
+			issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// OK: false
+	// 2:1: error [HP002] indent is not allowed
+	// 3:9: error [HP003] label "foo_bar": underscore outside service label
+}
+
+func ExampleValidator_Validate_hostsFile() {
+	input := strings.NewReader(`# hosts(5) allows comments
+127.0.0.1 localhost
+example.com
+127.0.0.2
+example.net 127.0.0.3
+`)
+
+	validator := hostpital.NewValidator()
+
+	// Validate with the semantics of hosts(5) instead of a domain list
+	validator.Mode = hostpital.ModeHostsFile
+
+	for issue := range validator.Issues(input) {
+		fmt.Printf("%d:%d: %s %s\n", issue.Line, issue.Column, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// 3:1: HP020 missing address
+	// 4:1: HP021 address without hostname
+	// 5:1: HP022 hostname in address column
+}
+
+func ExampleValidator_Validate_suppression() {
+	input := strings.NewReader(`# hostpital:ignore-next-line HP003
+0.0.0.0 foo_bar.example.com
+0.0.0.0 foo_bar.example.net # hostpital:ignore underscore
+# hostpital:disable ip-only
+127.0.0.1
+# hostpital:enable
+0.0.0.0 example.org # hostpital:ignore
+`)
+
+	validator := hostpital.NewValidator()
+
+	report := validator.Validate(input)
+
+	fmt.Println("OK:", report.OK())
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%d:%d: %s [%s] %s\n",
+			issue.Line, issue.Column, issue.Severity, issue.RuleID, issue.Message)
+	}
+	// Output:
+	// OK: true
+	// 7:21: warning [HP090] unused suppression "ignore"
+}
+
 func ExampleValidator_ValidateFile() {
 	// Validator with default settings
 	validator := hostpital.NewValidator()
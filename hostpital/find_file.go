@@ -1,7 +1,10 @@
 package hostpital
 
 import (
+	"context"
 	"io/fs"
+	"iter"
+	"path"
 	"path/filepath"
 
 	"github.com/pkg/errors"
@@ -15,31 +18,128 @@ import (
 //
 //	["/home/user/.ssh/hosts", "/home/user/.ssh/hosts.deny", "/home/user/.ssh/hosts.allow"]
 func FindFile(patternFile, pathDirSearch string) ([]string, error) {
-	patternSearch := patternFile
+	return FindFileContext(context.Background(), patternFile, pathDirSearch)
+}
+
+// FindFileContext is like FindFile but stops walking the directory promptly
+// when ctx is done. The error then wraps ctx.Err() with the number of files
+// walked.
+func FindFileContext(ctx context.Context, patternFile, pathDirSearch string) ([]string, error) {
+	return findFileWith(ctx, osWalker(), patternFile, pathDirSearch)
+}
+
+// FindFileFS is like FindFile but searches the given file system. Such as
+// embed.FS, zip.Reader and fstest.MapFS. The paths are slash-separated and
+// relative to the root of fsys as fs.FS requires. For example:
+//
+//	FindFileFS(fsys, "hosts*", ".")
+func FindFileFS(fsys fs.FS, patternFile, pathDirSearch string) ([]string, error) {
+	return findFileWith(context.Background(), fsWalker(fsys), patternFile, pathDirSearch)
+}
 
+// findFileWith is the implementation of FindFileContext and FindFileFS which
+// walks the directory with the given walker.
+func findFileWith(ctx context.Context, walker dirWalker, patternFile, pathDirSearch string) ([]string, error) {
 	findList := []string{}
-	err := filepath.WalkDir(pathDirSearch, func(path string, info fs.DirEntry, err error) error {
+
+	for pathFound, err := range findFileSeq(ctx, walker, patternFile, pathDirSearch) {
 		if err != nil {
-			return errors.Wrap(err, "failed filepath.WalkDir")
+			return findList, err
 		}
 
-		matched, errMatch := filepath.Match(patternSearch, filepath.Base(path))
-		if errMatch != nil {
-			return errors.Wrap(errMatch, "failed filepath.Match")
-		}
+		findList = append(findList, pathFound)
+	}
+
+	if len(findList) == 0 {
+		return findList, errors.Wrap(fs.ErrNotExist, "failed to search directory")
+	}
+
+	return findList, nil
+}
+
+// FindFileSeq returns an iterator over the file paths found under the given
+// directory. The paths are yielded as they are found and breaking the loop
+// stops walking the directory.
+//
+// Unlike FindFile, it yields nothing if no files are found. On error, an empty
+// path and the error are yielded last.
+func FindFileSeq(patternFile, pathDirSearch string) iter.Seq2[string, error] {
+	return findFileSeq(context.Background(), osWalker(), patternFile, pathDirSearch)
+}
+
+// findFileSeq is the implementation of FindFileSeq which walks the directory
+// with the given walker and stops walking when ctx is done.
+func findFileSeq(ctx context.Context, walker dirWalker, patternFile, pathDirSearch string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		numWalked := 0
+
+		err := walker.walkDir(pathDirSearch, func(pathFound string, info fs.DirEntry, err error) error {
+			if errCtx := ctx.Err(); errCtx != nil {
+				return errors.Wrapf(errCtx, "canceled after %d files walked", numWalked)
+			}
+
+			if err != nil {
+				return errors.Wrapf(err, "failed %s", walker.nameWalkDir)
+			}
+
+			numWalked++
+
+			matched, errMatch := walker.match(patternFile, walker.base(pathFound))
+			if errMatch != nil {
+				return errors.Wrapf(errMatch, "failed %s", walker.nameMatch)
+			}
+
+			if info.IsDir() || !matched {
+				return nil
+			}
+
+			if !yield(pathFound, nil) {
+				return fs.SkipAll
+			}
 
-		if info.IsDir() || !matched {
 			return nil
+		})
+		if err != nil {
+			yield("", errors.Wrap(err, "failed to search directory"))
 		}
+	}
+}
 
-		findList = append(findList, path)
+// ----------------------------------------------------------------------------
+//  Type: dirWalker
+// ----------------------------------------------------------------------------
 
-		return nil
-	})
+// dirWalker holds the functions to walk a directory and to match the names
+// found. The OS file system is walked with the OS paths, such as `C:\hosts`
+// on Windows, while fs.FS is walked with the slash-separated paths.
+type dirWalker struct {
+	walkDir     func(root string, fn fs.WalkDirFunc) error
+	match       func(pattern string, name string) (bool, error)
+	base        func(path string) string
+	nameWalkDir string // name of walkDir for the error messages
+	nameMatch   string // name of match for the error messages
+}
 
-	if err == nil && len(findList) == 0 {
-		err = fs.ErrNotExist
+// osWalker returns the dirWalker of the OS file system.
+func osWalker() dirWalker {
+	return dirWalker{
+		walkDir:     filepath.WalkDir,
+		match:       filepath.Match,
+		base:        filepath.Base,
+		nameWalkDir: "filepath.WalkDir",
+		nameMatch:   "filepath.Match",
 	}
+}
 
-	return findList, errors.Wrap(err, "failed to search directory")
+// fsWalker returns the dirWalker of the given fs.FS.
+func fsWalker(fsys fs.FS) dirWalker {
+	return dirWalker{
+		walkDir: func(root string, fn fs.WalkDirFunc) error {
+			return fs.WalkDir(fsys, root, fn)
+		},
+		match:       path.Match,
+		base:        path.Base,
+		nameWalkDir: "fs.WalkDir",
+		nameMatch:   "path.Match",
+	}
 }
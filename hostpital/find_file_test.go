@@ -1,8 +1,12 @@
 package hostpital
 
 import (
+	"context"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -38,3 +42,131 @@ func TestFindFile_serch_dir_is_empty(t *testing.T) {
 	require.Contains(t, err.Error(), "failed to search directory", "it should contain the error reason")
 	require.Empty(t, paths, "it should return empty list on error")
 }
+
+func TestFindFile_os_paths(t *testing.T) {
+	t.Parallel()
+
+	pathDir := t.TempDir()
+	pathFile := filepath.Join(pathDir, "sub", "hosts")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(pathFile), 0o755))
+	require.NoError(t, os.WriteFile(pathFile, []byte("0.0.0.0 example.com\n"), 0o600))
+
+	// The root is kept as given and joined with the OS path separator
+	for _, root := range []string{pathDir, pathDir + string(filepath.Separator)} {
+		paths, err := FindFile("hosts", root)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(root, "sub", "hosts")}, paths, "root: %q", root)
+	}
+}
+
+func TestFindFileContext_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	paths, err := FindFileContext(ctx, "*", "testdata")
+
+	require.Error(t, err, "it should error if the context is canceled")
+	require.ErrorIs(t, err, context.Canceled, "it should wrap the context error")
+	require.Contains(t, err.Error(), "canceled after 0 files walked", "it should contain the progress")
+	require.Empty(t, paths, "it should not walk any further")
+}
+
+func TestFindFileSeq(t *testing.T) {
+	t.Parallel()
+
+	found := []string{}
+
+	for path, err := range FindFileSeq("*.txt", "testdata") {
+		require.NoError(t, err)
+
+		found = append(found, path)
+	}
+
+	expect, err := FindFile("*.txt", "testdata")
+
+	require.NoError(t, err)
+	require.Equal(t, expect, found, "it should yield the same paths as FindFile")
+}
+
+func TestFindFileSeq_break(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+
+	for _, err := range FindFileSeq("*", "testdata") {
+		require.NoError(t, err)
+
+		count++
+
+		break
+	}
+
+	require.Equal(t, 1, count, "it should stop walking on break")
+}
+
+func TestFindFileSeq_no_files_matched(t *testing.T) {
+	t.Parallel()
+
+	for path, err := range FindFileSeq("unknownfile", t.TempDir()) {
+		require.Failf(t, "it should yield nothing", "path: %q, err: %v", path, err)
+	}
+}
+
+func TestFindFileSeq_error(t *testing.T) {
+	t.Parallel()
+
+	numYield := 0
+
+	for path, err := range FindFileSeq("hosts*", "") {
+		numYield++
+
+		require.Error(t, err)
+		require.Empty(t, path)
+		require.Contains(t, err.Error(), "failed filepath.WalkDir", "it should contain the wrapped error")
+	}
+
+	require.Equal(t, 1, numYield, "it should yield the error once")
+}
+
+func TestFindFileFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"lists/hosts":            {Data: []byte("example.com\n")},
+		"lists/sub/hosts.txt":    {Data: []byte("example.net\n")},
+		"lists/sub/other.txt":    {Data: []byte("example.org\n")},
+		"lists/hosts.d/README":   {Data: []byte("directory matches but not included\n")},
+		"elsewhere/hosts.backup": {Data: []byte("example.jp\n")},
+	}
+
+	paths, err := FindFileFS(fsys, "hosts*", "lists")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"lists/hosts", "lists/sub/hosts.txt"}, paths,
+		"it should return the slash-separated paths of the files matched")
+}
+
+func TestFindFileFS_bad_search_pattern(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"hosts": {Data: []byte("example.com\n")}}
+
+	paths, err := FindFileFS(fsys, "[]a]", ".")
+
+	require.Error(t, err, "it should error if the search pattern is mal-formed")
+	require.Contains(t, err.Error(), "failed path.Match", "it should contain the wrapped error")
+	require.Empty(t, paths, "it should return empty list on error")
+}
+
+func TestFindFileFS_no_files_matched(t *testing.T) {
+	t.Parallel()
+
+	paths, err := FindFileFS(fstest.MapFS{}, "hosts*", ".")
+
+	require.ErrorIs(t, err, fs.ErrNotExist, "the error should be fs.ErrNotExist if no files are found")
+	require.Empty(t, paths, "it should return empty list on error")
+}
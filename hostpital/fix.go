@@ -0,0 +1,147 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+)
+
+// fieldRules are the rules of which the issues are fixed by dropping the field
+// at the column of the issue.
+//
+//nolint:gochecknoglobals // read-only table
+var fieldRules = []string{
+	RuleUnderscore, RuleRFC6125, RuleIDNA2008, RuleLabelTooLong, RuleNameTooLong,
+	RuleLabelHyphen, RuleReservedLDH, RuleNumericTLD, RuleEmptyLabel, RuleSpecialUse,
+	RulePublicSuffix, RuleAddressInHostnameColumn,
+}
+
+// ----------------------------------------------------------------------------
+//  Type: Fix
+// ----------------------------------------------------------------------------
+
+// Fix is a change of a line made by Validator.Fix.
+type Fix struct {
+	Before  string   // The line as is.
+	After   string   // The fixed line. Empty if removed.
+	RuleIDs []string // IDs of the rules of which the issues were fixed. Empty if only normalized.
+	Line    int      // Line number of the line in the input. Starts from 1.
+	Removed bool     // True if the line was removed.
+}
+
+// ----------------------------------------------------------------------------
+//  Functions
+// ----------------------------------------------------------------------------
+
+// dropInvalidFields returns the line without the fields that have the issues
+// of SeverityError of fieldRules. It returns false if the line should be
+// removed. Such as the IP address only lines, the empty lines not allowed, the
+// lines pointing to the addresses not allowed as sinkholes and the lines left
+// with no host names.
+func dropInvalidFields(line string, issues []lineIssue) (string, bool) {
+	body, comment, hasComment := strings.Cut(line, string(DelimComnt))
+	fields := splitFields(body)
+	dropped := make([]bool, len(fields))
+	isDropped := false
+
+	for _, issue := range issues {
+		if issue.Severity != SeverityError {
+			continue
+		}
+
+		switch {
+		case issue.RuleID == RuleIPAddressOnly, issue.RuleID == RuleAddressWithoutHostname,
+			issue.RuleID == RuleEmptyLine, issue.RuleID == RuleSinkhole:
+			return "", false
+		case !slices.Contains(fieldRules, issue.RuleID):
+			continue
+		}
+
+		for index, field := range fields {
+			if field.index < issue.Column && issue.Column <= field.index+len(field.text) {
+				dropped[index] = true
+				isDropped = true
+			}
+		}
+	}
+
+	if !isDropped {
+		return line, true
+	}
+
+	kept := []string{}
+	hasHost := false
+
+	for index, field := range fields {
+		if !dropped[index] {
+			kept = append(kept, field.text)
+			hasHost = hasHost || !isHostsAddress(field.text)
+		}
+	}
+
+	if !hasHost {
+		return "", false
+	}
+
+	if hasComment {
+		kept = append(kept, string(DelimComnt)+comment)
+	}
+
+	return strings.Join(kept, " "), true
+}
+
+// fixedRuleIDs returns the unique rule IDs of the issues that are not in left.
+func fixedRuleIDs(issues []lineIssue, left []lineIssue) []string {
+	ruleIDs := []string{}
+
+	for _, issue := range issues {
+		isLeft := slices.ContainsFunc(left, func(leftIssue lineIssue) bool {
+			return leftIssue.RuleID == issue.RuleID
+		})
+
+		if !isLeft && !slices.Contains(ruleIDs, issue.RuleID) {
+			ruleIDs = append(ruleIDs, issue.RuleID)
+		}
+	}
+
+	return ruleIDs
+}
+
+// normalizeLine returns the line with the white spaces collapsed and the host
+// names in lowercase ASCII/punycode without the trailing dot. The host names
+// are converted with the profile if not nil. The comment is removed unless
+// allowed or it is a suppression directive. The comment kept is copied as is
+// but the trailing white spaces of the line.
+func normalizeLine(line string, allowComment bool, profile *IDNAProfile) string {
+	body, comment, hasComment := strings.Cut(line, string(DelimComnt))
+	fields := strings.Fields(body)
+
+	for index, field := range fields {
+		fields[index] = normalizeHostWith(profile, field)
+
+		if trimmed := strings.TrimSuffix(fields[index], string(DelimDNS)); trimmed != "" {
+			fields[index] = trimmed
+		}
+	}
+
+	if hasComment && (allowComment || strings.HasPrefix(strings.TrimSpace(comment), directivePrefix)) {
+		fields = append(fields, string(DelimComnt)+strings.TrimRight(comment, Cutset))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// normalizeHostWith is like normalizeHost but converts the host name with the
+// profile if not nil.
+func normalizeHostWith(profile *IDNAProfile, hostName string) string {
+	if profile == nil {
+		return normalizeHost(hostName)
+	}
+
+	hostLower := strings.ToLower(hostName)
+
+	if hostASCII, err := profile.ToASCII(hostLower); err == nil {
+		return hostASCII
+	}
+
+	return hostLower
+}
@@ -0,0 +1,183 @@
+package hostpital
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  Validator.Fix()
+// ----------------------------------------------------------------------------
+
+func TestValidator_Fix(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := heredoc.Doc(`
+		  Example.COM  
+		# comment
+		münchen.de
+		127.0.0.1
+		foo_bar.com   good.com
+
+		-bad.com
+		# hostpital:ignore-next-line HP003
+		foo_bar.net
+	`)
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader(input), &output)
+
+	require.NoError(t, err)
+	require.NoError(t, report.Err)
+	assert.Equal(t, heredoc.Doc(`
+		example.com
+		xn--mnchen-3ya.de
+		good.com
+
+		# hostpital:ignore-next-line HP003
+		foo_bar.net
+	`), output.String())
+	assert.Empty(t, report.Issues, "all the issues should be fixed")
+	assert.Equal(t, 9, report.LinesRead)
+	assert.Equal(t, []Fix{
+		{Line: 1, Before: "  Example.COM  ", After: "example.com", RuleIDs: []string{RuleIndent, RuleTrailingSpace, RuleUppercase}},
+		{Line: 2, Before: "# comment", RuleIDs: []string{RuleIDNA2008}, Removed: true},
+		{Line: 3, Before: "münchen.de", After: "xn--mnchen-3ya.de", RuleIDs: []string{}},
+		{Line: 4, Before: "127.0.0.1", RuleIDs: []string{RuleIPAddressOnly}, Removed: true},
+		{Line: 5, Before: "foo_bar.com   good.com", After: "good.com", RuleIDs: []string{RuleUnderscore}},
+		{Line: 7, Before: "-bad.com", RuleIDs: []string{RuleLabelHyphen}, Removed: true},
+	}, report.Fixes)
+}
+
+func TestValidator_Fix_hosts_file(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.Mode = ModeHostsFile
+
+	input := heredoc.Doc(`
+		127.0.0.1   LocalHost  # loopback
+		0.0.0.0 bad_host
+		0.0.0.0 example.com 192.0.2.1 -bad.example.com # comment
+		example.net
+	`)
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader(input), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		127.0.0.1 localhost # loopback
+		0.0.0.0 example.com # comment
+		example.net
+	`), output.String())
+	assert.Equal(t, []string{"4:1: HP020 missing address"}, summarizeIssues(report.Issues),
+		"issues that can not be fixed should be reported")
+	require.Len(t, report.Fixes, 3)
+	assert.True(t, report.Fixes[1].Removed, "lines left with no host names should be removed")
+	assert.Equal(t, []string{RuleLabelHyphen, RuleAddressInHostnameColumn}, report.Fixes[2].RuleIDs)
+}
+
+func TestValidator_Fix_not_allowed_empty_line(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowEmptyLine = false
+	validator.AllowComment = true
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader("\n\t# comment\t\nexample.com\n  \n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "# comment\nexample.com\n", output.String())
+	assert.Empty(t, report.Issues)
+	require.Len(t, report.Fixes, 3)
+	assert.Equal(t, []string{RuleEmptyLine}, report.Fixes[0].RuleIDs)
+	assert.Equal(t, []string{RuleIndent, RuleTrailingSpace}, report.Fixes[1].RuleIDs)
+	assert.Equal(t, []string{RuleIndent, RuleTrailingSpace, RuleEmptyLine}, report.Fixes[2].RuleIDs)
+}
+
+func TestValidator_Fix_keeps_comment(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowComment = true
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader("# keep   spacing\nExample.COM\t #  Keep\tAs IS \n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "# keep   spacing\nexample.com #  Keep\tAs IS\n", output.String(),
+		"the comments should be copied as is but the trailing spaces")
+	require.Len(t, report.Fixes, 1, "the comment line should not be fixed")
+	assert.Equal(t, 2, report.Fixes[0].Line)
+}
+
+func TestValidator_Fix_issues_left(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.SetSeverity(RuleUnderscore, SeverityWarning))
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader("Foo_Bar.com\n# hostpital:disable HP005\n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "foo_bar.com\n# hostpital:disable HP005\n", output.String(),
+		"the host names with warnings should be kept")
+	assert.Equal(t, []string{
+		"1:1: HP003 label \"foo_bar\": underscore outside service label",
+		`2:1: HP090 unused suppression "disable" of HP005`,
+	}, summarizeIssues(report.Issues), "the warnings and the unused suppressions should be left")
+	assert.True(t, report.OK())
+}
+
+func TestValidator_Fix_nil_writer(t *testing.T) {
+	t.Parallel()
+
+	report, err := NewValidator().Fix(strings.NewReader("example.com\n"), nil)
+
+	require.Error(t, err)
+	require.ErrorIs(t, report.Err, err)
+	assert.Contains(t, err.Error(), "the given io.Writer is nil")
+}
+
+func TestValidator_Fix_read_error(t *testing.T) {
+	t.Parallel()
+
+	dummy := &dummyReader{
+		dummyFn: func(_ []byte) (int, error) {
+			return 0, errors.New("forced error")
+		},
+	}
+
+	report, err := NewValidator().Fix(dummy, new(strings.Builder))
+
+	require.Error(t, err)
+	require.ErrorIs(t, report.Err, err)
+	assert.Contains(t, err.Error(), "failed to read from reader")
+	assert.Contains(t, err.Error(), "forced error")
+}
+
+func TestValidator_Fix_write_error(t *testing.T) {
+	t.Parallel()
+
+	report, err := NewValidator().Fix(strings.NewReader("example.com\nexample.net\n"), new(dummyWriter))
+
+	require.Error(t, err)
+	require.ErrorIs(t, report.Err, err)
+	assert.Contains(t, err.Error(), "failed to write to io.Writer")
+	assert.Equal(t, 1, report.LinesRead, "it should stop at the first write error")
+}
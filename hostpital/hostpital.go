@@ -26,8 +26,15 @@ const (
 	CR = int32(0x0d)
 	// Cutset is the set of characters for trimming white spaces.
 	Cutset = "\t\n\v\f\r "
+	// DefaultMaxLineLength is the default maximum length of a line in bytes for
+	// the Parser. Lines longer than this are handled by the LongLinePolicy.
+	DefaultMaxLineLength = 1024 * 1024
 )
 
+// sizeScanBatch is the number of lines the Parser parses concurrently at once
+// before writing them out. It bounds the memory usage when streaming.
+const sizeScanBatch = 1024
+
 // Function variables for testing.
 //
 //nolint:gochecknoglobals // Allow global var for testing
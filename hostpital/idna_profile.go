@@ -0,0 +1,195 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: IDNABase
+// ----------------------------------------------------------------------------
+
+// IDNABase is the predefined profile of golang.org/x/net/idna an IDNAProfile
+// begins with.
+type IDNABase int
+
+const (
+	// IDNALookup maps the host names for the lookup as of RFC 5891 5. Such as
+	// the case and the width. It is the profile of TransformToASCII.
+	IDNALookup IDNABase = iota
+	// IDNARegistration validates the host names for the registration as of RFC
+	// 5891 4 without mapping. It is the profile of IsCompatibleIDNA2008.
+	IDNARegistration
+	// IDNADisplay is like IDNALookup but meant to display the host names.
+	IDNADisplay
+	// IDNAPunycode converts the labels to punycode and back with no validation.
+	IDNAPunycode
+)
+
+// idnaProfiles caches the profiles of golang.org/x/net/idna built for the
+// settings of IDNAProfile. The settings are few, so the profiles are built
+// once per settings and reused since they are converted per host name.
+//
+//nolint:gochecknoglobals // cache of the built profiles
+var idnaProfiles = struct {
+	cache map[idnaProfileKey]*idna.Profile
+	mutx  sync.Mutex
+}{cache: map[idnaProfileKey]*idna.Profile{}}
+
+// idnaProfileKey is the key of idnaProfiles.
+type idnaProfileKey struct {
+	settings IDNAProfile
+	isLabel  bool // True if the profile is of labelProfile.
+}
+
+// namesIDNABase is the list of the names of IDNABase in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesIDNABase = []string{"lookup", "registration", "display", "punycode"}
+
+// ParseIDNABase returns the IDNABase of the given name. Such as "lookup",
+// "registration", "display" and "punycode".
+func ParseIDNABase(name string) (IDNABase, error) {
+	index := slices.Index(namesIDNABase, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return IDNALookup, errors.Errorf("unknown IDNA profile %#v. It must be one of: %s",
+			name, strings.Join(namesIDNABase, ", "))
+	}
+
+	return IDNABase(index), nil
+}
+
+// String returns the name of the base profile.
+func (b IDNABase) String() string {
+	if b < 0 || int(b) >= len(namesIDNABase) {
+		return "invalid"
+	}
+
+	return namesIDNABase[b]
+}
+
+// ----------------------------------------------------------------------------
+//  Type: IDNAProfile
+// ----------------------------------------------------------------------------
+
+// IDNAProfile is the policy to convert and validate the internationalized host
+// names. Set it to the Parser and the Validator to share the same policy. The
+// toggles override the ones of the Base.
+//
+// Note that BidiRule cannot be turned off for IDNARegistration and the toggles
+// take no effect on IDNAPunycode since it does no validation.
+//
+// It is recommended to use NewIDNAProfile() to create a new IDNAProfile due to
+// the default values of the Base.
+type IDNAProfile struct {
+	Base         IDNABase // Predefined profile to begin with (default: IDNALookup).
+	BidiRule     bool     // If true, the labels must satisfy the Bidi rule of RFC 5893 (default: true).
+	CheckHyphens bool     // If true, the labels cannot begin or end with hyphen nor have "--" in the third and fourth positions (default: true).
+	STD3Rules    bool     // If true, the ASCII characters are limited to the letters, digits and hyphen of STD3. Such as no underscore (default: true).
+	Transitional bool     // If true, the deviation characters are mapped as of IDNA2003. Such as "ß" to "ss". Not for IDNARegistration (default: false).
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// NewIDNAProfile returns a new IDNAProfile of the base with the same settings
+// as the predefined profile. Such as NewIDNAProfile(IDNALookup) converts as
+// TransformToASCII does.
+func NewIDNAProfile(base IDNABase) IDNAProfile {
+	isValidating := base != IDNAPunycode
+
+	return IDNAProfile{
+		Base:         base,
+		BidiRule:     isValidating,
+		CheckHyphens: isValidating,
+		STD3Rules:    isValidating,
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// ToASCII converts the host name to ASCII/punycode according to the profile.
+func (p IDNAProfile) ToASCII(hostName string) (string, error) {
+	hostASCII, err := p.profile().ToASCII(hostName)
+
+	return hostASCII, errors.Wrapf(err, "failed to convert host name to ASCII with the %s profile", p.Base)
+}
+
+// ToUnicode converts the host name to Unicode according to the profile.
+func (p IDNAProfile) ToUnicode(hostName string) (string, error) {
+	hostUnicode, err := p.profile().ToUnicode(hostName)
+
+	return hostUnicode, errors.Wrapf(err, "failed to convert host name to Unicode with the %s profile", p.Base)
+}
+
+// labelProfile returns the profile to check the characters of the labels. The
+// hyphens and the lengths are left to LabelValidator.
+func (p IDNAProfile) labelProfile() *idna.Profile {
+	return p.cached(true)
+}
+
+// labelValidator returns the LabelValidator relaxed as the profile. Such as
+// the underscores are allowed if STD3Rules is false.
+func (p IDNAProfile) labelValidator() *LabelValidator {
+	return &LabelValidator{
+		AllowHyphen:       !p.CheckHyphens,
+		AllowHyphenDouble: !p.CheckHyphens,
+		AllowUnderscore:   !p.STD3Rules,
+	}
+}
+
+// options returns the options of golang.org/x/net/idna for the profile.
+func (p IDNAProfile) options() []idna.Option {
+	options := []idna.Option{}
+
+	switch p.Base {
+	case IDNAPunycode:
+		return options
+	case IDNARegistration:
+		options = append(options, idna.ValidateForRegistration())
+	default:
+		options = append(options, idna.MapForLookup(), idna.Transitional(p.Transitional))
+	}
+
+	if p.BidiRule {
+		options = append(options, idna.BidiRule())
+	}
+
+	return append(options, idna.CheckHyphens(p.CheckHyphens), idna.StrictDomainName(p.STD3Rules))
+}
+
+// profile returns the profile of golang.org/x/net/idna for the settings.
+func (p IDNAProfile) profile() *idna.Profile {
+	return p.cached(false)
+}
+
+// cached returns the profile of golang.org/x/net/idna for the settings from
+// idnaProfiles. It is built on the first use. If isLabel is true, the profile
+// is of labelProfile.
+func (p IDNAProfile) cached(isLabel bool) *idna.Profile {
+	key := idnaProfileKey{settings: p, isLabel: isLabel}
+
+	idnaProfiles.mutx.Lock()
+	defer idnaProfiles.mutx.Unlock()
+
+	if profile, ok := idnaProfiles.cache[key]; ok {
+		return profile
+	}
+
+	options := p.options()
+	if isLabel {
+		options = append(options, idna.CheckHyphens(false), idna.VerifyDNSLength(false))
+	}
+
+	profile := idna.New(options...)
+	idnaProfiles.cache[key] = profile
+
+	return profile
+}
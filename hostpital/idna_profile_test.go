@@ -0,0 +1,219 @@
+package hostpital
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/idna"
+)
+
+// ----------------------------------------------------------------------------
+//  IDNABase
+// ----------------------------------------------------------------------------
+
+func TestParseIDNABase(t *testing.T) {
+	t.Parallel()
+
+	for _, base := range []IDNABase{IDNALookup, IDNARegistration, IDNADisplay, IDNAPunycode} {
+		parsed, err := ParseIDNABase(" " + strings.ToUpper(base.String()) + " ")
+
+		require.NoError(t, err)
+		assert.Equal(t, base, parsed)
+	}
+
+	parsed, err := ParseIDNABase("unknown")
+
+	require.Error(t, err)
+	assert.Equal(t, IDNALookup, parsed)
+	assert.Contains(t, err.Error(), `unknown IDNA profile "unknown". It must be one of: lookup, registration, display, punycode`)
+}
+
+func TestIDNABase_String_invalid(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "invalid", IDNABase(-1).String())
+	assert.Equal(t, "invalid", IDNABase(len(namesIDNABase)).String())
+}
+
+// ----------------------------------------------------------------------------
+//  NewIDNAProfile()
+// ----------------------------------------------------------------------------
+
+func TestNewIDNAProfile_same_as_predefined(t *testing.T) {
+	t.Parallel()
+
+	for base, predefined := range map[IDNABase]*idna.Profile{
+		IDNALookup:       idna.Lookup,
+		IDNARegistration: idna.Registration,
+		IDNADisplay:      idna.Display,
+		IDNAPunycode:     idna.Punycode,
+	} {
+		profile := NewIDNAProfile(base)
+
+		for _, hostName := range []string{
+			"www.example.com",
+			"www.GÖPHER.com",
+			"faß.de",
+			"xn--gpher-jua.com",
+			"xn--invalid.com",
+			"ab--c.example.com",
+			"-hyphen.example.com",
+			"foo_bar.example.com",
+			"ｅxample.com",
+			"אב.example.com", // Hebrew
+			"aא.example.com", // Breaks the Bidi rule
+			strings.Repeat("a", 64) + ".com",
+		} {
+			expectASCII, expectErr := predefined.ToASCII(hostName)
+			actualASCII, actualErr := profile.ToASCII(hostName)
+
+			assert.Equal(t, expectASCII, actualASCII, "%s: ToASCII(%q)", base, hostName)
+			assert.Equal(t, expectErr == nil, actualErr == nil, "%s: ToASCII(%q) error: %v", base, hostName, actualErr)
+
+			expectUnicode, expectErr := predefined.ToUnicode(hostName)
+			actualUnicode, actualErr := profile.ToUnicode(hostName)
+
+			assert.Equal(t, expectUnicode, actualUnicode, "%s: ToUnicode(%q)", base, hostName)
+			assert.Equal(t, expectErr == nil, actualErr == nil, "%s: ToUnicode(%q) error: %v", base, hostName, actualErr)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  IDNAProfile toggles
+// ----------------------------------------------------------------------------
+
+func TestIDNAProfile_toggles(t *testing.T) {
+	t.Parallel()
+
+	transitional := NewIDNAProfile(IDNALookup)
+	transitional.Transitional = true
+
+	hostASCII, err := TransformToASCIIWith(transitional, "faß.de")
+
+	require.NoError(t, err)
+	assert.Equal(t, "fass.de", hostASCII, "the deviation characters should be mapped")
+
+	relaxed := NewIDNAProfile(IDNARegistration)
+	relaxed.CheckHyphens = false
+	relaxed.STD3Rules = false
+
+	for _, hostName := range []string{"ab--c.example.com", "-hyphen.example.com", "foo_bar.example.com"} {
+		hostASCII, err := relaxed.ToASCII(hostName)
+
+		require.NoError(t, err, "host: %q", hostName)
+		assert.Equal(t, hostName, hostASCII)
+
+		_, err = NewIDNAProfile(IDNARegistration).ToASCII(hostName)
+
+		require.Error(t, err, "host: %q", hostName)
+		assert.Contains(t, err.Error(), "failed to convert host name to ASCII with the registration profile")
+	}
+
+	noBidi := NewIDNAProfile(IDNALookup)
+	noBidi.BidiRule = false
+
+	_, err = noBidi.ToASCII("aא.example.com")
+	require.NoError(t, err, "the Bidi rule should be off")
+}
+
+func TestIDNAProfile_cached(t *testing.T) {
+	t.Parallel()
+
+	profile := NewIDNAProfile(IDNALookup)
+
+	require.Same(t, profile.profile(), NewIDNAProfile(IDNALookup).profile(),
+		"the profile of the same settings should be reused")
+	require.NotSame(t, profile.profile(), profile.labelProfile())
+
+	profile.Transitional = true
+
+	require.NotSame(t, profile.profile(), NewIDNAProfile(IDNALookup).profile(),
+		"the profile should follow the change of the settings")
+}
+
+func TestTransformToUnicodeWith(t *testing.T) {
+	t.Parallel()
+
+	hostUnicode, err := TransformToUnicodeWith(NewIDNAProfile(IDNADisplay), "www.xn--gpher-jua.com")
+
+	require.NoError(t, err)
+	assert.Equal(t, "www.göpher.com", hostUnicode)
+
+	_, err = TransformToUnicodeWith(NewIDNAProfile(IDNARegistration), "ab--c.com")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to convert host name to Unicode with the registration profile")
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.IDNA and Validator.IDNA
+// ----------------------------------------------------------------------------
+
+func TestParser_IDNA(t *testing.T) {
+	t.Parallel()
+
+	input := "0.0.0.0 Faß.de foo_bar.example.com ab--c.example.com -hyphen.example.com\n"
+
+	parser := NewParser()
+	assert.Equal(t, "xn--fa-hia.de   \n", parser.ParseString(input))
+
+	profile := NewIDNAProfile(IDNALookup)
+	profile.Transitional = true
+	profile.STD3Rules = false
+	profile.CheckHyphens = false
+
+	parser.IDNA = &profile
+	assert.Equal(t, "fass.de foo_bar.example.com ab--c.example.com -hyphen.example.com\n", parser.ParseString(input))
+
+	registration := NewIDNAProfile(IDNARegistration)
+
+	parser.IDNA = &registration
+	assert.Equal(t, " xn--fa-hia.de\n", parser.ParseString("0.0.0.0 Faß.de faß.de\n"),
+		"registration should reject the uppercase")
+}
+
+func TestValidator_IDNA(t *testing.T) {
+	t.Parallel()
+
+	input := "0.0.0.0 Göpher.com foo_bar.example.com ab--c.example.com\n"
+	validator := NewValidator()
+
+	assert.Equal(t, []string{
+		`1:21: HP003 label "foo_bar": underscore outside service label`,
+		`1:9: HP010 "Göpher.com" is not IDNA2008 compatible: idna: disallowed rune U+0047`,
+		`1:41: HP014 label "ab--c": label has "--" in the third and fourth positions but is not "xn--"`,
+	}, summarizeIssues(validator.ValidateString(input).Issues))
+
+	profile := NewIDNAProfile(IDNALookup)
+	profile.STD3Rules = false
+	profile.CheckHyphens = false
+	validator.IDNA = &profile
+
+	assert.Empty(t, validator.ValidateString(input).Issues, "the lookup profile should map the case and relax the labels")
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader("0.0.0.0 FAß.de Foo_Bar.example.com\n"), &output)
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, "0.0.0.0 xn--fa-hia.de foo_bar.example.com\n", output.String(), "it should convert with the profile")
+
+	profile.Transitional = true
+	output.Reset()
+
+	_, err = validator.Fix(strings.NewReader("0.0.0.0 FAß.de\n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0 fass.de\n", output.String())
+
+	output.Reset()
+
+	_, err = validator.Fix(strings.NewReader("0.0.0.0 aא.example.com\n"), &output)
+
+	require.NoError(t, err)
+	assert.Empty(t, output.String(), "the lines left with no host names failing to convert should be removed")
+}
@@ -0,0 +1,8 @@
+package hostpital
+
+// IsPublicSuffix returns true if the host name is a public suffix listed in the
+// embedded Public Suffix List. Such as "com", "co.uk" and "github.io". Blocking
+// them in a hosts file blocks all the domains under them.
+func IsPublicSuffix(hostName string) bool {
+	return defaultPublicSuffixes().IsPublicSuffix(hostName)
+}
@@ -0,0 +1,15 @@
+package hostpital
+
+// ----------------------------------------------------------------------------
+//  Type: Issue
+// ----------------------------------------------------------------------------
+
+// Issue is a problem found by the Validator in a line of a hosts file.
+type Issue struct {
+	Message  string   // Reason of the issue.
+	RuleID   string   // ID of the rule violated. Such as "HP002". Empty if the input failed to be read.
+	Snippet  string   // The line with the issue as is.
+	Column   int      // Column of the issue in bytes. Starts from 1.
+	Line     int      // Line number of the issue. Starts from 1.
+	Severity Severity // Level of the issue.
+}
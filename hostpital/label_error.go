@@ -0,0 +1,25 @@
+package hostpital
+
+import "fmt"
+
+// ----------------------------------------------------------------------------
+//  Type: LabelError
+// ----------------------------------------------------------------------------
+
+// LabelError is a constraint of host names broken by a label found by the
+// LabelValidator.
+type LabelError struct {
+	Label  string // The label that broke the constraint. Empty if the constraint is of the whole name.
+	Reason string // The constraint broken. Such as "label ends with hyphen".
+	RuleID string // ID of the Validator rule of the constraint. Such as RuleLabelHyphen.
+	Index  int    // Byte offset of the label in the host name.
+}
+
+// Error implements the error interface.
+func (e LabelError) Error() string {
+	if e.Label == "" {
+		return e.Reason
+	}
+
+	return fmt.Sprintf("label %#v: %s", e.Label, e.Reason)
+}
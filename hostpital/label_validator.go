@@ -0,0 +1,146 @@
+package hostpital
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// MaxLabelLength is the maximum length of a label in octets. RFC 1035 2.3.4.
+	MaxLabelLength = 63
+	// MaxNameLength is the maximum length of a host name in octets without the
+	// trailing dot. RFC 1035 2.3.4.
+	MaxNameLength = 253
+)
+
+// ----------------------------------------------------------------------------
+//  Type: LabelValidator
+// ----------------------------------------------------------------------------
+
+// LabelValidator validates host names label by label and reports which label
+// broke which constraint. The lengths are of the ASCII/punycode form. The
+// characters of the labels are not checked. Use IsCompatibleIDNA2008 or
+// IsCompatibleRFC6125 for them.
+//
+// It is used by the Validator and the Parser.
+type LabelValidator struct {
+	AllowHyphen       bool // If true, labels can begin and end with hyphen (default: false).
+	AllowHyphenDouble bool // If true, labels can have "--" in the third and fourth positions without being valid punycode (default: false).
+	AllowNumericTLD   bool // If true, the top-level label can be all-numeric (default: false).
+	AllowUnderscore   bool // If true, labels can have underscore anywhere. Otherwise only the leading service labels such as "_sip._tcp" (default: false).
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// NewLabelValidator returns a new LabelValidator instance with the default
+// values.
+func NewLabelValidator() *LabelValidator {
+	return new(LabelValidator) // Set all to false
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// Validate returns the constraints broken by the labels of the host name. It
+// returns none if the host name is valid. A trailing dot of the host name is
+// allowed.
+//
+//nolint:cyclop // keep the constraints in one place
+func (lv *LabelValidator) Validate(hostName string) []LabelError {
+	name := strings.TrimSuffix(hostName, ".")
+	errs := []LabelError{}
+
+	if len(labelASCII(name)) > MaxNameLength {
+		errs = append(errs, LabelError{
+			Reason: fmt.Sprintf("name is longer than %d octets", MaxNameLength),
+			RuleID: RuleNameTooLong,
+		})
+	}
+
+	labels := strings.Split(name, ".")
+	isService := true // leading labels beginning with underscore
+	index := 0
+
+	for position, label := range labels {
+		add := func(ruleID, reason string) {
+			errs = append(errs, LabelError{Label: label, Reason: reason, RuleID: ruleID, Index: index})
+		}
+
+		switch {
+		case label == "":
+			add(RuleEmptyLabel, "empty label")
+		case len(labelASCII(label)) > MaxLabelLength:
+			add(RuleLabelTooLong, fmt.Sprintf("label is longer than %d octets", MaxLabelLength))
+		}
+
+		if !lv.AllowHyphen && strings.HasPrefix(label, "-") {
+			add(RuleLabelHyphen, "label begins with hyphen")
+		}
+
+		if !lv.AllowHyphen && len(label) > 1 && strings.HasSuffix(label, "-") {
+			add(RuleLabelHyphen, "label ends with hyphen")
+		}
+
+		if !lv.AllowHyphenDouble && isReservedLDH(label) {
+			if strings.HasPrefix(strings.ToLower(label), "xn--") {
+				add(RuleReservedLDH, `"xn--" label is not valid punycode`)
+			} else {
+				add(RuleReservedLDH, `label has "--" in the third and fourth positions but is not "xn--"`)
+			}
+		}
+
+		isService = isService && strings.HasPrefix(label, "_") && strings.Count(label, "_") == 1
+		if !lv.AllowUnderscore && !isService && strings.Contains(label, "_") {
+			add(RuleUnderscore, "underscore outside service label")
+		}
+
+		if !lv.AllowNumericTLD && position == len(labels)-1 && label != "" &&
+			strings.Trim(label, "0123456789") == "" {
+			add(RuleNumericTLD, "all-numeric top-level label")
+		}
+
+		index += len(label) + 1
+	}
+
+	return errs
+}
+
+// ----------------------------------------------------------------------------
+//  Functions
+// ----------------------------------------------------------------------------
+
+// isACELabel returns true if the label is a valid "xn--" label. Such as
+// "xn--gpher-jua".
+func isACELabel(label string) bool {
+	label = strings.ToLower(label)
+	if !strings.HasPrefix(label, "xn--") {
+		return false
+	}
+
+	_, err := idna.Punycode.ToUnicode(label)
+
+	return err == nil
+}
+
+// isReservedLDH returns true if the label is a R-LDH label that is not a valid
+// "xn--" label. R-LDH labels have "--" in the third and fourth positions.
+// RFC 5890 2.3.1.
+func isReservedLDH(label string) bool {
+	return len(label) >= 4 && label[2:4] == "--" && !isACELabel(label)
+}
+
+// labelASCII returns the ASCII/punycode form of the label or name. It returns
+// the given one as is if it fails to convert.
+func labelASCII(label string) string {
+	ascii, err := idna.Punycode.ToASCII(label)
+	if err != nil {
+		return label
+	}
+
+	return ascii
+}
@@ -0,0 +1,71 @@
+package hostpital
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelValidator_Validate(t *testing.T) {
+	t.Parallel()
+
+	// 30 runes but longer than 63 octets in punycode
+	cjkLabel := ""
+	for index := range 30 {
+		cjkLabel += string(rune(0x4E00 + index*97))
+	}
+
+	for index, test := range []struct {
+		hostName string
+		expect   []string
+	}{
+		{hostName: "example.com", expect: []string{}},
+		{hostName: "example.com.", expect: []string{}},
+		{hostName: "xn--gpher-jua.com", expect: []string{}},
+		{hostName: "_sip._tcp.example.com", expect: []string{}},
+		{hostName: "a.b-c.d", expect: []string{}},
+		{hostName: "-", expect: []string{`HP013: label "-": label begins with hyphen`}},
+		{hostName: "", expect: []string{"HP016: empty label"}},
+		{hostName: "_sip.foo_bar._tcp.com", expect: []string{
+			`HP003: label "foo_bar": underscore outside service label`,
+			`HP003: label "_tcp": underscore outside service label`,
+		}},
+		{hostName: "__sip.com", expect: []string{`HP003: label "__sip": underscore outside service label`}},
+		{hostName: "XN--ZZ-ZZZZ.com", expect: []string{`HP014: label "XN--ZZ-ZZZZ": "xn--" label is not valid punycode`}},
+		// The lengths are of the punycode
+		{hostName: cjkLabel + ".com", expect: []string{
+			`HP011: label "` + cjkLabel + `": label is longer than 63 octets`,
+		}},
+		{hostName: strings.Repeat("a", 63) + ".com", expect: []string{}},
+	} {
+		actual := []string{}
+		for _, err := range NewLabelValidator().Validate(test.hostName) {
+			actual = append(actual, err.RuleID+": "+err.Error())
+		}
+
+		assert.Equal(t, test.expect, actual, "test #%d failed: %q", index, test.hostName)
+	}
+}
+
+func TestLabelValidator_Validate_allow(t *testing.T) {
+	t.Parallel()
+
+	validator := &LabelValidator{
+		AllowHyphen:       true,
+		AllowHyphenDouble: true,
+		AllowNumericTLD:   true,
+		AllowUnderscore:   true,
+	}
+
+	assert.Empty(t, validator.Validate("-foo-.ab--cd.xn--zz-zzzz.foo_bar.123"),
+		"it should allow all by the configuration")
+}
+
+func TestLabelError_Error(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "empty label", LabelError{Reason: "empty label"}.Error())
+	assert.Equal(t, `label "-a": label begins with hyphen`,
+		LabelError{Label: "-a", Reason: "label begins with hyphen"}.Error())
+}
@@ -0,0 +1,57 @@
+package hostpital
+
+import "strings"
+
+// ----------------------------------------------------------------------------
+//  Type: LineInfo
+// ----------------------------------------------------------------------------
+
+// LineInfo is a line of a hosts file given to the Check method of the rules.
+type LineInfo struct {
+	Raw       string // The line as is.
+	Body      string // The trimmed line without the comment. Empty if the line is empty or a comment.
+	Offset    int    // Byte offset of Body in Raw.
+	IsComment bool   // True if the line is a comment line. Only if AllowComment of the Validator is true.
+}
+
+// Fields returns the white space separated fields of Body with their columns
+// in Raw. The columns are in bytes and start from 1.
+func (l LineInfo) Fields() ([]string, []int) {
+	fields := splitFields(l.Body)
+	texts := make([]string, len(fields))
+	columns := make([]int, len(fields))
+
+	for index, field := range fields {
+		texts[index] = field.text
+		columns[index] = l.Offset + field.index + 1
+	}
+
+	return texts, columns
+}
+
+// newLineInfo returns the LineInfo of the line. If allowComment is false, the
+// comments are left in Body as is.
+func newLineInfo(line string, allowComment bool) LineInfo {
+	trimmed := strings.TrimSpace(line)
+	info := LineInfo{
+		Raw:    line,
+		Body:   trimmed,
+		Offset: strings.Index(line, trimmed),
+	}
+
+	if !allowComment || trimmed == "" {
+		return info
+	}
+
+	if IsCommentLine(trimmed) {
+		info.Body = ""
+		info.IsComment = true
+
+		return info
+	}
+
+	body, _, _ := strings.Cut(trimmed, string(DelimComnt))
+	info.Body = strings.TrimSpace(body)
+
+	return info
+}
@@ -0,0 +1,44 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLineInfo(t *testing.T) {
+	t.Parallel()
+
+	for index, test := range []struct {
+		input        string
+		expect       LineInfo
+		allowComment bool
+	}{
+		{input: "", expect: LineInfo{}},
+		{
+			input:  "  0.0.0.0 example.com # comment",
+			expect: LineInfo{Raw: "  0.0.0.0 example.com # comment", Body: "0.0.0.0 example.com # comment", Offset: 2},
+		},
+		{
+			input:        "  0.0.0.0 example.com # comment",
+			expect:       LineInfo{Raw: "  0.0.0.0 example.com # comment", Body: "0.0.0.0 example.com", Offset: 2},
+			allowComment: true,
+		},
+		{
+			input:        " # comment",
+			expect:       LineInfo{Raw: " # comment", Offset: 1, IsComment: true},
+			allowComment: true,
+		},
+	} {
+		assert.Equal(t, test.expect, newLineInfo(test.input, test.allowComment), "test #%d failed", index)
+	}
+}
+
+func TestLineInfo_Fields(t *testing.T) {
+	t.Parallel()
+
+	texts, columns := newLineInfo("  0.0.0.0   example.com # comment", true).Fields()
+
+	assert.Equal(t, []string{"0.0.0.0", "example.com"}, texts)
+	assert.Equal(t, []int{3, 13}, columns, "columns should be 1-based byte offsets in the raw line")
+}
@@ -0,0 +1,205 @@
+package hostpital
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: lineReader
+// ----------------------------------------------------------------------------
+
+// lineReader reads the lines from an io.Reader like bufio.Scanner does. Unlike
+// bufio.Scanner, the maximum length of a line is configurable and the lines
+// exceeding it are handled according to the LongLinePolicy.
+type lineReader struct {
+	err       error
+	reader    *bufio.Reader
+	splitter  *lineSplitter // Splitter of the long line being read. Nil if none.
+	line      string
+	pending   []string // Rest of the split line to be returned.
+	longLines []int    // Line numbers of the lines exceeding maxLength.
+	maxLength int
+	numLine   int
+	policy    LongLinePolicy
+}
+
+// newLineReader returns a new lineReader. The line breaks of the lines are
+// trimmed. Both LF and CRLF are supported.
+func newLineReader(input io.Reader, maxLength int, policy LongLinePolicy) *lineReader {
+	return &lineReader{
+		reader:    bufio.NewReader(input),
+		maxLength: maxLength,
+		policy:    policy,
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// Err returns the first error occurred while reading. It is nil on io.EOF.
+func (l *lineReader) Err() error {
+	return l.err
+}
+
+// Line returns the line number of the current line. The split lines share the
+// line number of the original line. On error, the line that failed to be read
+// is the next line.
+func (l *lineReader) Line() int {
+	return l.numLine
+}
+
+// Scan advances to the next line. It returns false at the end of the input or
+// on error.
+func (l *lineReader) Scan() bool {
+	for {
+		if len(l.pending) > 0 {
+			l.line, l.pending = l.pending[0], l.pending[1:]
+
+			return true
+		}
+
+		if l.splitter != nil {
+			if err := l.readSplit(); err != nil {
+				l.err = err
+
+				return false
+			}
+
+			continue
+		}
+
+		line, isLong, err := l.readLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				l.err = err
+			}
+
+			return false
+		}
+
+		numLine := l.numLine + 1
+
+		if !isLong {
+			l.line, l.numLine = line, numLine
+
+			return true
+		}
+
+		l.longLines = append(l.longLines, numLine)
+
+		switch l.policy {
+		case LongLineSkip, LongLineSplit:
+			// The split lines are pending or to be read by readSplit.
+			l.numLine = numLine
+
+			continue
+		default:
+			l.err = errors.Errorf("line %d is longer than the max line length of %d bytes",
+				numLine, l.maxLength)
+
+			return false
+		}
+	}
+}
+
+// Text returns the current line.
+func (l *lineReader) Text() string {
+	return l.line
+}
+
+// readLine reads a line without the line break. isLong is true if the line
+// exceeds maxLength. In that case, the line is truncated unless the policy is
+// LongLineSplit, which passes the line to the splitter instead. See split.
+func (l *lineReader) readLine() (string, bool, error) {
+	var buf []byte
+
+	isLong := false
+
+	for {
+		chunk, err := l.reader.ReadSlice(byte(LF))
+		isFull := errors.Is(err, bufio.ErrBufferFull)
+
+		if err != nil && !isFull && !errors.Is(err, io.EOF) {
+			return "", false, errors.Wrap(err, "failed to read the line")
+		}
+
+		if !isLong {
+			buf = append(buf, chunk...)
+		}
+
+		// +2 for the line break (CRLF)
+		if len(buf) > l.maxLength+2 {
+			if l.policy == LongLineSplit {
+				l.split(buf, isFull)
+
+				return "", true, nil
+			}
+
+			isLong = true
+			buf = buf[:l.maxLength+2]
+		}
+
+		if isFull {
+			continue
+		}
+
+		if len(buf) == 0 {
+			return "", false, io.EOF
+		}
+
+		line := strings.TrimSuffix(strings.TrimSuffix(string(buf), string(LF)), string(CR))
+		isLong = isLong || len(line) > l.maxLength
+
+		if isLong && l.policy == LongLineSplit {
+			l.split([]byte(line), false)
+
+			return "", true, nil
+		}
+
+		return line, isLong, nil
+	}
+}
+
+// readSplit reads the next chunk of the long line being split. See split.
+func (l *lineReader) readSplit() error {
+	chunk, err := l.reader.ReadSlice(byte(LF))
+	isFull := errors.Is(err, bufio.ErrBufferFull)
+
+	if err != nil && !isFull && !errors.Is(err, io.EOF) {
+		return errors.Wrap(err, "failed to read the line")
+	}
+
+	l.split(chunk, isFull)
+
+	return nil
+}
+
+// split passes the chunk of the long line to the splitter and makes the lines
+// split so far pending. isPartial is true if the rest of the line is not read
+// yet. So the long line is read in chunks rather than as a whole.
+func (l *lineReader) split(chunk []byte, isPartial bool) {
+	if l.splitter == nil {
+		l.splitter = newLineSplitter(l.maxLength)
+	}
+
+	l.splitter.write(chunk)
+
+	if isPartial {
+		l.pending = l.splitter.take()
+
+		return
+	}
+
+	l.pending = l.splitter.finish()
+	l.splitter = nil
+}
+
+// stats returns the statistics of the lines read so far.
+func (l *lineReader) stats() ParseStats {
+	return ParseStats{LinesRead: l.numLine, LongLines: l.longLines}
+}
@@ -0,0 +1,208 @@
+package hostpital
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  lineReader.Scan()
+// ----------------------------------------------------------------------------
+
+// readAll returns all the lines and their line numbers read by the lineReader.
+func readAll(reader *lineReader) ([]string, []int) {
+	lines := []string{}
+	numLines := []int{}
+
+	for reader.Scan() {
+		lines = append(lines, reader.Text())
+		numLines = append(numLines, reader.Line())
+	}
+
+	return lines, numLines
+}
+
+func TestLineReader_line_breaks(t *testing.T) {
+	t.Parallel()
+
+	reader := newLineReader(strings.NewReader("line1\r\nline2\n\nline4"), 10, LongLineFail)
+
+	lines, numLines := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, []string{"line1", "line2", "", "line4"}, lines,
+		"it should trim LF and CRLF and keep the last line without a line break")
+	assert.Equal(t, []int{1, 2, 3, 4}, numLines)
+	assert.Equal(t, ParseStats{LinesRead: 4}, reader.stats())
+}
+
+func TestLineReader_longer_than_buffer(t *testing.T) {
+	t.Parallel()
+
+	// Longer than the default buffer size of bufio.Reader (4096 bytes)
+	longLine := strings.Repeat("a", 10_000)
+
+	reader := newLineReader(strings.NewReader(longLine+"\nshort\n"), 20_000, LongLineFail)
+
+	lines, _ := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, []string{longLine, "short"}, lines, "it should read lines longer than the buffer")
+}
+
+func TestLineReader_policy_fail(t *testing.T) {
+	t.Parallel()
+
+	reader := newLineReader(strings.NewReader("short\n"+strings.Repeat("a", 10_000)+"\nshort\n"), 10, LongLineFail)
+
+	lines, _ := readAll(reader)
+
+	require.Error(t, reader.Err())
+	assert.Equal(t, []string{"short"}, lines, "it should stop at the long line")
+	assert.Contains(t, reader.Err().Error(), "line 2 is longer than the max line length of 10 bytes")
+}
+
+func TestLineReader_policy_skip(t *testing.T) {
+	t.Parallel()
+
+	input := "short\n" + strings.Repeat("a", 10_000) + "\n12345678901\r\n1234567890\r\nshort"
+	reader := newLineReader(strings.NewReader(input), 10, LongLineSkip)
+
+	lines, numLines := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, []string{"short", "1234567890", "short"}, lines,
+		"it should skip the lines longer than the max length but not the line break")
+	assert.Equal(t, []int{1, 4, 5}, numLines, "it should keep the line numbers of the source")
+	assert.Equal(t, ParseStats{LinesRead: 5, LongLines: []int{2, 3}}, reader.stats(),
+		"it should report the skipped lines")
+}
+
+func TestLineReader_policy_split(t *testing.T) {
+	t.Parallel()
+
+	input := "0.0.0.0 a.example b.example c.example # comment\nd.example e.example\n"
+	reader := newLineReader(strings.NewReader(input), 20, LongLineSplit)
+
+	lines, numLines := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, []string{
+		"0.0.0.0 a.example",
+		"0.0.0.0 b.example",
+		"0.0.0.0 c.example",
+		"# comment",
+		"d.example e.example",
+	}, lines, "it should split the long line and repeat the IP address")
+	assert.Equal(t, []int{1, 1, 1, 1, 2}, numLines, "split lines should share the line number")
+	assert.Equal(t, ParseStats{LinesRead: 2, LongLines: []int{1}}, reader.stats())
+}
+
+func TestLineReader_policy_split_just_over(t *testing.T) {
+	t.Parallel()
+
+	// Lines of one or two bytes over maxLength fit in the room for the line
+	// break. They should be split as well.
+	reader := newLineReader(strings.NewReader("a.example b\nc.example dd\r\n"), 10, LongLineSplit)
+
+	lines, numLines := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, []string{"a.example", "b", "c.example", "dd"}, lines)
+	assert.Equal(t, []int{1, 1, 2, 2}, numLines)
+	assert.Equal(t, []int{1, 2}, reader.stats().LongLines)
+}
+
+func TestLineReader_policy_split_longer_than_buffer(t *testing.T) {
+	t.Parallel()
+
+	const maxLength = 30
+
+	hostNames := make([]string, 2000)
+	for index := range hostNames {
+		hostNames[index] = fmt.Sprintf("host%d.example", index)
+	}
+
+	// The line is longer than the buffer of bufio.Reader and has a comment
+	// longer than maxLength as well.
+	input := "0.0.0.0 " + strings.Join(hostNames, " ") + " # " + strings.Repeat("cómment ", 20) + "\r\nlast.example\n"
+	reader := newLineReader(strings.NewReader(input), maxLength, LongLineSplit)
+
+	lines, numLines := readAll(reader)
+
+	require.NoError(t, reader.Err())
+	require.Equal(t, "last.example", lines[len(lines)-1])
+	assert.Equal(t, 2, numLines[len(numLines)-1])
+
+	found := []string{}
+	comment := ""
+
+	for _, line := range lines[:len(lines)-1] {
+		require.LessOrEqual(t, len(line), maxLength, "line: %q", line)
+		require.True(t, utf8.ValidString(line), "line: %q", line)
+
+		if body, ok := strings.CutPrefix(line, "#"); ok {
+			comment += body
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		require.Equal(t, "0.0.0.0", fields[0], "it should repeat the IP address")
+
+		found = append(found, fields[1:]...)
+	}
+
+	assert.Equal(t, hostNames, found, "it should keep all the host names in order")
+	assert.Equal(t, " "+strings.TrimSpace(strings.Repeat("cómment ", 20)), strings.TrimRight(comment, " "),
+		"it should keep the comment in the lines of its own")
+}
+
+func TestLineReader_policy_split_read_error(t *testing.T) {
+	t.Parallel()
+
+	isRead := false
+	dummy := &dummyReader{
+		dummyFn: func(buf []byte) (int, error) {
+			if isRead {
+				return 0, errors.New("forced error")
+			}
+
+			isRead = true
+
+			return copy(buf, strings.Repeat("a.example ", len(buf)/10+1)), nil
+		},
+	}
+
+	reader := newLineReader(dummy, 20, LongLineSplit)
+
+	lines, _ := readAll(reader)
+
+	require.Error(t, reader.Err())
+	assert.Contains(t, reader.Err().Error(), "failed to read the line")
+	assert.NotEmpty(t, lines, "the lines split before the error should be read")
+}
+
+func TestLineReader_read_error(t *testing.T) {
+	t.Parallel()
+
+	dummy := &dummyReader{
+		dummyFn: func(_ []byte) (int, error) {
+			return 0, errors.New("forced error")
+		},
+	}
+
+	reader := newLineReader(dummy, 10, LongLineFail)
+
+	require.False(t, reader.Scan())
+	require.Error(t, reader.Err())
+	assert.Contains(t, reader.Err().Error(), "failed to read the line")
+	assert.Contains(t, reader.Err().Error(), "forced error")
+}
@@ -0,0 +1,174 @@
+package hostpital
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: lineSplitter
+// ----------------------------------------------------------------------------
+
+// lineSplitter splits a long line into lines of maxLength at the most by the
+// host names as the line is written in chunks. So the whole line does not need
+// to be kept in memory.
+//
+// The leading IP address is repeated in each line. The in-line comment follows
+// the host names as the lines of its own, which are cut at maxLength. A host
+// name too long to fit in a line with the IP address is dropped.
+type lineSplitter struct {
+	word       []byte   // Word being read. It holds the comment in the comment part.
+	pieces     []string // Lines split so far. See take.
+	piece      string   // Line being built.
+	prefix     string   // Leading IP address to repeat in each line.
+	maxLength  int
+	hasWord    bool // True if the first word was read.
+	isComment  bool // True after the comment delimiter.
+	isDropping bool // True while skipping the word too long.
+}
+
+// newLineSplitter returns a new lineSplitter for the lines of maxLength bytes at
+// the most.
+func newLineSplitter(maxLength int) *lineSplitter {
+	return &lineSplitter{maxLength: maxLength}
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// endPiece adds the line being built to the lines split if it has host names.
+func (s *lineSplitter) endPiece() {
+	if s.piece != s.prefix {
+		s.pieces = append(s.pieces, s.piece)
+	}
+
+	s.piece = s.prefix
+}
+
+// endWord adds the word read to the line being built. The first word is taken
+// as the leading IP address if it is.
+func (s *lineSplitter) endWord() {
+	word := string(s.word)
+	isDropped := s.isDropping
+
+	s.word = s.word[:0]
+	s.isDropping = false
+
+	if word == "" || isDropped {
+		return
+	}
+
+	if !s.hasWord {
+		s.hasWord = true
+
+		if IsIPAddress(word) {
+			s.prefix, s.piece = word, word
+
+			return
+		}
+	}
+
+	if s.piece != s.prefix && len(s.piece)+1+len(word) > s.maxLength {
+		s.endPiece()
+	}
+
+	if s.piece != "" {
+		s.piece += " "
+	}
+
+	s.piece += word
+}
+
+// finish ends the line and returns the rest of the lines split. The splitter
+// can not be used after that.
+func (s *lineSplitter) finish() []string {
+	if s.isComment {
+		if comment := strings.TrimRight(string(s.word), "\r\n"); comment != string(DelimComnt) {
+			s.pieces = append(s.pieces, comment)
+		}
+	} else {
+		s.endWord()
+	}
+
+	s.endPiece()
+
+	return s.take()
+}
+
+// maxWordLength returns the maximum length of a word to fit in a line with the
+// leading IP address.
+func (s *lineSplitter) maxWordLength() int {
+	if s.prefix == "" {
+		return s.maxLength
+	}
+
+	return s.maxLength - len(s.prefix) - 1
+}
+
+// take returns the lines split so far and forgets them.
+func (s *lineSplitter) take() []string {
+	pieces := s.pieces
+	s.pieces = nil
+
+	return pieces
+}
+
+// write splits the chunk of the line. The chunk can end in the middle of a word
+// or a character.
+func (s *lineSplitter) write(chunk []byte) {
+	for _, char := range chunk {
+		switch {
+		case s.isComment:
+			s.writeComment(char)
+		case char == DelimComnt:
+			s.endWord()
+			s.endPiece()
+
+			s.isComment = true
+			s.word = append(s.word[:0], DelimComnt)
+		case strings.IndexByte(Cutset, char) >= 0:
+			s.endWord()
+		case s.isDropping:
+			continue
+		default:
+			s.word = append(s.word, char)
+
+			if len(s.word) > s.maxWordLength() {
+				s.isDropping = true
+				s.word = s.word[:0]
+			}
+		}
+	}
+}
+
+// writeComment adds the character to the comment. The comment is cut at
+// maxLength without breaking the UTF-8 characters and continues in the next
+// line as a comment.
+func (s *lineSplitter) writeComment(char byte) {
+	s.word = append(s.word, char)
+
+	// A line can not hold more than the delimiter.
+	if s.maxLength <= 1 {
+		s.word = s.word[:1]
+
+		return
+	}
+
+	if len(s.word) <= s.maxLength {
+		return
+	}
+
+	cut := s.maxLength
+	for cut > 1 && !utf8.RuneStart(s.word[cut]) {
+		cut--
+	}
+
+	// Not UTF-8. Cut as is not to keep growing.
+	if cut == 1 {
+		cut = s.maxLength
+	}
+
+	s.pieces = append(s.pieces, string(s.word[:cut]))
+	s.word = append([]byte{DelimComnt}, s.word[cut:]...)
+}
@@ -0,0 +1,87 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------------------------------------------------------------------
+//  lineSplitter
+// ----------------------------------------------------------------------------
+
+func TestLineSplitter(t *testing.T) {
+	t.Parallel()
+
+	const maxLength = 20
+
+	for index, test := range []struct {
+		input  string
+		expect []string
+	}{
+		{
+			input:  "a.example b.example c.example",
+			expect: []string{"a.example b.example", "c.example"},
+		},
+		{
+			input:  "::1 very-long-host-name.example a.example",
+			expect: []string{"::1 a.example"},
+		},
+		{
+			input:  "0.0.0.0 a.example #",
+			expect: []string{"0.0.0.0 a.example"},
+		},
+		{
+			input:  "0.0.0.0 a.example # comment\r\n",
+			expect: []string{"0.0.0.0 a.example", "# comment"},
+		},
+		{
+			input:  "# very long comment line without hosts",
+			expect: []string{"# very long comment ", "#line without hosts"},
+		},
+		{
+			input:  "# 日本語のコメントです",
+			expect: []string{"# 日本語のコメ", "#ントです"},
+		},
+		{
+			input:  "0.0.0.0\t\ta.example",
+			expect: []string{"0.0.0.0 a.example"},
+		},
+	} {
+		// The result should not depend on the size of the chunks written.
+		for sizeChunk := 1; sizeChunk <= len(test.input); sizeChunk++ {
+			splitter := newLineSplitter(maxLength)
+			actual := []string{}
+
+			for begin := 0; begin < len(test.input); begin += sizeChunk {
+				splitter.write([]byte(test.input[begin:min(begin+sizeChunk, len(test.input))]))
+				actual = append(actual, splitter.take()...)
+			}
+
+			actual = append(actual, splitter.finish()...)
+
+			assert.Equal(t, test.expect, actual, "test #%d: input: %q, size: %d", index+1, test.input, sizeChunk)
+
+			for _, line := range actual {
+				assert.LessOrEqual(t, len(line), maxLength, "test #%d: line: %q", index+1, line)
+			}
+		}
+	}
+}
+
+func TestLineSplitter_too_short(t *testing.T) {
+	t.Parallel()
+
+	splitter := newLineSplitter(1)
+
+	splitter.write([]byte("a # comment"))
+
+	assert.Equal(t, []string{"a"}, splitter.finish(), "comments should be dropped if not fit")
+
+	splitter = newLineSplitter(2)
+
+	splitter.write([]byte("#\xff\x80\x80\x80"))
+
+	assert.Equal(t, []string{"#\xff", "#\x80", "#\x80", "#\x80"}, splitter.finish(),
+		"the comments not in UTF-8 should be cut as is")
+}
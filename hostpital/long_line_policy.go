@@ -0,0 +1,54 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: LongLinePolicy
+// ----------------------------------------------------------------------------
+
+// LongLinePolicy is the strategy of the Parser for the lines longer than its
+// MaxLineLength.
+type LongLinePolicy int
+
+const (
+	// LongLineFail stops the parsing and returns an error.
+	LongLineFail LongLinePolicy = iota
+	// LongLineSkip drops the line and reports its line number in ParseStats.
+	LongLineSkip
+	// LongLineSplit splits the line into multiple lines of MaxLineLength at the
+	// most. The leading IP address is repeated in each line and the in-line
+	// comment follows as comment lines of its own. A host name too long to fit
+	// in a line is dropped. The line number is reported in ParseStats.
+	LongLineSplit
+)
+
+// namesLongLinePolicy is the list of the names of LongLinePolicy in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesLongLinePolicy = []string{"fail", "skip", "split"}
+
+// ParseLongLinePolicy returns the LongLinePolicy of the given name. Such as
+// "fail", "skip" and "split".
+func ParseLongLinePolicy(name string) (LongLinePolicy, error) {
+	index := slices.Index(namesLongLinePolicy, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return LongLineFail, errors.Errorf("unknown long line policy %#v. It must be one of: %s",
+			name, strings.Join(namesLongLinePolicy, ", "))
+	}
+
+	return LongLinePolicy(index), nil
+}
+
+// String returns the name of the policy.
+func (l LongLinePolicy) String() string {
+	if l < 0 || int(l) >= len(namesLongLinePolicy) {
+		return "invalid"
+	}
+
+	return namesLongLinePolicy[l]
+}
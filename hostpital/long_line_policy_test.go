@@ -0,0 +1,50 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  ParseLongLinePolicy()
+// ----------------------------------------------------------------------------
+
+func TestParseLongLinePolicy(t *testing.T) {
+	t.Parallel()
+
+	for name, expect := range map[string]LongLinePolicy{
+		"fail":    LongLineFail,
+		" Skip ":  LongLineSkip,
+		"SPLIT\n": LongLineSplit,
+	} {
+		actual, err := ParseLongLinePolicy(name)
+
+		require.NoError(t, err, "name: %q", name)
+		assert.Equal(t, expect, actual, "name: %q", name)
+	}
+}
+
+func TestParseLongLinePolicy_unknown(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParseLongLinePolicy("truncate")
+
+	require.Error(t, err)
+	assert.Equal(t, LongLineFail, policy, "it should return LongLineFail on error")
+	assert.Contains(t, err.Error(), `unknown long line policy "truncate"`)
+	assert.Contains(t, err.Error(), "fail, skip, split", "it should list the available policies")
+}
+
+// ----------------------------------------------------------------------------
+//  LongLinePolicy.String()
+// ----------------------------------------------------------------------------
+
+func TestLongLinePolicy_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "split", LongLineSplit.String())
+	assert.Equal(t, "invalid", LongLinePolicy(-1).String())
+	assert.Equal(t, "invalid", LongLinePolicy(3).String())
+}
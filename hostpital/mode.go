@@ -0,0 +1,51 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: Mode
+// ----------------------------------------------------------------------------
+
+// Mode is the kind of the input of the Validator.
+type Mode int
+
+const (
+	// ModeDomainList validates the lines as lists of domain names. The IP
+	// addresses are accepted anywhere in the line.
+	ModeDomainList Mode = iota
+	// ModeHostsFile validates the lines with the semantics of hosts(5). Such as
+	// an IP address followed by a canonical host name, optional aliases and an
+	// optional comment.
+	ModeHostsFile
+)
+
+// namesMode is the list of the names of Mode in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesMode = []string{"domain-list", "hosts-file"}
+
+// ParseMode returns the Mode of the given name. Such as "domain-list" and
+// "hosts-file".
+func ParseMode(name string) (Mode, error) {
+	index := slices.Index(namesMode, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return ModeDomainList, errors.Errorf("unknown mode %#v. It must be one of: %s",
+			name, strings.Join(namesMode, ", "))
+	}
+
+	return Mode(index), nil
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(namesMode) {
+		return "invalid"
+	}
+
+	return namesMode[m]
+}
@@ -0,0 +1,41 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for name, expect := range map[string]Mode{
+		"domain-list":   ModeDomainList,
+		" Hosts-File\n": ModeHostsFile,
+	} {
+		actual, err := ParseMode(name)
+
+		require.NoError(t, err, "name %#v should be parsed", name)
+		assert.Equal(t, expect, actual, "name %#v should be parsed", name)
+	}
+}
+
+func TestParseMode_unknown(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("unknown")
+
+	require.Error(t, err, "unknown names should be an error")
+	assert.Equal(t, ModeDomainList, mode, "it should return the default on error")
+	assert.Contains(t, err.Error(), "domain-list, hosts-file")
+}
+
+func TestMode_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "domain-list", ModeDomainList.String())
+	assert.Equal(t, "hosts-file", ModeHostsFile.String())
+	assert.Equal(t, "invalid", Mode(-1).String())
+	assert.Equal(t, "invalid", Mode(2).String())
+}
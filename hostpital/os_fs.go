@@ -0,0 +1,18 @@
+package hostpital
+
+import "io/fs"
+
+// ----------------------------------------------------------------------------
+//  Type: osFS
+// ----------------------------------------------------------------------------
+
+// osFS is the fs.FS of the OS file system to route the path based functions to
+// their fs.FS variants. Unlike os.DirFS, the names are OS paths and are not
+// validated by fs.ValidPath, so absolute paths and paths with ".." are opened
+// as is.
+type osFS struct{}
+
+// Open opens the named file of the OS file system.
+func (osFS) Open(name string) (fs.File, error) {
+	return osOpen(name)
+}
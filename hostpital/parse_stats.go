@@ -0,0 +1,10 @@
+package hostpital
+
+// ParseStats holds the statistics of the last parse of a Parser.
+type ParseStats struct {
+	Hijacks     []SinkholeViolation // Entries dropped for pointing to the addresses not allowed by the Sinkhole policy.
+	Unconverted []ConversionFailure // Host names left in punycode for failing to convert to Unicode by OutputUnicode.
+	LinesRead   int                 // Number of lines read from the input.
+	Duplicates  int                 // Number of duplicates dropped. Lines for DedupeLine and host names for the others.
+	LongLines   []int               // Line numbers of the lines longer than MaxLineLength. Skipped or split by the LongLine policy.
+}
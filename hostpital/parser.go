@@ -3,9 +3,12 @@ package hostpital
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
-	"os"
+	"io/fs"
+	"iter"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"sync"
@@ -21,16 +24,28 @@ import (
 // Parser holds the settings and the rules for the parsing. To simply validate
 // the hostfile, use the methods in the Validator type instead.
 type Parser struct {
-	UseIPAddress      string // If not empty and 'TrimIPAddress' is true, use this IP address instead (default: "").
+	IDNA              *IDNAProfile      // If set and IDNACompatible is true, the host names are converted and validated by the profile (default: nil).
+	PublicSuffixes    *PublicSuffixList // List of the public suffixes for SortByDomain. The embedded snapshot is used if nil (default: nil).
+	Sinkhole          *SinkholePolicy   // If set, the entries pointing to the addresses not allowed are dropped. See ParseStats.Hijacks (default: nil).
+	UseIPAddress      string            // If not empty and 'TrimIPAddress' is true, use this IP address instead (default: "").
+	stats             ParseStats
 	mutx              sync.Mutex
-	IDNACompatible    bool // If true, punycode is converted to IDNA2008 compatible (default: true).
-	OmitEmptyLine     bool // If true, empty lines are omitted (default: true).
-	SortAfterParse    bool // If true, sort the lines after parsing (default: false).
-	SortAsReverseDNS  bool // If true, sort the lines as reversed DNS hosts (default: false).
-	TrimComment       bool // If true, comment is trimmed (default: true).
-	TrimIPAddress     bool // If true, leading IP address is trimmed (default: true).
-	TrimLeadingSpace  bool // If true, leading spaces are trimmed (default: true).
-	TrimTrailingSpace bool // If true, trailing spaces are trimmed (default: true).
+	Concurrency       int            // Number of workers to parse the lines of a file concurrently (default: runtime.GOMAXPROCS(0)).
+	Deduplicate       DedupeMode     // Strategy to remove duplicate lines or host names (default: DedupeNone).
+	DropSpecialUse    bool           // If true, special-use domain names such as "localhost" and "*.local" are dropped. See Classify (default: false).
+	MaxLineLength     int            // Maximum length of a line in bytes (default: DefaultMaxLineLength).
+	LongLine          LongLinePolicy // Policy for the lines longer than MaxLineLength (default: LongLineFail).
+	AnnotateIDN       bool           // If true, the other form of the internationalized host names is appended as a comment. Such as "xn--gpher-jua.com # göpher.com" (default: false).
+	IDNACompatible    bool           // If true, punycode is converted to IDNA2008 compatible (default: true).
+	OmitEmptyLine     bool           // If true, empty lines are omitted (default: true).
+	OutputUnicode     bool           // If true, the host names in punycode are rendered in Unicode. See ParseStats.Unconverted (default: false).
+	SortAfterParse    bool           // If true, sort the lines after parsing (default: false).
+	SortAsReverseDNS  bool           // If true, sort the lines as reversed DNS hosts (default: false).
+	SortByDomain      bool           // If true, sort the lines by the registrable domain without the public suffix, then as reversed DNS hosts. Such as "example" of "www.example.co.uk" (default: false).
+	TrimComment       bool           // If true, comment is trimmed (default: true).
+	TrimIPAddress     bool           // If true, leading IP address is trimmed (default: true).
+	TrimLeadingSpace  bool           // If true, leading spaces are trimmed (default: true).
+	TrimTrailingSpace bool           // If true, trailing spaces are trimmed (default: true).
 }
 
 // ----------------------------------------------------------------------------
@@ -43,6 +58,10 @@ func NewParser() *Parser {
 
 	// Set default values. Non mentioned values are set to false.
 	parser.UseIPAddress = ""
+	parser.Concurrency = runtime.GOMAXPROCS(0)
+	parser.Deduplicate = DedupeNone
+	parser.MaxLineLength = DefaultMaxLineLength
+	parser.LongLine = LongLineFail
 	parser.IDNACompatible = true
 	parser.OmitEmptyLine = true
 	parser.TrimComment = true
@@ -57,6 +76,45 @@ func NewParser() *Parser {
 //  Methods (Public)
 // ----------------------------------------------------------------------------
 
+// All returns an iterator over the parsed entries of the lines read from input
+// according to the settings in the Parser. Omitted lines are not included.
+//
+// The entries are yielded as they are parsed, so they are not sorted even if
+// sorting is set. On read error, an empty Entry and the error are yielded last.
+// Breaking the loop stops reading the input.
+func (p *Parser) All(input io.Reader) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		isStopped := false
+		dedupe := newDeduper(p.Deduplicate)
+
+		stats, err := p.scanFile(context.Background(), input, func(entry *Entry) error {
+			if entry == nil {
+				return nil
+			}
+
+			filtered, _, ok := p.filterEntry(dedupe, *entry)
+			if !ok {
+				return nil
+			}
+
+			if !yield(filtered, nil) {
+				isStopped = true
+
+				return errors.New("stopped by the caller")
+			}
+
+			return nil
+		})
+
+		stats.Duplicates = dedupe.numDropped
+		p.setStats(stats)
+
+		if err != nil && !isStopped {
+			yield(Entry{}, errors.Wrap(err, "failed to read from reader"))
+		}
+	}
+}
+
 // CountLines counts the number of lines in the file.
 func (p *Parser) CountLines(pathFile string) (int, error) {
 	p.mutx.Lock()
@@ -67,7 +125,7 @@ func (p *Parser) CountLines(pathFile string) (int, error) {
 		pathFile = filepath.Clean(pathFile)
 	}
 
-	osFile, err := os.Open(pathFile)
+	osFile, err := osFS{}.Open(pathFile)
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to open the file")
 	}
@@ -84,9 +142,27 @@ func (p *Parser) CountLines(pathFile string) (int, error) {
 // ParseFile reads the file from pathFile and returns the parsed lines as a string
 // according to the settings in the Parser.
 func (p *Parser) ParseFile(pathFile string) (string, error) {
+	return p.ParseFS(osFS{}, filepath.Clean(pathFile))
+}
+
+// ParseFileContext is like ParseFileTo but stops promptly when ctx is done. The
+// error then wraps ctx.Err() with the number of lines processed.
+func (p *Parser) ParseFileContext(ctx context.Context, pathFileIn string, fileOut io.Writer) error {
+	return p.parseFSTo(ctx, osFS{}, filepath.Clean(pathFileIn), fileOut)
+}
+
+// ParseFileTo reads the file from pathFileIn and writes the parsed lines to fileOut.
+// It is a wrapper of ParseReader.
+func (p *Parser) ParseFileTo(pathFileIn string, fileOut io.Writer) error {
+	return p.ParseFileContext(context.Background(), pathFileIn, fileOut)
+}
+
+// ParseFS is like ParseFile but reads the named file from the given file system.
+// Such as embed.FS, zip.Reader and fstest.MapFS.
+func (p *Parser) ParseFS(fsys fs.FS, name string) (string, error) {
 	outBuf := new(bytes.Buffer)
 
-	err := p.ParseFileTo(pathFile, outBuf)
+	err := p.parseFSTo(context.Background(), fsys, name, outBuf)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to parse the file")
 	}
@@ -94,65 +170,157 @@ func (p *Parser) ParseFile(pathFile string) (string, error) {
 	return outBuf.String(), nil
 }
 
-// ParseFileTo reads the file from pathFileIn and writes the parsed lines to fileOut.
-func (p *Parser) ParseFileTo(pathFileIn string, fileOut io.Writer) error {
-	if fileOut == nil {
-		return errors.New("the given io.Writer is nil")
+// ParseEntries reads the file from pathFile and returns the parsed lines as
+// entries according to the settings in the Parser. Omitted lines are not
+// included.
+func (p *Parser) ParseEntries(pathFile string) ([]Entry, error) {
+	return p.ParseEntriesFS(osFS{}, filepath.Clean(pathFile))
+}
+
+// ParseEntriesFS is like ParseEntries but reads the named file from the given
+// file system. Such as embed.FS, zip.Reader and fstest.MapFS.
+func (p *Parser) ParseEntriesFS(fsys fs.FS, name string) ([]Entry, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the file")
 	}
 
-	pathFileIn = filepath.Clean(pathFileIn)
+	defer func() {
+		_ = file.Close()
+	}()
 
-	// Prepare a slice to store the parsed lines.
-	numLines, err := p.CountLines(pathFileIn)
+	entries, err := p.ParseEntriesFrom(file)
 	if err != nil {
-		return errors.Wrap(err, "failed to read and count lines from the input file")
+		return nil, errors.Wrap(err, "failed to parse the file")
 	}
 
-	lines := make([]string, numLines)
+	for index := range entries {
+		entries[index].Source = name
+	}
 
-	// Open the file.
-	// Error check is omitted because it is done in the above p.CountLines() so
-	// it will never reach here if the file does not exist or is not readable.
-	osFile, _ := osOpen(pathFileIn)
+	p.setStatsSource(name)
 
-	defer func() {
-		_ = osFile.Close()
-	}()
+	return entries, nil
+}
 
-	// Returned error not checked as it is done in the above p.CountLines().
-	_ = p.scanFile(osFile, lines)
+// ParseEntriesFrom reads the lines from the given io.Reader and returns the
+// parsed lines as entries according to the settings in the Parser. Omitted
+// lines are not included.
+func (p *Parser) ParseEntriesFrom(input io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	dedupe := newDeduper(p.Deduplicate)
 
-	if p.SortAfterParse || p.SortAsReverseDNS {
-		lines = p.sortSlices(lines)
-	}
+	stats, err := p.scanFile(context.Background(), input, func(entry *Entry) error {
+		if entry == nil {
+			return nil
+		}
 
-	for _, line := range lines {
-		_, err = fileOut.Write([]byte(line))
-		if err != nil {
-			return errors.Wrap(err, "failed to write to io.Writer")
+		if filtered, _, ok := p.filterEntry(dedupe, *entry); ok {
+			entries = append(entries, filtered)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	stats.Duplicates = dedupe.numDropped
+	p.setStats(stats)
+
+	return p.sortEntries(entries), nil
 }
 
 // ParseLine parses the given line and returns the parsed line as a string
 // according to the settings in the Parser.
 func (p *Parser) ParseLine(line string) (string, bool) {
-	trimmed := line
+	entry, _, ok := p.parseEntry(line)
+	if !ok {
+		return "", false
+	}
 
-	trimmed = p.trimSpace(trimmed)
-	trimmed = p.trimComment(trimmed)
-	trimmed = p.trimIPAddress(trimmed)
-	trimmed = p.onlyIDNACompatible(trimmed)
+	return p.formatEntry(entry)
+}
 
-	if p.OmitEmptyLine && strings.TrimSpace(trimmed) == "" {
-		return "", false
+// ParseReader reads the lines from input and writes the parsed lines to output
+// in a single pass. Unlike ParseFileTo, the input does not need to be a file,
+// so stdin, pipes and network streams can be parsed as well.
+//
+// If none of SortAfterParse, SortAsReverseDNS and SortByDomain is set, the
+// lines are written as soon as they are parsed, so the memory usage does not
+// grow with the size of the input. Otherwise, all the lines are kept in memory
+// to be sorted.
+func (p *Parser) ParseReader(input io.Reader, output io.Writer) error {
+	return p.ParseReaderContext(context.Background(), input, output)
+}
+
+// ParseReaderContext is like ParseReader but stops promptly when ctx is done,
+// including the lines being parsed by the workers. The error then wraps
+// ctx.Err() with the number of lines processed. The lines processed before the
+// cancellation are written to output unless sorting.
+//
+// Note that a blocking Read of the input can not be interrupted. Close the
+// input to unblock it if needed.
+func (p *Parser) ParseReaderContext(ctx context.Context, input io.Reader, output io.Writer) error {
+	if output == nil {
+		return errors.New("the given io.Writer is nil")
 	}
 
-	trimmed = p.prependIPAddress(trimmed)
+	var (
+		errWrite error
+		lines    []string
+	)
+
+	isSort := p.SortAfterParse || p.SortAsReverseDNS || p.SortByDomain
+	dedupe := newDeduper(p.Deduplicate)
+	bufOut := bufio.NewWriter(output)
+
+	stats, errRead := p.scanFile(ctx, input, func(entry *Entry) error {
+		if entry == nil {
+			return nil
+		}
+
+		_, line, ok := p.filterEntry(dedupe, *entry)
+		if !ok {
+			return nil
+		}
+
+		if isSort {
+			lines = append(lines, line+string(LF))
+
+			return nil
+		}
+
+		_, errWrite = writeLines(bufOut, line+string(LF))
+
+		return errWrite
+	})
+
+	stats.Duplicates = dedupe.numDropped
+	p.setStats(stats)
+
+	switch {
+	case errWrite != nil:
+		return errWrite
+	case ctx.Err() != nil:
+		// Keep the output consistent with the lines processed
+		_ = bufOut.Flush()
 
-	return trimmed, true
+		if errRead == nil {
+			// Canceled after all the lines were parsed. Such as before sorting.
+			errRead = errors.Wrapf(ctx.Err(), "canceled after %d lines processed", stats.LinesRead)
+		}
+
+		return errRead
+	case errRead != nil:
+		return errors.Wrap(errRead, "failed to read from reader")
+	case isSort:
+		if _, err := writeLines(bufOut, p.sortSlices(lines)...); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(bufOut.Flush(), "failed to write to io.Writer")
 }
 
 // ParseString parses the given string and returns the parsed lines as a string
@@ -160,26 +328,286 @@ func (p *Parser) ParseLine(line string) (string, bool) {
 func (p *Parser) ParseString(input string) string {
 	lines := strings.Split(input, string(LF))
 	parsed := make([]string, len(lines))
+	entries := make([]*Entry, len(lines))
+	dedupe := newDeduper(p.Deduplicate)
+	hijacks := []SinkholeViolation(nil)
 
 	for index, line := range lines {
-		if trimmed, ok := p.ParseLine(line); ok {
+		entry, hijack, ok := p.parseEntry(line)
+		if hijack != nil {
+			hijack.Line = index + 1
+			hijacks = append(hijacks, *hijack)
+		}
+
+		if !ok {
+			continue
+		}
+
+		entry.Line = index + 1
+		entries[index] = &entry
+
+		if _, trimmed, ok := p.filterEntry(dedupe, entry); ok {
 			parsed[index] = trimmed
 		}
 	}
 
-	if p.SortAfterParse || p.SortAsReverseDNS {
+	p.setStats(ParseStats{
+		Hijacks:     hijacks,
+		Unconverted: p.checkUnicode(entries),
+		LinesRead:   len(lines),
+		Duplicates:  dedupe.numDropped,
+	})
+
+	if p.SortAfterParse || p.SortAsReverseDNS || p.SortByDomain {
 		parsed = p.sortSlices(parsed)
 	}
 
 	return strings.Join(parsed, string(LF))
 }
 
+// Stats returns the statistics of the last parse. Such as the number of lines
+// read and the number of duplicates dropped.
+func (p *Parser) Stats() ParseStats {
+	p.mutx.Lock()
+	defer p.mutx.Unlock()
+
+	return p.stats
+}
+
 // ----------------------------------------------------------------------------
 //  Methods (Private)
 // ----------------------------------------------------------------------------
 
+// checkUnicode returns the host names of the entries left in punycode for
+// failing to convert to Unicode. The entries can be nil for the omitted lines.
+func (p *Parser) checkUnicode(entries []*Entry) []ConversionFailure {
+	var failures []ConversionFailure
+
+	if !p.OutputUnicode {
+		return failures
+	}
+
+	profile := p.idnaProfile()
+
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+
+		for _, hostName := range entry.Hostnames {
+			if !hasACELabel(hostName) {
+				continue
+			}
+
+			if _, err := profile.ToUnicode(hostName); err != nil {
+				failures = append(failures, ConversionFailure{Err: err, Hostname: hostName, Line: entry.Line})
+			}
+		}
+	}
+
+	return failures
+}
+
+// convertHost converts the host name according to the settings in the Parser.
+// It returns false if the host name is dropped. isSpecialUse is true if it was
+// dropped for being a special-use domain name.
+func (p *Parser) convertHost(hostName string) (string, bool, bool) {
+	if p.IDNACompatible {
+		hostASCII, err := toIDNA2008(p.IDNA, hostName)
+		if err != nil {
+			return "", false, false
+		}
+
+		hostName = hostASCII
+	}
+
+	if p.DropSpecialUse && Classify(hostName) != SpecialUseNone {
+		return "", true, false
+	}
+
+	return p.toUnicode(hostName), false, true
+}
+
+// counterpart returns the other form of the internationalized host name. Such
+// as "göpher.com" for "xn--gpher-jua.com" and vice versa. It returns false if
+// the host name is not internationalized or fails to convert.
+func (p *Parser) counterpart(hostName string) (string, bool) {
+	convert := p.idnaProfile().ToASCII
+
+	if isASCII(hostName) {
+		if !hasACELabel(hostName) {
+			return "", false
+		}
+
+		convert = p.idnaProfile().ToUnicode
+	}
+
+	converted, err := convert(hostName)
+
+	return converted, err == nil && converted != hostName
+}
+
+// domainSortKey returns the key of the line to sort by the registrable domain.
+// Which is the label of the registrable domain of the first host name followed
+// by the reversed host name. Such as "example uk.co.example.www" for the line
+// "0.0.0.0 www.example.co.uk". The key of the lines with no registrable domain,
+// such as the comment lines, begins with a space to come first.
+func (p *Parser) domainSortKey(line string) string {
+	hostName := ""
+
+	body, _, _ := strings.Cut(line, string(DelimComnt))
+	for _, field := range strings.Fields(body) {
+		if !IsIPAddress(field) {
+			hostName = field
+
+			break
+		}
+	}
+
+	// The label is empty if the host name is a public suffix or empty.
+	domain, _ := p.publicSuffixes().RegistrableDomain(hostName)
+	label, _, _ := strings.Cut(domain, string(DelimDNS))
+
+	return label + " " + ReverseDNS(strings.ToLower(hostName))
+}
+
+// filterEntry removes the duplicates from the entry and renders it. It returns
+// false if the whole entry is a duplicate or the rendered line is omitted.
+func (p *Parser) filterEntry(dedupe *deduper, entry Entry) (Entry, string, bool) {
+	line, ok := p.formatEntry(entry)
+	if !ok {
+		return entry, "", false
+	}
+
+	filtered, ok := dedupe.filter(entry, line)
+	if !ok {
+		return filtered, "", false
+	}
+
+	// Host names were dropped. Render from the fields instead of the raw line.
+	if len(filtered.Hostnames) != len(entry.Hostnames) {
+		line = p.renderEntry(filtered)
+	}
+
+	return filtered, line, true
+}
+
+// formatEntry renders the entry as a line according to the settings in the
+// Parser. It returns false if the line is empty and OmitEmptyLine is true.
+//
+// The line is edited from the raw line as a string unless AnnotateIDN is true.
+// So the host names dropped leave their spaces, such as " good.com" for
+// "0.0.0.0 -bad.com good.com", as ParseLine always did.
+func (p *Parser) formatEntry(entry Entry) (string, bool) {
+	if p.AnnotateIDN {
+		return p.renderEntry(entry), true
+	}
+
+	line := p.trimComment(p.trimSpace(entry.Raw))
+	line = p.trimIPAddress(line)
+	line = p.onlyIDNACompatible(line)
+
+	if p.OmitEmptyLine && strings.TrimSpace(line) == "" {
+		return "", false
+	}
+
+	return p.prependIPAddress(line), true
+}
+
+// idnaProfile returns the IDNA profile of the Parser. It falls back to the one
+// of TransformToASCII and TransformToUnicode if IDNA is not set.
+func (p *Parser) idnaProfile() IDNAProfile {
+	if p.IDNA == nil {
+		return NewIDNAProfile(IDNALookup)
+	}
+
+	return *p.IDNA
+}
+
+// parseEntry parses the given line into an Entry according to the settings in
+// the Parser. It returns false if the line should be omitted. The violation of
+// the Sinkhole policy is returned as well if the line is omitted for it.
+func (p *Parser) parseEntry(line string) (Entry, *SinkholeViolation, bool) {
+	entry := Entry{Raw: line}
+
+	if p.Sinkhole != nil {
+		if violation, isHijack := p.Sinkhole.Check(line); isHijack {
+			return entry, &violation, false
+		}
+	}
+
+	body, comment, hasComment := strings.Cut(p.trimSpace(line), string(DelimComnt))
+	if hasComment && !p.TrimComment {
+		entry.Comment = comment
+	}
+
+	fields := strings.Fields(body)
+
+	numIP := 0
+	for numIP < len(fields) && IsIPAddress(fields[numIP]) {
+		numIP++
+	}
+
+	if numIP > 0 && !p.TrimIPAddress {
+		entry.IP = fields[0]
+		numIP = 1 // following IP addresses are treated as aliases
+	}
+
+	numSpecialUse := 0
+
+	for _, field := range fields[numIP:] {
+		hostName, isSpecialUse, ok := p.convertHost(field)
+		if isSpecialUse {
+			numSpecialUse++
+		}
+
+		if ok {
+			entry.Hostnames = append(entry.Hostnames, hostName)
+		}
+	}
+
+	// All the host names were special-use. Do not leave the IP address alone.
+	if numSpecialUse > 0 && len(entry.Hostnames) == 0 {
+		return entry, nil, false
+	}
+
+	if p.TrimIPAddress && p.UseIPAddress != "" && len(entry.Hostnames) > 0 {
+		entry.IP = p.UseIPAddress
+	}
+
+	keepComment := hasComment && !p.TrimComment
+	if p.OmitEmptyLine && entry.IsEmpty() && !keepComment {
+		return entry, nil, false
+	}
+
+	return entry, nil, true
+}
+
+// maxLineLength returns the maximum length of a line in bytes. It falls back to
+// DefaultMaxLineLength if MaxLineLength is not set.
+func (p *Parser) maxLineLength() int {
+	if p.MaxLineLength < 1 {
+		return DefaultMaxLineLength
+	}
+
+	return p.MaxLineLength
+}
+
+// numWorkers returns the number of workers to parse the lines concurrently.
+// It falls back to GOMAXPROCS if Concurrency is not set.
+func (p *Parser) numWorkers() int {
+	if p.Concurrency < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+
+	return p.Concurrency
+}
+
+// onlyIDNACompatible edits the line as a string to keep the words converted by
+// convertHost. The words dropped are replaced by an empty string leaving their
+// spaces.
 func (p *Parser) onlyIDNACompatible(line string) string {
-	if !p.IDNACompatible {
+	if !p.IDNACompatible && !p.DropSpecialUse && !p.OutputUnicode {
 		return line
 	}
 
@@ -190,60 +618,188 @@ func (p *Parser) onlyIDNACompatible(line string) string {
 	trimmed := strings.Split(TrimWordGaps(line), " ")
 
 	for index, chunk := range trimmed {
-		hostASCII, err := TransformToASCII(chunk)
-
-		if err != nil || !IsCompatibleIDNA2008(hostASCII) {
-			hostASCII = ""
-		}
-
-		trimmed[index] = hostASCII
+		trimmed[index], _, _ = p.convertHost(chunk)
 	}
 
 	return strings.Join(trimmed, " ")
 }
 
+// prependIPAddress prepends UseIPAddress to the line if TrimIPAddress is true.
 func (p *Parser) prependIPAddress(line string) string {
 	if IsCommentLine(line) || p.UseIPAddress == "" || !p.TrimIPAddress {
 		return line
 	}
 
-	if p.UseIPAddress != "" && IsIPAddress(line) {
-		return line
+	return p.UseIPAddress + " " + line
+}
+
+// publicSuffixes returns the PublicSuffixes or the embedded snapshot if nil.
+func (p *Parser) publicSuffixes() *PublicSuffixList {
+	if p.PublicSuffixes == nil {
+		return defaultPublicSuffixes()
 	}
 
-	return p.UseIPAddress + " " + line
+	return p.PublicSuffixes
+}
+
+// parseFSTo reads the named file from fsys and writes the parsed lines to
+// fileOut.
+func (p *Parser) parseFSTo(ctx context.Context, fsys fs.FS, name string, fileOut io.Writer) error {
+	if fileOut == nil {
+		return errors.New("the given io.Writer is nil")
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the file")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	err = p.ParseReaderContext(ctx, file, fileOut)
+
+	p.setStatsSource(name)
+
+	return err
 }
 
-func (p *Parser) scanFile(inFile io.Reader, lines []string) error {
-	// Prepare reading the file.
-	countLines := 0
+// parseBatch parses the given lines with a bounded number of workers and stores
+// the results to entries in the same order. The entry is nil if the line was
+// omitted. The violations of the Sinkhole policy are stored to hijacks in the
+// same way. numLines holds the line numbers of the lines in the source.
+//
+// The workers stop as soon as ctx is done leaving the rest of entries and
+// hijacks as is.
+func (p *Parser) parseBatch(ctx context.Context, lines []string, numLines []int, entries []*Entry, hijacks []*SinkholeViolation) {
+	if len(lines) == 0 {
+		return
+	}
+
+	numWorkers := min(p.numWorkers(), len(lines))
+	sizeChunk := (len(lines) + numWorkers - 1) / numWorkers
 	wgrp := new(sync.WaitGroup)
-	scanBuf := bufio.NewScanner(inFile)
 
-	for scanBuf.Scan() {
-		line := scanBuf.Text()
+	// Each worker parses its own chunk of the batch, so the order is kept
+	// without any locking.
+	for begin := 0; begin < len(lines); begin += sizeChunk {
+		end := min(begin+sizeChunk, len(lines))
+
+		wgrp.Go(func() {
+			for index := begin; index < end; index++ {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				entries[index], hijacks[index] = nil, nil
+
+				entry, hijack, ok := p.parseEntry(lines[index])
+				if hijack != nil {
+					hijack.Line = numLines[index]
+					hijacks[index] = hijack
+				}
+
+				if ok {
+					entry.Line = numLines[index]
+					entries[index] = &entry
+				}
+			}
+		})
+	}
 
-		wgrp.Add(1)
+	wgrp.Wait()
+}
 
-		go func(line string, index int) {
-			defer wgrp.Done()
+// scanFile reads the lines from inFile and parses them concurrently in batches
+// of sizeScanBatch lines. The parsed entries are passed to emit in the order of
+// the input. The entry is nil if the line was omitted. Scanning stops at the
+// first error returned by emit and the error is returned as is.
+//
+// The number of goroutines is bounded by Concurrency regardless of the size of
+// the input. The lines longer than MaxLineLength are handled according to the
+// LongLine policy and reported in the returned ParseStats as well as the lines
+// violating the Sinkhole policy.
+//
+// If ctx is done, it stops and returns ctx.Err() wrapped with the number of
+// lines processed.
+func (p *Parser) scanFile(ctx context.Context, inFile io.Reader, emit func(entry *Entry) error) (ParseStats, error) {
+	batch := make([]string, 0, sizeScanBatch)
+	numLines := make([]int, 0, sizeScanBatch)
+	entries := make([]*Entry, sizeScanBatch)
+	violations := make([]*SinkholeViolation, sizeScanBatch)
+	scanBuf := newLineReader(inFile, p.maxLineLength(), p.LongLine)
+	numProcessed := 0
+
+	var (
+		hijacks     []SinkholeViolation
+		unconverted []ConversionFailure
+	)
+
+	stats := func() ParseStats {
+		stats := scanBuf.stats()
+		stats.Hijacks = hijacks
+		stats.Unconverted = unconverted
+
+		return stats
+	}
 
-			parsed, ok := p.ParseLine(line)
-			if ok {
-				lines[index] = parsed + string(LF)
+	flush := func() error {
+		// Clear the violations of the last batch not to report them again
+		// if the workers stop halfway.
+		clear(violations)
+		p.parseBatch(ctx, batch, numLines, entries, violations)
+
+		for _, violation := range violations[:len(batch)] {
+			if violation != nil {
+				hijacks = append(hijacks, *violation)
 			}
-		}(line, countLines)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "canceled after %d lines processed", numProcessed)
+		}
+
+		unconverted = append(unconverted, p.checkUnicode(entries[:len(batch)])...)
+
+		for _, entry := range entries[:len(batch)] {
+			if err := emit(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(numLines) > 0 {
+			numProcessed = numLines[len(numLines)-1]
+		}
+
+		batch = batch[:0]
+		numLines = numLines[:0]
+
+		return nil
+	}
+
+	for scanBuf.Scan() {
+		batch = append(batch, scanBuf.Text())
+		numLines = append(numLines, scanBuf.Line())
+
+		if len(batch) < sizeScanBatch {
+			continue
+		}
 
-		countLines++
+		if err := flush(); err != nil {
+			return stats(), err
+		}
 	}
 
 	if scanBuf.Err() != nil {
-		return errors.Wrap(scanBuf.Err(), "failed to read/scan the file")
+		return stats(), errors.Wrap(scanBuf.Err(), "failed to read/scan the file")
 	}
 
-	wgrp.Wait()
+	err := flush()
 
-	return nil
+	return stats(), err
 }
 
 // sortAsReverseDNS sorts the given slice as reversed DNS hosts.
@@ -262,7 +818,90 @@ func (p *Parser) sortAsReverseDNS(lines []string) []string {
 	return lines
 }
 
+// sortByDomain sorts the given slice by the registrable domains of the first
+// host names of the lines. See domainSortKey.
+func (p *Parser) sortByDomain(lines []string) []string {
+	slices.SortStableFunc(lines, func(a string, b string) int {
+		return strings.Compare(p.domainSortKey(a), p.domainSortKey(b))
+	})
+
+	return lines
+}
+
+// sortEntries sorts the given entries by their rendered lines if sorting is
+// enabled in the settings.
+func (p *Parser) sortEntries(entries []Entry) []Entry {
+	if !p.SortAfterParse && !p.SortAsReverseDNS && !p.SortByDomain {
+		return entries
+	}
+
+	slices.SortStableFunc(entries, func(a Entry, b Entry) int {
+		lineA, _ := p.formatEntry(a)
+		lineB, _ := p.formatEntry(b)
+
+		if p.SortByDomain {
+			return strings.Compare(p.domainSortKey(lineA), p.domainSortKey(lineB))
+		}
+
+		if p.SortAsReverseDNS {
+			return strings.Compare(ReverseDNS(lineA), ReverseDNS(lineB))
+		}
+
+		return strings.Compare(lineA, lineB)
+	})
+
+	return entries
+}
+
+// setStatsSource sets the source of the violations and the failures in the
+// stats of the last parse.
+func (p *Parser) setStatsSource(source string) {
+	p.mutx.Lock()
+	defer p.mutx.Unlock()
+
+	for index := range p.stats.Hijacks {
+		p.stats.Hijacks[index].Source = source
+	}
+
+	for index := range p.stats.Unconverted {
+		p.stats.Unconverted[index].Source = source
+	}
+}
+
+// renderEntry renders the entry from the fields. The other forms of the host
+// names are appended to the comment if AnnotateIDN is true.
+func (p *Parser) renderEntry(entry Entry) string {
+	if !p.AnnotateIDN {
+		return entry.String()
+	}
+
+	counterparts := []string{}
+
+	for _, hostName := range entry.Hostnames {
+		if converted, ok := p.counterpart(hostName); ok {
+			counterparts = append(counterparts, converted)
+		}
+	}
+
+	if len(counterparts) > 0 {
+		entry.Comment = strings.TrimRight(entry.Comment, Cutset) + " " + strings.Join(counterparts, " ")
+	}
+
+	return entry.String()
+}
+
+func (p *Parser) setStats(stats ParseStats) {
+	p.mutx.Lock()
+	defer p.mutx.Unlock()
+
+	p.stats = stats
+}
+
 func (p *Parser) sortSlices(lines []string) []string {
+	if p.SortByDomain {
+		return p.sortByDomain(lines)
+	}
+
 	if p.SortAsReverseDNS {
 		return p.sortAsReverseDNS(lines)
 	}
@@ -284,6 +923,14 @@ func (p *Parser) trimComment(line string) string {
 	return trimmed
 }
 
+func (p *Parser) trimIPAddress(line string) string {
+	if IsCommentLine(line) || !p.TrimIPAddress {
+		return line
+	}
+
+	return TrimIPAdd(line)
+}
+
 func (p *Parser) trimSpace(line string) string {
 	trimmed := line
 
@@ -302,10 +949,33 @@ func (p *Parser) trimSpace(line string) string {
 	return trimmed
 }
 
-func (p *Parser) trimIPAddress(line string) string {
-	if IsCommentLine(line) || !p.TrimIPAddress {
-		return line
+// toUnicode converts the host name in punycode to Unicode if OutputUnicode is
+// true. The host name is left as is if it fails. See checkUnicode.
+func (p *Parser) toUnicode(hostName string) string {
+	if !p.OutputUnicode || !hasACELabel(hostName) {
+		return hostName
 	}
 
-	return TrimIPAdd(line)
+	hostUnicode, err := p.idnaProfile().ToUnicode(hostName)
+	if err != nil {
+		return hostName
+	}
+
+	return hostUnicode
+}
+
+// ----------------------------------------------------------------------------
+//  Functions (Private)
+// ----------------------------------------------------------------------------
+
+// hasACELabel returns true if any label of the host name begins with "xn--".
+// Such as "www.xn--gpher-jua.com".
+func hasACELabel(hostName string) bool {
+	for label := range strings.SplitSeq(strings.ToLower(hostName), ".") {
+		if strings.HasPrefix(label, "xn--") {
+			return true
+		}
+	}
+
+	return false
 }
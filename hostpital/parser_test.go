@@ -1,10 +1,18 @@
 package hostpital
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/pkg/errors"
@@ -12,6 +20,79 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// ----------------------------------------------------------------------------
+//  Parser.All()
+// ----------------------------------------------------------------------------
+
+func TestParser_All(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.Deduplicate = DedupeHost
+
+	input := "# comment\n0.0.0.0 a.example.com b.example.com\n\n0.0.0.0 b.example.com c.example.com\na.example.com\n"
+	actual := []Entry{}
+
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		require.NoError(t, err)
+
+		actual = append(actual, entry)
+	}
+
+	require.Len(t, actual, 2, "it should omit the comment and empty lines")
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, actual[0].Hostnames)
+	assert.Equal(t, []string{"c.example.com"}, actual[1].Hostnames, "it should dedupe the entries")
+	assert.Equal(t, 4, actual[1].Line)
+	assert.Equal(t, ParseStats{LinesRead: 5, Duplicates: 2}, parser.Stats())
+}
+
+func TestParser_All_break(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	input := strings.Repeat("a.example.com\n", sizeScanBatch*3)
+	count := 0
+
+	for _, err := range parser.All(strings.NewReader(input)) {
+		require.NoError(t, err)
+
+		count++
+
+		break
+	}
+
+	require.Equal(t, 1, count)
+	assert.Equal(t, sizeScanBatch, parser.Stats().LinesRead,
+		"it should stop reading the input after the current batch")
+}
+
+func TestParser_All_read_error(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.MaxLineLength = 20
+
+	input := "a.example.com\n" + strings.Repeat("b", 30) + ".example.com\nc.example.com\n"
+	actual := []string{}
+
+	var errLast error
+
+	for entry, err := range parser.All(strings.NewReader(input)) {
+		if err != nil {
+			errLast = err
+
+			continue
+		}
+
+		actual = append(actual, entry.String())
+	}
+
+	require.Error(t, errLast)
+	assert.Contains(t, errLast.Error(), "failed to read from reader")
+	assert.Contains(t, errLast.Error(), "line 2 is longer than the max line length of 20 bytes")
+	assert.Empty(t, actual, "the batch of the failed line should not be yielded")
+}
+
 // ----------------------------------------------------------------------------
 //  Parser.CountLines()
 // ----------------------------------------------------------------------------
@@ -33,11 +114,94 @@ func TestParser_CountLines(t *testing.T) {
 	}
 }
 
+func TestParser_CountLines_golden(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte("example.com\nexample.net\n"), 0o600))
+
+	parser := NewParser()
+	result, err := parser.CountLines(pathFile)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result, "it should count the lines of the file")
+}
+
+func TestParser_CountLines_dir(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	_, err := parser.CountLines(t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to count lines", "it should contain the error reason")
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.parseEntry()
+// ----------------------------------------------------------------------------
+
+func TestParser_parseEntry(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parser.TrimIPAddress = false
+	parser.TrimComment = false
+
+	entry, _, ok := parser.parseEntry("0.0.0.0  127.0.0.1 göpher.com  my_host.com # comment")
+
+	require.True(t, ok)
+	assert.Equal(t, "0.0.0.0", entry.IP, "it should keep the leading IP address")
+	assert.Equal(t, []string{"127.0.0.1", "xn--gpher-jua.com"}, entry.Hostnames,
+		"following IP addresses should be aliases and incompatible hosts should be dropped")
+	assert.Equal(t, " comment", entry.Comment, "it should keep the comment as is")
+	assert.Equal(t, "0.0.0.0 127.0.0.1 xn--gpher-jua.com # comment", entry.String())
+}
+
+func TestParser_parseEntry_omit_empty(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parser.UseIPAddress = "0.0.0.0"
+
+	_, _, ok := parser.parseEntry("123.123.123.123 # no host names")
+	require.False(t, ok, "it should omit the line if nothing is left after trimming")
+
+	parser.OmitEmptyLine = false
+
+	entry, _, ok := parser.parseEntry("123.123.123.123 # no host names")
+	require.True(t, ok, "it should not omit the line if OmitEmptyLine is false")
+	assert.Empty(t, entry.IP, "it should not set UseIPAddress to a line without host names")
+}
+
+func TestParser_parseEntry_hijack(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	parser := NewParser()
+	parser.Sinkhole = policy
+
+	_, hijack, ok := parser.parseEntry("203.0.113.7 login.example.com")
+
+	require.False(t, ok, "it should omit the hijacking line")
+	require.NotNil(t, hijack, "it should return the violation to report")
+	assert.Equal(t, "203.0.113.7", hijack.Address)
+
+	_, hijack, ok = parser.parseEntry("0.0.0.0 ads.example.com")
+
+	require.True(t, ok)
+	assert.Nil(t, hijack)
+}
+
 // ----------------------------------------------------------------------------
-//  Parser.onlyIDNACompatible()
+//  Parser.ParseLine()
 // ----------------------------------------------------------------------------
 
-func TestParser_onlyIDNACompatible(t *testing.T) {
+func TestParser_ParseLine_idna_compatible(t *testing.T) {
 	t.Parallel()
 
 	parser := NewParser()
@@ -45,21 +209,279 @@ func TestParser_onlyIDNACompatible(t *testing.T) {
 	{
 		parser.IDNACompatible = false
 
-		expect := "foo bar baz"
-		actual := parser.onlyIDNACompatible(expect)
+		actual, ok := parser.ParseLine("foo bar baz")
 
-		require.Equal(t, expect, actual, "it should return as is if IDNACompatible is false")
+		require.True(t, ok)
+		require.Equal(t, "foo bar baz", actual, "it should return as is if IDNACompatible is false")
 	}
 	{
 		parser.IDNACompatible = true
+		parser.OmitEmptyLine = false
+
+		actual, ok := parser.ParseLine("# this is a sample comment line")
+
+		require.True(t, ok)
+		require.Empty(t, actual, "it should trim the comment line if TrimComment is true")
+	}
+	{
+		parser.TrimComment = false
 
 		expect := "# this is a sample comment line"
-		actual := parser.onlyIDNACompatible(expect)
+		actual, ok := parser.ParseLine(expect)
 
+		require.True(t, ok)
 		require.Equal(t, expect, actual, "it should return as is if the line is a comment")
 	}
 }
 
+func TestParser_ParseLine_omit_empty_line(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	actual, ok := parser.ParseLine("# comment line")
+
+	require.False(t, ok, "it should return false if the line is omitted")
+	require.Empty(t, actual, "it should return empty string if the line is omitted")
+}
+
+func TestParser_ParseLine_keeps_layout(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parser.IDNACompatible = false
+	parser.TrimIPAddress = false
+	parser.TrimComment = false
+
+	const line = "0.0.0.0\t\texample.com    # comment"
+
+	actual, ok := parser.ParseLine("   " + line + "   ")
+
+	require.True(t, ok)
+	require.Equal(t, line, actual, "it should keep the layout if nothing normalizes the line")
+}
+
+func TestParser_ParseLine_use_ip_address(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	// Regular settings
+	{
+		parser.UseIPAddress = "0.0.0.0" // set an IP address to prepend
+
+		actual, ok := parser.ParseLine("foo.example.com")
+
+		require.True(t, ok)
+		require.Equal(t, "0.0.0.0 foo.example.com", actual,
+			"it should return the input with prepended IP address set")
+	}
+
+	// IP address is empty
+	{
+		parser.UseIPAddress = "" // set to empty
+
+		actual, ok := parser.ParseLine("bar.example.com")
+
+		require.True(t, ok)
+		require.Equal(t, "bar.example.com", actual, "it should return as is if UseIPAddress is empty")
+	}
+
+	// Input is an IP address
+	{
+		parser.UseIPAddress = "0.0.0.0" // set an IP address to prepend
+		parser.TrimIPAddress = false
+
+		actual, ok := parser.ParseLine("127.0.0.1")
+
+		require.True(t, ok)
+		require.Equal(t, "127.0.0.1", actual, "it should return as is if the input is an IP address")
+	}
+}
+
+func TestParser_ParseLine_edits_line_as_string(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		setup  func(parser *Parser)
+		input  string
+		expect string
+	}{
+		// Dropped host names leave their spaces
+		{input: "0.0.0.0 -bad.com good.com", expect: " good.com"},
+		{
+			setup:  func(parser *Parser) { parser.UseIPAddress = "127.0.0.1" },
+			input:  "0.0.0.0 -bad.com good.com",
+			expect: "127.0.0.1  good.com",
+		},
+		// Words of inline comments are checked as host names
+		{
+			setup:  func(parser *Parser) { parser.TrimComment = false },
+			input:  "0.0.0.0 example.com # comment",
+			expect: "example.com  comment",
+		},
+		{
+			setup:  func(parser *Parser) { parser.TrimComment = false },
+			input:  "0.0.0.0 #",
+			expect: "#",
+		},
+		// Comment lines are not trimmed as IP addresses
+		{
+			setup:  func(parser *Parser) { parser.TrimComment = false },
+			input:  "# this is a comment line",
+			expect: "# this is a comment line",
+		},
+		// IP addresses are checked as host names if not trimmed
+		{
+			setup:  func(parser *Parser) { parser.TrimIPAddress = false },
+			input:  "::1 localhost",
+			expect: " localhost",
+		},
+		{
+			setup:  func(parser *Parser) { parser.TrimIPAddress = false },
+			input:  "0.0.0.0 _dmarc.example.com",
+			expect: "0.0.0.0 ",
+		},
+		// UseIPAddress is prepended to the empty lines as well
+		{
+			setup: func(parser *Parser) {
+				parser.UseIPAddress = "127.0.0.1"
+				parser.OmitEmptyLine = false
+			},
+			input:  "0.0.0.0",
+			expect: "127.0.0.1 ",
+		},
+	} {
+		parser := NewParser()
+
+		if test.setup != nil {
+			test.setup(parser)
+		}
+
+		actual, ok := parser.ParseLine(test.input)
+
+		require.True(t, ok, "input: %q", test.input)
+		assert.Equal(t, test.expect, actual, "input: %q", test.input)
+
+		// ParseString and ParseReader render the same
+		assert.Equal(t, test.expect, parser.ParseString(test.input), "input: %q", test.input)
+
+		output := new(strings.Builder)
+
+		require.NoError(t, parser.ParseReader(strings.NewReader(test.input), output))
+		assert.Equal(t, test.expect+"\n", output.String(), "input: %q", test.input)
+	}
+}
+
+func TestParser_ParseLine_omit_empty_after_edit(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.TrimIPAddress = false
+
+	// The IPv6 address is dropped as a host name leaving the line empty
+	actual, ok := parser.ParseLine("::1")
+
+	require.False(t, ok)
+	assert.Empty(t, actual)
+	assert.Equal(t, "\na.com", parser.ParseString("::1\na.com"))
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.Deduplicate
+// ----------------------------------------------------------------------------
+
+func TestParser_Deduplicate(t *testing.T) {
+	t.Parallel()
+
+	const input = `0.0.0.0 a.example.com b.example.com
+0.0.0.0 a.example.com b.example.com
+127.0.0.1 B.example.com c.example.com # comment
+0.0.0.0 c.example.com
+`
+
+	for index, test := range []struct {
+		expect        string
+		mode          DedupeMode
+		numDuplicates int
+	}{
+		{
+			mode:          DedupeNone,
+			numDuplicates: 0,
+			expect: "0.0.0.0 a.example.com b.example.com\n0.0.0.0 a.example.com b.example.com\n" +
+				"127.0.0.1 b.example.com c.example.com\n0.0.0.0 c.example.com\n",
+		},
+		{
+			mode:          DedupeLine,
+			numDuplicates: 1,
+			expect: "0.0.0.0 a.example.com b.example.com\n" +
+				"127.0.0.1 b.example.com c.example.com\n0.0.0.0 c.example.com\n",
+		},
+		{
+			mode:          DedupeHost,
+			numDuplicates: 4,
+			expect:        "0.0.0.0 a.example.com b.example.com\n127.0.0.1 c.example.com\n",
+		},
+		{
+			mode:          DedupeHostIP,
+			numDuplicates: 2,
+			expect: "0.0.0.0 a.example.com b.example.com\n" +
+				"127.0.0.1 b.example.com c.example.com\n0.0.0.0 c.example.com\n",
+		},
+	} {
+		parser := NewParser()
+
+		parser.TrimIPAddress = false
+		parser.Deduplicate = test.mode
+
+		// ParseString
+		actual := parser.ParseString(input)
+
+		// ParseString keeps the omitted lines as empty lines
+		linesLeft := slices.DeleteFunc(strings.Split(actual, "\n"), func(line string) bool { return line == "" })
+
+		assert.Equal(t, test.expect, strings.Join(linesLeft, "\n")+"\n",
+			"test #%d: ParseString with mode %s", index+1, test.mode)
+		assert.Equal(t, test.numDuplicates, parser.Stats().Duplicates,
+			"test #%d: ParseString with mode %s", index+1, test.mode)
+
+		// ParseFileTo
+		pathFile := filepath.Join(t.TempDir(), "hosts")
+		require.NoError(t, os.WriteFile(pathFile, []byte(input), 0o600))
+
+		parsed, err := parser.ParseFile(pathFile)
+
+		require.NoError(t, err)
+		assert.Equal(t, test.expect, parsed, "test #%d: ParseFile with mode %s", index+1, test.mode)
+		assert.Equal(t, ParseStats{LinesRead: 4, Duplicates: test.numDuplicates}, parser.Stats(),
+			"test #%d: ParseFile with mode %s", index+1, test.mode)
+
+		// ParseEntriesFrom
+		entries, err := parser.ParseEntriesFrom(strings.NewReader(input))
+
+		require.NoError(t, err)
+		assert.Equal(t, strings.Count(test.expect, "\n"), len(entries),
+			"test #%d: ParseEntriesFrom with mode %s", index+1, test.mode)
+		assert.Equal(t, test.numDuplicates, parser.Stats().Duplicates,
+			"test #%d: ParseEntriesFrom with mode %s", index+1, test.mode)
+	}
+}
+
+func TestParser_Deduplicate_keeps_comments(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parser.TrimComment = false
+	parser.Deduplicate = DedupeLine
+
+	actual := parser.ParseString("# comment\nexample.com\n# comment\nexample.com")
+
+	require.Equal(t, "# comment\nexample.com\n# comment\n", actual,
+		"it should not remove duplicate lines without host names")
+}
+
 // ----------------------------------------------------------------------------
 //  Parser.ParseFile()
 // ----------------------------------------------------------------------------
@@ -78,6 +500,38 @@ func TestParser_ParseFile(t *testing.T) {
 		"it should contain the error reason")
 }
 
+// ----------------------------------------------------------------------------
+//  Parser.ParseFS()
+// ----------------------------------------------------------------------------
+
+func TestParser_ParseFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"lists/hosts": {Data: []byte("# comment\n0.0.0.0 example.com\n0.0.0.0 example.net\n")},
+	}
+
+	parser := NewParser()
+
+	parsed, err := parser.ParseFS(fsys, "lists/hosts")
+
+	require.NoError(t, err)
+	assert.Equal(t, "example.com\nexample.net\n", parsed)
+}
+
+func TestParser_ParseFS_not_exist(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parsed, err := parser.ParseFS(fstest.MapFS{}, "hosts")
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, parsed, "it should be empty on error")
+	assert.Contains(t, err.Error(), "failed to parse the file: failed to open the file")
+}
+
 // ----------------------------------------------------------------------------
 //  Parser.ParseFileTo()
 // ----------------------------------------------------------------------------
@@ -134,49 +588,361 @@ func TestParser_ParseFileTo_outfile_is_dir(t *testing.T) {
 		"it should contain the error reason")
 }
 
+func TestParser_ParseFileTo_file_not_exist(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	pathFile := filepath.Join(t.TempDir(), "not_exist")
+
+	err := parser.ParseFileTo(pathFile, new(strings.Builder))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open the file",
+		"it should contain the error reason")
+}
+
 // ----------------------------------------------------------------------------
-//  Parser.prependIPAddress()
+//  Parser.ParseReader()
 // ----------------------------------------------------------------------------
 
-func TestParser_prependIPAddress(t *testing.T) {
+func TestParser_ParseReader_pipe(t *testing.T) {
 	t.Parallel()
 
+	numLines := sizeScanBatch*2 + 10 // over multiple batches
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		for index := range numLines {
+			_, _ = fmt.Fprintf(pipeWriter, "0.0.0.0 host%d.example.com\n", index+1)
+		}
+
+		_ = pipeWriter.Close()
+	}()
+
 	parser := NewParser()
+	output := new(strings.Builder)
 
-	// Regular settings
-	{
-		parser.UseIPAddress = "0.0.0.0" // set an IP address to prepend
+	err := parser.ParseReader(pipeReader, output)
+	require.NoError(t, err, "it should parse non-seekable readers")
 
-		input := "foo.example.com"
-		expect := "0.0.0.0 foo.example.com"
-		actual := parser.prependIPAddress(input)
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
 
-		require.Equal(t, expect, actual, "it should return the input with prepended IP address set")
+	require.Len(t, lines, numLines)
+
+	for index, line := range lines {
+		require.Equal(t, fmt.Sprintf("host%d.example.com", index+1), line,
+			"it should keep the order of the input")
 	}
 
-	// IP address is empty
-	{
-		parser.UseIPAddress = "" // set to empty
+	assert.Equal(t, ParseStats{LinesRead: numLines}, parser.Stats())
+}
+
+func TestParser_ParseReader_sort(t *testing.T) {
+	t.Parallel()
 
-		input := "bar.example.com"
-		expect := "bar.example.com"
-		actual := parser.prependIPAddress(input)
+	parser := NewParser()
+	parser.SortAfterParse = true
 
-		require.Equal(t, expect, actual, "it should return as is if UseIPAddress is empty")
+	output := new(strings.Builder)
+
+	err := parser.ParseReader(strings.NewReader("host3.example.com\nhost1.example.com\nhost2.example.com"), output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "host1.example.com\nhost2.example.com\nhost3.example.com\n", output.String())
+}
+
+func TestParser_ParseReader_nil_writer(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	err := parser.ParseReader(strings.NewReader("example.com"), nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the given io.Writer is nil",
+		"it should contain the error reason")
+}
+
+func TestParser_ParseReader_read_error(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	dummy := &dummyReader{
+		dummyFn: func(_ []byte) (int, error) {
+			return 0, errors.New("forced error")
+		},
 	}
 
-	// Input is an IP address
-	{
-		parser.UseIPAddress = "0.0.0.0" // set an IP address to prepend
+	err := parser.ParseReader(dummy, new(strings.Builder))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read from reader",
+		"it should contain the error reason")
+	assert.Contains(t, err.Error(), "forced error",
+		"it should contain the underlying error")
+}
 
-		input := "127.0.0.1"
-		expect := "127.0.0.1"
-		actual := parser.prependIPAddress(input)
+func TestParser_ParseReader_write_error(t *testing.T) {
+	t.Parallel()
+
+	for _, isSort := range []bool{false, true} {
+		for _, numLines := range []int{1, sizeScanBatch} {
+			parser := NewParser()
+			parser.SortAfterParse = isSort
+
+			// Small inputs fail on flush and large inputs fail while writing
+			input := strings.Repeat("example.com\n", numLines)
+
+			err := parser.ParseReader(strings.NewReader(input), new(dummyWriter))
+
+			require.Error(t, err, "lines: %d, sort: %v", numLines, isSort)
+			assert.Contains(t, err.Error(), "failed to write to io.Writer",
+				"it should contain the error reason. lines: %d, sort: %v", numLines, isSort)
+			assert.Contains(t, err.Error(), "forced error",
+				"it should contain the underlying error. lines: %d, sort: %v", numLines, isSort)
+		}
+	}
+}
+
+func TestParser_ParseReader_longer_than_scanner_limit(t *testing.T) {
+	t.Parallel()
+
+	// Longer than the 64KB limit of bufio.Scanner
+	hosts := make([]string, 10_000)
+	for index := range hosts {
+		hosts[index] = fmt.Sprintf("host%d.example.com", index)
+	}
+
+	input := "0.0.0.0 " + strings.Join(hosts, " ") + "\nlast.example.com\n"
 
-		require.Equal(t, expect, actual, "it should return as is if the input is an IP address")
+	parser := NewParser()
+	output := new(strings.Builder)
+
+	err := parser.ParseReader(strings.NewReader(input), output)
+
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join(hosts, " ")+"\nlast.example.com\n", output.String(),
+		"it should not truncate the output")
+}
+
+func TestParser_ParseReader_long_line_policy(t *testing.T) {
+	t.Parallel()
+
+	input := "0.0.0.0 a.example.com b.example.com c.example.com\n0.0.0.0 d.example.com\n"
+
+	for _, test := range []struct {
+		expect string
+		stats  ParseStats
+		policy LongLinePolicy
+	}{
+		{
+			policy: LongLineSkip,
+			expect: "0.0.0.0 d.example.com\n",
+			stats:  ParseStats{LinesRead: 2, LongLines: []int{1}},
+		},
+		{
+			policy: LongLineSplit,
+			expect: "0.0.0.0 a.example.com b.example.com\n0.0.0.0 c.example.com\n0.0.0.0 d.example.com\n",
+			stats:  ParseStats{LinesRead: 2, LongLines: []int{1}},
+		},
+	} {
+		parser := NewParser()
+
+		parser.TrimIPAddress = false
+		parser.MaxLineLength = 40
+		parser.LongLine = test.policy
+
+		output := new(strings.Builder)
+
+		err := parser.ParseReader(strings.NewReader(input), output)
+
+		require.NoError(t, err, "policy: %s", test.policy)
+		assert.Equal(t, test.expect, output.String(), "policy: %s", test.policy)
+		assert.Equal(t, test.stats, parser.Stats(), "policy: %s", test.policy)
 	}
 }
 
+func TestParser_ParseReader_long_line_fail(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.MaxLineLength = 10
+
+	err := parser.ParseReader(strings.NewReader("a.example\nlong.example.com\n"), new(strings.Builder))
+
+	require.Error(t, err, "it should not succeed silently on long lines")
+	assert.Contains(t, err.Error(), "failed to read from reader")
+	assert.Contains(t, err.Error(), "line 2 is longer than the max line length of 10 bytes")
+	assert.Equal(t, ParseStats{LinesRead: 1, LongLines: []int{2}}, parser.Stats(),
+		"it should report the long line")
+}
+
+func TestParser_MaxLineLength_fallback(t *testing.T) {
+	t.Parallel()
+
+	parser := new(Parser) // MaxLineLength is zero
+
+	require.Equal(t, DefaultMaxLineLength, parser.maxLineLength(),
+		"it should fall back to the default if not set")
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.ParseReaderContext()
+// ----------------------------------------------------------------------------
+
+// cancelingReader is an io.Reader that calls cancel once more than limit bytes
+// are read.
+type cancelingReader struct {
+	reader   io.Reader
+	cancel   context.CancelFunc
+	limit    int
+	numBytes int
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+
+	c.numBytes += n
+	if c.numBytes > c.limit {
+		c.cancel()
+	}
+
+	return n, err
+}
+
+func TestParser_ParseReaderContext_canceled(t *testing.T) {
+	t.Parallel()
+
+	const line = "a.example\n"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel while reading the second batch
+	input := &cancelingReader{
+		reader: strings.NewReader(strings.Repeat(line, sizeScanBatch*3)),
+		cancel: cancel,
+		limit:  len(line) * sizeScanBatch * 3 / 2,
+	}
+
+	parser := NewParser()
+	output := new(strings.Builder)
+
+	err := parser.ParseReaderContext(ctx, input, output)
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.Canceled, "it should wrap the context error")
+	assert.Contains(t, err.Error(), fmt.Sprintf("canceled after %d lines processed", sizeScanBatch),
+		"it should contain the progress")
+	assert.Equal(t, strings.Repeat(line, sizeScanBatch), output.String(),
+		"it should write the lines processed before the cancellation")
+}
+
+// canceledAfterContext is a context.Context that reports the cancellation from
+// the given number of calls to Err onwards without closing Done.
+type canceledAfterContext struct {
+	context.Context //nolint:containedctx // to wrap the context
+
+	calls atomic.Int32
+	after int32
+}
+
+func (c *canceledAfterContext) Err() error {
+	if c.calls.Add(1) >= c.after {
+		return context.Canceled
+	}
+
+	return nil
+}
+
+func TestParser_ParseReaderContext_canceled_before_sort(t *testing.T) {
+	t.Parallel()
+
+	// The first call is of scanFile after parsing the last batch. Cancel after
+	// it, which is before sorting and writing the lines.
+	ctx := &canceledAfterContext{Context: context.Background(), after: 2}
+
+	parser := NewParser()
+	parser.SortAfterParse = true
+
+	output := new(strings.Builder)
+
+	err := parser.ParseReaderContext(ctx, strings.NewReader("b.example\na.example\n"), output)
+
+	require.Error(t, err, "it should not return nil with the sorted lines left unwritten")
+	require.ErrorIs(t, err, context.Canceled, "it should wrap the context error")
+	assert.Contains(t, err.Error(), "canceled after 2 lines processed")
+	assert.Empty(t, output.String())
+}
+
+func TestParser_ParseFileContext_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	parser := NewParser()
+	output := new(strings.Builder)
+
+	err := parser.ParseFileContext(ctx, filepath.Join("testdata", "default.txt"), output)
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.Canceled, "it should wrap the context error")
+	assert.Contains(t, err.Error(), "canceled after 0 lines processed")
+	assert.Empty(t, output.String(), "it should not write anything")
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.Concurrency
+// ----------------------------------------------------------------------------
+
+func TestParser_Concurrency_keeps_order(t *testing.T) {
+	t.Parallel()
+
+	numLines := sizeScanBatch*2 + 7 // over multiple batches with a remainder
+	input := new(strings.Builder)
+
+	for index := range numLines {
+		if index%3 == 0 {
+			input.WriteString("# comment to be omitted\n")
+
+			continue
+		}
+
+		fmt.Fprintf(input, "0.0.0.0 host%d.example.com\n", index+1)
+	}
+
+	for _, concurrency := range []int{-1, 0, 1, 3, 64} {
+		parser := NewParser()
+		parser.Concurrency = concurrency
+
+		entries, err := parser.ParseEntriesFrom(strings.NewReader(input.String()))
+		require.NoError(t, err)
+
+		require.Len(t, entries, numLines-(numLines+2)/3,
+			"concurrency %d: it should omit the comment lines", concurrency)
+
+		for _, entry := range entries {
+			require.NotZero(t, entry.Line%3-1,
+				"concurrency %d: it should not contain the omitted lines", concurrency)
+			require.Equal(t, []string{fmt.Sprintf("host%d.example.com", entry.Line)}, entry.Hostnames,
+				"concurrency %d: it should keep the line number and the order", concurrency)
+		}
+
+		require.True(t, slices.IsSortedFunc(entries, func(a, b Entry) int { return a.Line - b.Line }),
+			"concurrency %d: it should keep the order of the input", concurrency)
+	}
+}
+
+func TestNewParser_concurrency(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	require.Equal(t, runtime.GOMAXPROCS(0), parser.Concurrency,
+		"it should default to GOMAXPROCS")
+}
+
 // ----------------------------------------------------------------------------
 //  Parser.scanFile()
 // ----------------------------------------------------------------------------
@@ -200,7 +966,7 @@ func TestParser_scanFile(t *testing.T) {
 		return 0, errors.New("forced error")
 	}
 
-	err := parser.scanFile(dummy, []string{"foo.example.com"})
+	_, err := parser.scanFile(context.Background(), dummy, func(*Entry) error { return nil })
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read/scan the file",
@@ -334,18 +1100,84 @@ func TestParser_trimComment(t *testing.T) {
 }
 
 // ----------------------------------------------------------------------------
-//  Parser.trimIPAddress()
+//  Parser.ParseEntries()
 // ----------------------------------------------------------------------------
 
-func TestParser_trimIPAddress(t *testing.T) {
+func TestParser_ParseEntries_file_not_exist(t *testing.T) {
 	t.Parallel()
 
 	parser := NewParser()
 
-	expect := "# this is a comment line"
-	actual := parser.trimIPAddress(expect)
+	entries, err := parser.ParseEntries(filepath.Join(t.TempDir(), "not_exist"))
+
+	require.Error(t, err)
+	require.Nil(t, entries, "it should be nil on error")
+	assert.Contains(t, err.Error(), "failed to open the file", "it should contain the error reason")
+}
+
+func TestParser_ParseEntries_path_is_dir(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	entries, err := parser.ParseEntries(t.TempDir())
+
+	require.Error(t, err)
+	require.Nil(t, entries, "it should be nil on error")
+	assert.Contains(t, err.Error(), "failed to read/scan the file", "it should contain the error reason")
+}
+
+func TestParser_ParseEntriesFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"lists/hosts": {Data: []byte("# comment\n0.0.0.0 example.com\n0.0.0.0 example.net\n")},
+	}
+
+	parser := NewParser()
 
-	require.Equal(t, expect, actual, "it should return as is if the line is a comment")
+	entries, err := parser.ParseEntriesFS(fsys, "lists/hosts")
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"example.net"}, entries[1].Hostnames)
+	assert.Equal(t, "lists/hosts", entries[1].Source, "it should set the name as the source")
+	assert.Equal(t, 3, entries[1].Line)
+
+	entries, err = parser.ParseEntriesFS(fsys, "not_exist")
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.Nil(t, entries, "it should be nil on error")
+}
+
+func TestParser_ParseEntries_sort(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	parser.SortAsReverseDNS = true
+
+	entries, err := parser.ParseEntriesFrom(strings.NewReader(heredoc.Doc(`
+		www.example.jp
+		www.example.com
+	`)))
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "www.example.com", entries[0].String())
+	assert.Equal(t, 2, entries[0].Line, "it should keep the line number of the source")
+	assert.Equal(t, "www.example.jp", entries[1].String())
+
+	parser.SortAsReverseDNS = false
+	parser.SortAfterParse = true
+
+	entries, err = parser.ParseEntriesFrom(strings.NewReader("b.example.com\na.example.jp\n"))
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a.example.jp", entries[0].String())
+	assert.Equal(t, "b.example.com", entries[1].String())
 }
 
 // ----------------------------------------------------------------------------
@@ -390,3 +1222,140 @@ func TestParser_trimSpace(t *testing.T) {
 			"it should trim trailing space if TrimTrailingSpace is true")
 	}
 }
+
+func TestParser_ParseLine_label_constraints(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+
+	actual, ok := parser.ParseLine("0.0.0.0 example.123 example.com")
+
+	require.True(t, ok)
+	assert.Equal(t, " example.com", actual, "hosts breaking the label constraints should be dropped")
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.OutputUnicode and Parser.AnnotateIDN
+// ----------------------------------------------------------------------------
+
+func TestParser_OutputUnicode(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.TrimIPAddress = false
+	parser.IDNACompatible = false
+	parser.OutputUnicode = true
+
+	var output strings.Builder
+
+	err := parser.ParseReader(strings.NewReader(heredoc.Doc(`
+		0.0.0.0   XN--GPHER-JUA.com www.example.com
+
+		0.0.0.0 www.xn--zz.com foo_bar.example.com
+	`)), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		0.0.0.0 göpher.com www.example.com
+		0.0.0.0 www.xn--zz.com foo_bar.example.com
+	`), output.String(), "the host names failing to convert should be left as is")
+
+	unconverted := parser.Stats().Unconverted
+
+	require.Len(t, unconverted, 1, "only the host names in punycode should be reported")
+	assert.Equal(t, "www.xn--zz.com", unconverted[0].Hostname)
+	assert.Equal(t, 3, unconverted[0].Line)
+	require.Error(t, unconverted[0].Err)
+	assert.Contains(t, unconverted[0].Err.Error(), "failed to convert host name to Unicode with the lookup profile")
+
+	assert.Equal(t, "0.0.0.0 göpher.com\n0.0.0.0 www.xn--zz.com",
+		parser.ParseString("0.0.0.0 xn--gpher-jua.com\n0.0.0.0 www.xn--zz.com"))
+	require.Len(t, parser.Stats().Unconverted, 1, "ParseString should report the failures as well")
+	assert.Equal(t, 2, parser.Stats().Unconverted[0].Line)
+}
+
+func TestParser_OutputUnicode_source(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte("0.0.0.0 xn--zz.com\n"), 0o600))
+
+	profile := NewIDNAProfile(IDNADisplay)
+
+	parser := NewParser()
+	parser.IDNA = &profile
+	parser.IDNACompatible = false
+	parser.OutputUnicode = true
+
+	entries, err := parser.ParseEntries(pathFile)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xn--zz.com"}, entries[0].Hostnames)
+	require.Len(t, parser.Stats().Unconverted, 1)
+	assert.Equal(t, pathFile, parser.Stats().Unconverted[0].Source)
+	assert.Contains(t, parser.Stats().Unconverted[0].Err.Error(), "with the display profile")
+}
+
+func TestParser_AnnotateIDN(t *testing.T) {
+	t.Parallel()
+
+	input := heredoc.Doc(`
+		0.0.0.0 göpher.com www.example.com
+		0.0.0.0 xn--bcher-kva.de göpher.com # blocked
+		0.0.0.0 www.example.com
+	`)
+
+	parser := NewParser()
+	parser.TrimIPAddress = false
+	parser.AnnotateIDN = true
+
+	for _, test := range []struct {
+		expect        string
+		dedupe        DedupeMode
+		outputUnicode bool
+		trimComment   bool
+	}{
+		{
+			expect: heredoc.Doc(`
+				0.0.0.0 xn--gpher-jua.com www.example.com # göpher.com
+				0.0.0.0 xn--bcher-kva.de xn--gpher-jua.com # bücher.de göpher.com
+				0.0.0.0 www.example.com
+			`),
+			trimComment: true,
+		},
+		{
+			expect: heredoc.Doc(`
+				0.0.0.0 göpher.com www.example.com # xn--gpher-jua.com
+				0.0.0.0 bücher.de göpher.com # blocked xn--bcher-kva.de xn--gpher-jua.com
+				0.0.0.0 www.example.com
+			`),
+			outputUnicode: true,
+		},
+		{
+			expect: heredoc.Doc(`
+				0.0.0.0 göpher.com www.example.com # xn--gpher-jua.com
+				0.0.0.0 bücher.de # xn--bcher-kva.de
+			`),
+			dedupe:        DedupeHost,
+			outputUnicode: true,
+			trimComment:   true,
+		},
+	} {
+		parser.Deduplicate = test.dedupe
+		parser.OutputUnicode = test.outputUnicode
+		parser.TrimComment = test.trimComment
+
+		var output strings.Builder
+
+		require.NoError(t, parser.ParseReader(strings.NewReader(input), &output))
+		assert.Equal(t, test.expect, output.String(), "the annotation should follow the host names left")
+	}
+
+	parser.IDNACompatible = false
+
+	line, ok := parser.ParseLine("0.0.0.0 göpher.com xn--zz.com")
+
+	require.True(t, ok)
+	assert.Equal(t, "0.0.0.0 göpher.com xn--zz.com # xn--gpher-jua.com", line,
+		"the host names failing to convert should not be annotated")
+}
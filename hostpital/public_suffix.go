@@ -0,0 +1,8 @@
+package hostpital
+
+// PublicSuffix returns the public suffix of the host name and the section of
+// the rule matched in the embedded Public Suffix List. Such as "co.uk" and
+// SuffixICANN for "www.example.co.uk". See PublicSuffixList.PublicSuffix.
+func PublicSuffix(hostName string) (string, SuffixSection) {
+	return defaultPublicSuffixes().PublicSuffix(hostName)
+}
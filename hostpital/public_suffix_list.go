@@ -0,0 +1,215 @@
+package hostpital
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// publicSuffixListData is the snapshot of the Public Suffix List embedded in
+// the binary.
+//
+// Ref: https://publicsuffix.org/list/public_suffix_list.dat
+//
+//go:embed public_suffix_list.dat
+var publicSuffixListData string
+
+// defaultPublicSuffixes returns the PublicSuffixList of the embedded snapshot.
+// It is parsed once on the first use.
+//
+//nolint:gochecknoglobals // read-only list parsed once
+var defaultPublicSuffixes = sync.OnceValue(func() *PublicSuffixList {
+	// The embedded snapshot never fails to read.
+	list, _ := ParsePublicSuffixList(strings.NewReader(publicSuffixListData))
+
+	return list
+})
+
+// ----------------------------------------------------------------------------
+//  Type: PublicSuffixList
+// ----------------------------------------------------------------------------
+
+// PublicSuffixList is the list of the public suffixes. Such as "com", "co.uk"
+// and "github.io" under which anyone can register a domain name. The package
+// functions such as PublicSuffix use the snapshot embedded in the binary. Use
+// LoadPublicSuffixList to use a newer copy.
+//
+// The rules are matched as of the algorithm of the list including the wildcard
+// rules such as "*.ck" and the exception rules such as "!www.ck". The rules in
+// Unicode are converted to punycode to match the host names in hosts files.
+//
+// Ref: https://publicsuffix.org/list/
+type PublicSuffixList struct {
+	rules     map[string]SuffixSection // Sections by rule in lowercase ASCII. Such as "*.ck" and "!www.ck".
+	ICANNOnly bool                     // If true, the rules of the private section are ignored. Such as "github.io" (default: false).
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// LoadPublicSuffixList reads the Public Suffix List from the file. Such as
+// "/usr/share/publicsuffix/public_suffix_list.dat" of the distributions.
+func LoadPublicSuffixList(pathFile string) (*PublicSuffixList, error) {
+	osFile, err := osOpen(filepath.Clean(pathFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the file")
+	}
+
+	defer func() {
+		_ = osFile.Close()
+	}()
+
+	list, err := ParsePublicSuffixList(osFile)
+
+	return list, errors.Wrapf(err, "failed to load the public suffix list %#v", pathFile)
+}
+
+// ParsePublicSuffixList reads the Public Suffix List from input. It errors if
+// no rules are found.
+func ParsePublicSuffixList(input io.Reader) (*PublicSuffixList, error) {
+	list := &PublicSuffixList{rules: map[string]SuffixSection{}}
+	section := SuffixICANN
+	scanner := bufio.NewScanner(input)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.Contains(line, "===BEGIN ICANN DOMAINS==="):
+			section = SuffixICANN
+		case strings.Contains(line, "===BEGIN PRIVATE DOMAINS==="):
+			section = SuffixPrivate
+		case line == "" || strings.HasPrefix(line, "//"):
+			continue
+		default:
+			list.rules[normalizeSuffixRule(strings.Fields(line)[0])] = section
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read from reader")
+	}
+
+	if len(list.rules) == 0 {
+		return nil, errors.New("no public suffix rules found")
+	}
+
+	return list, nil
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// IsPublicSuffix returns true if the host name is a public suffix listed. Such
+// as "co.uk". Unlike PublicSuffix, the implicit rule "*" is not counted, so the
+// single-label names such as "localhost" are not public suffixes.
+func (l *PublicSuffixList) IsPublicSuffix(hostName string) bool {
+	suffix, section := l.PublicSuffix(hostName)
+
+	return section != SuffixUnlisted && suffix == normalizeSuffixHost(hostName)
+}
+
+// PublicSuffix returns the public suffix of the host name and the section of
+// the rule matched. Such as "co.uk" and SuffixICANN for "www.example.co.uk".
+// The host name is case-insensitive and can have a trailing dot. The suffix is
+// in lowercase ASCII/punycode.
+//
+// If no rules match, the top level label and SuffixUnlisted are returned as of
+// the implicit rule "*".
+func (l *PublicSuffixList) PublicSuffix(hostName string) (string, SuffixSection) {
+	candidate := normalizeSuffixHost(hostName)
+
+	for {
+		_, parent, hasParent := strings.Cut(candidate, ".")
+
+		if section, ok := l.section("!" + candidate); ok {
+			return parent, section
+		}
+
+		if section, ok := l.section(candidate); ok {
+			return candidate, section
+		}
+
+		if !hasParent {
+			return candidate, SuffixUnlisted
+		}
+
+		if section, ok := l.section("*." + parent); ok {
+			return candidate, section
+		}
+
+		candidate = parent
+	}
+}
+
+// RegistrableDomain returns the registrable domain of the host name. Which is
+// the public suffix and one more label. Such as "example.co.uk" for
+// "a.b.example.co.uk". It errors if the host name is a public suffix itself.
+func (l *PublicSuffixList) RegistrableDomain(hostName string) (string, error) {
+	host := normalizeSuffixHost(hostName)
+	if host == "" {
+		return "", errors.New("the given host name is empty")
+	}
+
+	suffix, _ := l.PublicSuffix(host)
+	if suffix == host {
+		return "", errors.Errorf("%#v is a public suffix", hostName)
+	}
+
+	domain := strings.TrimSuffix(host, "."+suffix)
+	label := domain[strings.LastIndex(domain, ".")+1:]
+
+	if label == "" {
+		return "", errors.Errorf("%#v has an empty label", hostName)
+	}
+
+	return label + "." + suffix, nil
+}
+
+// section returns the section of the rule. It returns false if the rule is not
+// listed or ignored by ICANNOnly.
+func (l *PublicSuffixList) section(rule string) (SuffixSection, bool) {
+	section, ok := l.rules[rule]
+	if !ok || (l.ICANNOnly && section == SuffixPrivate) {
+		return SuffixUnlisted, false
+	}
+
+	return section, true
+}
+
+// ----------------------------------------------------------------------------
+//  Functions (Private)
+// ----------------------------------------------------------------------------
+
+// normalizeSuffixHost returns the host name in lowercase ASCII/punycode without
+// the trailing dot to match the rules.
+func normalizeSuffixHost(hostName string) string {
+	host := strings.ToLower(strings.TrimSuffix(hostName, "."))
+
+	if !isASCII(host) {
+		if hostASCII, err := TransformToASCII(host); err == nil {
+			return hostASCII
+		}
+	}
+
+	return host
+}
+
+// normalizeSuffixRule returns the rule in lowercase ASCII/punycode keeping the
+// leading "!" or "*." of the exception and the wildcard rules.
+func normalizeSuffixRule(rule string) string {
+	for _, prefix := range []string{"!", "*."} {
+		if name, ok := strings.CutPrefix(rule, prefix); ok {
+			return prefix + normalizeSuffixHost(name)
+		}
+	}
+
+	return normalizeSuffixHost(rule)
+}
@@ -0,0 +1,275 @@
+package hostpital
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPublicSuffixList is a small Public Suffix List for the tests.
+const testPublicSuffixList = `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+*.ck
+!www.ck
+公司.cn
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+*.compute.example.com extra fields are ignored
+// ===END PRIVATE DOMAINS===
+`
+
+// ----------------------------------------------------------------------------
+//  LoadPublicSuffixList() and ParsePublicSuffixList()
+// ----------------------------------------------------------------------------
+
+func TestLoadPublicSuffixList(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join(t.TempDir(), "public_suffix_list.dat")
+	require.NoError(t, os.WriteFile(pathFile, []byte(testPublicSuffixList), 0o600))
+
+	list, err := LoadPublicSuffixList(pathFile)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]SuffixSection{
+		"com":                   SuffixICANN,
+		"uk":                    SuffixICANN,
+		"co.uk":                 SuffixICANN,
+		"*.ck":                  SuffixICANN,
+		"!www.ck":               SuffixICANN,
+		"xn--55qx5d.cn":         SuffixICANN,
+		"*.compute.example.com": SuffixPrivate,
+	}, list.rules)
+}
+
+func TestLoadPublicSuffixList_failures(t *testing.T) {
+	t.Parallel()
+
+	list, err := LoadPublicSuffixList(filepath.Join(t.TempDir(), "missing"))
+
+	require.Error(t, err)
+	assert.Nil(t, list)
+	assert.Contains(t, err.Error(), "failed to open the file")
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte("// comment only\n\n"), 0o600))
+
+	list, err = LoadPublicSuffixList(pathFile)
+
+	require.Error(t, err)
+	assert.Nil(t, list)
+	assert.Contains(t, err.Error(), "failed to load the public suffix list")
+	assert.Contains(t, err.Error(), "no public suffix rules found")
+
+	list, err = ParsePublicSuffixList(&dummyReader{dummyFn: func(_ []byte) (int, error) {
+		return 0, errors.New("forced error")
+	}})
+
+	require.Error(t, err)
+	assert.Nil(t, list)
+	assert.Contains(t, err.Error(), "failed to read from reader: forced error")
+}
+
+// ----------------------------------------------------------------------------
+//  PublicSuffixList methods
+// ----------------------------------------------------------------------------
+
+func TestPublicSuffixList_PublicSuffix(t *testing.T) {
+	t.Parallel()
+
+	list, err := ParsePublicSuffixList(strings.NewReader(testPublicSuffixList))
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		input   string
+		suffix  string
+		domain  string
+		section SuffixSection
+	}{
+		{input: "www.example.co.uk", suffix: "co.uk", domain: "example.co.uk", section: SuffixICANN},
+		{input: "WWW.Example.UK.", suffix: "uk", domain: "example.uk", section: SuffixICANN},
+		{input: "a.b.ck", suffix: "b.ck", domain: "a.b.ck", section: SuffixICANN},
+		{input: "a.www.ck", suffix: "ck", domain: "www.ck", section: SuffixICANN},
+		{input: "www.例子.公司.cn", suffix: "xn--55qx5d.cn", domain: "xn--fsqu00a.xn--55qx5d.cn", section: SuffixICANN},
+		{input: "a.b.compute.example.com", suffix: "b.compute.example.com", domain: "a.b.compute.example.com", section: SuffixPrivate},
+		{input: "www.example.test", suffix: "test", domain: "example.test", section: SuffixUnlisted},
+		{input: "foo_ä.example.test", suffix: "test", domain: "example.test", section: SuffixUnlisted},
+	} {
+		suffix, section := list.PublicSuffix(test.input)
+
+		assert.Equal(t, test.suffix, suffix, "input: %q", test.input)
+		assert.Equal(t, test.section, section, "input: %q", test.input)
+
+		domain, err := list.RegistrableDomain(test.input)
+
+		require.NoError(t, err, "input: %q", test.input)
+		assert.Equal(t, test.domain, domain, "input: %q", test.input)
+		assert.False(t, list.IsPublicSuffix(test.input), "input: %q", test.input)
+	}
+
+	assert.True(t, list.IsPublicSuffix("b.ck"), "the wildcard rule should match")
+	assert.False(t, list.IsPublicSuffix("ck"), "the implicit rule should not count")
+
+	list.ICANNOnly = true
+
+	suffix, section := list.PublicSuffix("a.b.compute.example.com")
+
+	assert.Equal(t, "com", suffix, "the private section should be ignored")
+	assert.Equal(t, SuffixICANN, section)
+}
+
+func TestPublicSuffixList_RegistrableDomain_failures(t *testing.T) {
+	t.Parallel()
+
+	for input, expect := range map[string]string{
+		"":         "the given host name is empty",
+		"co.uk":    `"co.uk" is a public suffix`,
+		"b.ck":     `"b.ck" is a public suffix`,
+		"x..co.uk": `"x..co.uk" has an empty label`,
+	} {
+		domain, err := RegistrableDomain(input)
+
+		require.Error(t, err, "input: %q", input)
+		assert.Empty(t, domain)
+		assert.Equal(t, expect, err.Error(), "input: %q", input)
+	}
+}
+
+func TestSuffixSection_String_invalid(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "invalid", SuffixSection(-1).String())
+	assert.Equal(t, "invalid", SuffixSection(len(namesSuffixSection)).String())
+}
+
+// ----------------------------------------------------------------------------
+//  Validator (RulePublicSuffix)
+// ----------------------------------------------------------------------------
+
+func TestValidator_Validate_public_suffix(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.Mode = ModeHostsFile
+	input := heredoc.Doc(`
+		0.0.0.0 co.uk example.co.uk github.io
+		0.0.0.0 localhost b.compute.example.com
+		0.0.0.0 com # hostpital:ignore public-suffix
+	`)
+
+	assert.Equal(t, SeverityWarning, validator.Severity(RulePublicSuffix))
+	assert.Equal(t, []string{
+		`1:9: HP070 "co.uk" is a public suffix (ICANN)`,
+		`1:29: HP070 "github.io" is a public suffix (private)`,
+	}, summarizeIssues(validator.ValidateString(input).Issues))
+
+	list, err := ParsePublicSuffixList(strings.NewReader(testPublicSuffixList))
+	require.NoError(t, err)
+
+	validator.PublicSuffixes = list
+
+	assert.Equal(t, []string{
+		`1:9: HP070 "co.uk" is a public suffix (ICANN)`,
+		`2:19: HP070 "b.compute.example.com" is a public suffix (private)`,
+	}, summarizeIssues(validator.ValidateString(input).Issues), "the given list should be used")
+
+	var output strings.Builder
+
+	_, err = validator.Fix(strings.NewReader("0.0.0.0 co.uk example.co.uk\n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0 co.uk example.co.uk\n", output.String(), "Fix should keep the warnings")
+
+	validator.SetSeverity(RulePublicSuffix, SeverityError)
+	output.Reset()
+
+	_, err = validator.Fix(strings.NewReader("0.0.0.0 co.uk example.co.uk\n"), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0 example.co.uk\n", output.String(), "Fix should drop the public suffixes as errors")
+
+	err = validator.ValidateLine("0.0.0.0 co.uk")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to validate chunk/part of line",
+		"the error should be wrapped as the other rules of the host names")
+}
+
+func TestValidator_ValidateLine_public_suffix_is_warning(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	for _, line := range []string{"co.uk", "com", "github.io"} {
+		require.NoError(t, validator.ValidateLine(line), "line: %q", line)
+	}
+
+	validator.Mode = ModeHostsFile
+
+	require.NoError(t, validator.ValidateLine("0.0.0.0 co.uk"))
+	assert.True(t, validator.Validate(strings.NewReader("0.0.0.0 co.uk\n")).OK())
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.SortByDomain
+// ----------------------------------------------------------------------------
+
+func TestParser_SortByDomain(t *testing.T) {
+	t.Parallel()
+
+	input := heredoc.Doc(`
+		0.0.0.0 www.example.co.uk
+		# comment
+		0.0.0.0 WWW.Example.com ads.another.com
+		0.0.0.0 a.b.ck
+		0.0.0.0 example.co.uk
+	`)
+
+	parser := NewParser()
+	parser.TrimComment = false
+	parser.TrimIPAddress = false
+	parser.SortByDomain = true
+
+	var output strings.Builder
+
+	require.NoError(t, parser.ParseReader(strings.NewReader(input), &output))
+	assert.Equal(t, heredoc.Doc(`
+		# comment
+		0.0.0.0 a.b.ck
+		0.0.0.0 www.example.com ads.another.com
+		0.0.0.0 example.co.uk
+		0.0.0.0 www.example.co.uk
+	`), output.String(), "the lines should be grouped by the registrable domains")
+
+	list, err := ParsePublicSuffixList(strings.NewReader("uk\n"))
+	require.NoError(t, err)
+
+	parser.PublicSuffixes = list
+	parser.TrimComment = true
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte(input), 0o600))
+
+	entries, err := parser.ParseEntries(pathFile)
+	require.NoError(t, err)
+
+	lines := make([]string, len(entries))
+	for index, entry := range entries {
+		lines[index] = entry.String()
+	}
+
+	assert.Equal(t, []string{
+		"0.0.0.0 a.b.ck",
+		"0.0.0.0 example.co.uk",
+		"0.0.0.0 www.example.co.uk",
+		"0.0.0.0 www.example.com ads.another.com",
+	}, lines, "co.uk should be a registrable domain by the given list")
+}
@@ -0,0 +1,10 @@
+package hostpital
+
+// RegistrableDomain returns the registrable domain, also known as eTLD+1, of
+// the host name by the embedded Public Suffix List. Such as "example.co.uk" for
+// "a.b.example.co.uk" where TrimDNSByLevel(host, 1) returns "co.uk".
+//
+// It errors if the host name is a public suffix itself. Such as "co.uk".
+func RegistrableDomain(hostName string) (string, error) {
+	return defaultPublicSuffixes().RegistrableDomain(hostName)
+}
@@ -0,0 +1,53 @@
+package hostpital
+
+import "github.com/pkg/errors"
+
+// ----------------------------------------------------------------------------
+//  Type: Report
+// ----------------------------------------------------------------------------
+
+// Report is the result of the validation of a hosts file by the Validator. It
+// holds all the issues found, not only the first one.
+type Report struct {
+	Err       error   // Error occurred while reading the input. Nil if read till the end.
+	Source    string  // Path of the file validated. Empty if unknown.
+	Fixes     []Fix   // Fixes applied by Validator.Fix in the order of the lines. Nil if not fixed.
+	Issues    []Issue // Issues found in the order of the lines.
+	LinesRead int     // Number of lines read from the input.
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// OK returns true if the input was read till the end and no issue of
+// SeverityError was found.
+func (r Report) OK() bool {
+	if r.Err != nil {
+		return false
+	}
+
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// asError returns the error of the report. Such as the read error or the first
+// issue of SeverityError. Nil if OK.
+func (r Report) asError() error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return errors.Errorf("invalid line %d: %#v: %s", issue.Line, issue.Snippet, issue.Message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  Report.OK()
+// ----------------------------------------------------------------------------
+
+func TestReport_OK(t *testing.T) {
+	t.Parallel()
+
+	for index, test := range []struct {
+		report Report
+		expect bool
+	}{
+		{report: Report{}, expect: true},
+		{report: Report{Issues: []Issue{{Severity: SeverityWarning}, {Severity: SeverityInfo}}}, expect: true},
+		{report: Report{Issues: []Issue{{Severity: SeverityWarning}, {Severity: SeverityError}}}, expect: false},
+		{report: Report{Err: errors.New("forced error")}, expect: false},
+	} {
+		assert.Equal(t, test.expect, test.report.OK(), "test #%d", index+1)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  Report.asError()
+// ----------------------------------------------------------------------------
+
+func TestReport_asError(t *testing.T) {
+	t.Parallel()
+
+	// Only warnings
+	report := Report{Issues: []Issue{{Severity: SeverityWarning, Message: "warn"}}}
+
+	require.NoError(t, report.asError(), "warnings should not be an error")
+
+	// Error after warnings
+	report.Issues = append(report.Issues, Issue{
+		Line:     3,
+		Snippet:  " example.com",
+		Message:  "indent is not allowed",
+		Severity: SeverityError,
+	})
+
+	err := report.asError()
+
+	require.Error(t, err)
+	assert.Equal(t, `invalid line 3: " example.com": indent is not allowed`, err.Error())
+}
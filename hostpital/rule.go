@@ -0,0 +1,127 @@
+package hostpital
+
+// IDs of the built-in rules of the Validator with their names. The Allow*
+// fields, IDNACompatible and Mode of the Validator turn the rules off as noted
+// regardless of SetSeverity. AllowComment is not a rule but tells the rules
+// whether the line can have comments.
+const (
+	RuleTrailingSpace = "HP001" // trailing-space. Off if AllowTrailingSpace.
+	RuleIndent        = "HP002" // indent. Off if AllowIndent.
+	RuleUnderscore    = "HP003" // underscore. Off if AllowUnderscore, not IDNACompatible or not STD3Rules of IDNA.
+	RuleEmptyLine     = "HP004" // empty-line. Off if AllowEmptyLine.
+	RuleIPAddressOnly = "HP005" // ip-only. Off if AllowIPAddressOnly or in ModeHostsFile.
+	RuleLineBreak     = "HP006" // line-break
+	RuleRFC6125       = "HP009" // not-rfc6125. Off if IDNACompatible.
+	// RuleIDNA2008 checks the characters of the labels for IDNA2008. If IDNA
+	// of the Validator is set, the labels are checked by the profile and Fix
+	// converts the host names with it. Set the same profile to the Parser to
+	// agree on the host names. Off if not IDNACompatible.
+	RuleIDNA2008     = "HP010" // not-idna2008
+	RuleLabelTooLong = "HP011" // label-too-long
+	RuleNameTooLong  = "HP012" // name-too-long
+	RuleLabelHyphen  = "HP013" // label-hyphen. Off if AllowHyphen or not CheckHyphens of IDNA.
+	RuleReservedLDH  = "HP014" // reserved-ldh. Off if AllowHyphenDouble or not CheckHyphens of IDNA.
+	RuleNumericTLD   = "HP015" // numeric-tld
+	RuleEmptyLabel   = "HP016" // empty-label
+	// RuleUppercase and RuleTrailingDot report the host names rejected by
+	// IDNA2008 for registration but not by the other rules of the labels.
+	// Such as "EXAMPLE.COM" and "example.com.". Off if not IDNACompatible or
+	// IDNA of the Validator is set to a base other than IDNARegistration.
+	RuleUppercase   = "HP017" // uppercase
+	RuleTrailingDot = "HP018" // trailing-dot
+
+	// The rules of hosts(5) used in ModeHostsFile instead of RuleIPAddressOnly.
+	// The comments are always allowed in the mode.
+	RuleMissingAddress          = "HP020" // missing-address
+	RuleAddressWithoutHostname  = "HP021" // address-without-hostname
+	RuleHostnameInAddressColumn = "HP022" // hostname-in-address-column
+	RuleMalformedAddress        = "HP023" // malformed-address
+	RuleAddressInHostnameColumn = "HP024" // address-in-hostname-column
+
+	// RuleSpecialUse reports the special-use domain names. Such as "localhost"
+	// and "*.local". See Classify. Off by default.
+	RuleSpecialUse = "HP030" // special-use
+	// RuleSinkhole reports the lines pointing to the addresses not allowed by
+	// the Sinkhole policy of the Validator. Such as "203.0.113.7
+	// login.example.com" in a blocklist which hijacks the host name rather
+	// than blocks it. Off if Sinkhole is nil.
+	RuleSinkhole = "HP040" // non-sinkhole-address
+	// RuleConflict reports the host names mapped to an address other than the
+	// one recorded first to the Conflicts of the Validator, which wins. Share
+	// it between the validations to find the conflicts across the files. It is
+	// checked by Validate, Issues and the ValidateFile* methods only. Off if
+	// Conflicts is nil.
+	RuleConflict = "HP050" // conflicting-address
+
+	RuleConfusable  = "HP060" // confusable. See Confusable.
+	RuleMixedScript = "HP061" // mixed-script. See Confusable.
+
+	// RulePublicSuffix reports the host names which are public suffixes. Such
+	// as "co.uk" which blocks all the domains under it. Set PublicSuffixes of
+	// the Validator to use a newer copy of the Public Suffix List than the
+	// embedded snapshot.
+	RulePublicSuffix = "HP070" // public-suffix
+
+	RuleUnusedSuppression = "HP090" // unused-suppression. See Validator.ValidateFile.
+)
+
+// ----------------------------------------------------------------------------
+//  Type: Rule
+// ----------------------------------------------------------------------------
+
+// Rule is a check of the Validator. Implement it to plug in custom checks with
+// Validator.AddRule.
+type Rule interface {
+	// ID returns the unique ID of the rule. Such as "HP001".
+	ID() string
+	// Name returns the unique name of the rule. Such as "trailing-space".
+	Name() string
+	// Check returns the issues found in the line. Only Message and Column of
+	// the issues are needed. The rest is set by the Validator.
+	Check(line LineInfo) []Issue
+}
+
+// ----------------------------------------------------------------------------
+//  Type: builtinRule
+// ----------------------------------------------------------------------------
+
+// builtinRule is a rule of the Validator. The check function returns the issues
+// with the errors of the former versions for ValidateLine.
+type builtinRule struct {
+	check    func(v *Validator, line LineInfo) []lineIssue
+	id       string
+	name     string
+	severity Severity // Default severity of the rule.
+}
+
+// ----------------------------------------------------------------------------
+//  Type: boundRule
+// ----------------------------------------------------------------------------
+
+// boundRule is a builtinRule bound to a Validator to implement Rule.
+type boundRule struct {
+	validator *Validator
+	builtinRule
+}
+
+// Check implements Rule.
+func (r boundRule) Check(line LineInfo) []Issue {
+	found := r.check(r.validator, line)
+	issues := make([]Issue, len(found))
+
+	for index, issue := range found {
+		issues[index] = issue.Issue
+	}
+
+	return issues
+}
+
+// ID implements Rule.
+func (r boundRule) ID() string {
+	return r.id
+}
+
+// Name implements Rule.
+func (r boundRule) Name() string {
+	return r.name
+}
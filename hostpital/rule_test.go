@@ -0,0 +1,408 @@
+package hostpital
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dummyRule is a custom Rule that reports the lines equal to text.
+type dummyRule struct {
+	id   string
+	name string
+	text string
+}
+
+func (r dummyRule) ID() string   { return r.id }
+func (r dummyRule) Name() string { return r.name }
+
+func (r dummyRule) Check(line LineInfo) []Issue {
+	if line.Body != r.text {
+		return nil
+	}
+
+	return []Issue{{Message: "dummy issue", Column: line.Offset + 1}}
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.AddRule()
+// ----------------------------------------------------------------------------
+
+func TestValidator_AddRule(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	err := validator.AddRule(dummyRule{id: "X001", name: "dummy", text: "example.com"}, SeverityError)
+	require.NoError(t, err)
+
+	rules := validator.Rules()
+
+	require.Len(t, rules, len(builtinRules)+1)
+	assert.Equal(t, "X001", rules[len(rules)-1].ID(), "custom rules should come after the built-in rules")
+	assert.Equal(t, SeverityError, validator.Severity("dummy"))
+
+	report := validator.ValidateString("  example.com\n")
+
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, RuleIndent, report.Issues[0].RuleID)
+	assert.Equal(t, "X001", report.Issues[1].RuleID)
+	assert.Equal(t, 3, report.Issues[1].Column)
+	assert.Equal(t, "  example.com", report.Issues[1].Snippet)
+
+	require.NoError(t, validator.SetSeverity(RuleIndent, SeverityOff))
+
+	err = validator.ValidateLine("  example.com")
+
+	require.Error(t, err, "custom rules with SeverityError should fail ValidateLine")
+	assert.Equal(t, "dummy issue", err.Error())
+
+	require.NoError(t, validator.SetSeverity("X001", SeverityOff))
+	require.NoError(t, validator.ValidateLine("  example.com"), "disabled rules should not be checked")
+}
+
+func TestValidator_AddRule_duplicate(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	for _, rule := range []dummyRule{
+		{id: RuleTrailingSpace, name: "unique"},
+		{id: "X001", name: "trailing-space"},
+	} {
+		err := validator.AddRule(rule, SeverityError)
+
+		require.Error(t, err, "it should error if the ID or name is in use")
+		assert.Contains(t, err.Error(), "is already registered")
+	}
+
+	assert.Len(t, validator.Rules(), len(builtinRules), "it should not register the duplicate")
+}
+
+func TestValidator_AddRule_invalid_severity(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	err := validator.AddRule(dummyRule{id: "X001", name: "dummy"}, SeverityOff+1)
+
+	require.Error(t, err, "it should error on invalid severity")
+	assert.Contains(t, err.Error(), `invalid severity 4 for rule "X001"`)
+	assert.Len(t, validator.Rules(), len(builtinRules), "it should not register the rule")
+}
+
+// Run with -race to detect the data races.
+func TestValidator_AddRule_concurrent(t *testing.T) {
+	t.Parallel()
+
+	const numRules = 20
+
+	validator := NewValidator()
+	wgrp := new(sync.WaitGroup)
+
+	for index := range numRules {
+		rule := dummyRule{id: fmt.Sprintf("X%03d", index), name: fmt.Sprintf("dummy-%d", index), text: "example.com"}
+
+		wgrp.Go(func() {
+			assert.NoError(t, validator.AddRule(rule, SeverityWarning))
+		})
+		wgrp.Go(func() {
+			for issue := range validator.Issues(strings.NewReader("example.com\n")) {
+				assert.Equal(t, SeverityWarning, validator.Severity(issue.RuleID))
+			}
+		})
+		wgrp.Go(func() {
+			_ = validator.Rules()
+			_ = validator.SetSeverity(RuleIndent, SeverityWarning)
+		})
+	}
+
+	wgrp.Wait()
+
+	assert.Len(t, validator.Rules(), len(builtinRules)+numRules)
+	assert.Len(t, slices.Collect(validator.Issues(strings.NewReader("example.com\n"))), numRules)
+}
+
+func TestValidator_AddRule_nil(t *testing.T) {
+	t.Parallel()
+
+	err := NewValidator().AddRule(nil, SeverityError)
+
+	require.Error(t, err, "it should error on nil rule")
+	assert.Contains(t, err.Error(), "the given rule is nil")
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.Rules()
+// ----------------------------------------------------------------------------
+
+func TestValidator_Rules_copy(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.AddRule(dummyRule{id: "X001", name: "dummy"}, SeverityError))
+
+	rules := validator.Rules()
+	rules[len(rules)-1] = dummyRule{id: "X002", name: "replaced"}
+
+	assert.Equal(t, "X001", validator.Rules()[len(rules)-1].ID(), "it should return a copy")
+}
+
+func TestValidator_Rules_check(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	for _, rule := range validator.Rules() {
+		if rule.ID() != RuleTrailingSpace {
+			continue
+		}
+
+		issues := rule.Check(newLineInfo("example.com  ", false))
+
+		require.Len(t, issues, 1)
+		assert.Equal(t, 12, issues[0].Column)
+		assert.Equal(t, "trailing space is not allowed", issues[0].Message)
+
+		return
+	}
+
+	t.Fatal("trailing-space rule not found")
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.SetSeverity()
+// ----------------------------------------------------------------------------
+
+func TestValidator_SetSeverity(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.SetSeverity("indent", SeverityWarning))
+	assert.Equal(t, SeverityWarning, validator.Severity(RuleIndent))
+
+	report := validator.ValidateString("  example.com\n")
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, SeverityWarning, report.Issues[0].Severity)
+	assert.True(t, report.OK(), "warnings should not make the file invalid")
+	require.NoError(t, validator.ValidateLine("  example.com"), "warnings should not fail ValidateLine")
+
+	validator.AllowIndent = true
+
+	assert.Equal(t, SeverityOff, validator.Severity(RuleIndent), "the Allow* fields should take precedence")
+}
+
+func TestValidator_SetSeverity_invalid(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	err := validator.SetSeverity("unknown", SeverityError)
+
+	require.Error(t, err, "it should error on unknown rule")
+	assert.Contains(t, err.Error(), `unknown rule "unknown"`)
+
+	err = validator.SetSeverity(RuleIndent, Severity(99))
+
+	require.Error(t, err, "it should error on invalid severity")
+	assert.Contains(t, err.Error(), "invalid severity 99")
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.Severity()
+// ----------------------------------------------------------------------------
+
+func TestValidator_Severity_allow_fields(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	assert.Equal(t, SeverityOff, validator.Severity("unknown"), "unknown rules should be off")
+	assert.Equal(t, SeverityOff, validator.Severity(RuleEmptyLine), "empty lines are allowed by default")
+	assert.Equal(t, SeverityOff, validator.Severity(RuleRFC6125), "IDNA2008 is used by default")
+	assert.Equal(t, SeverityError, validator.Severity(RuleIDNA2008))
+
+	validator.IDNACompatible = false
+	validator.AllowEmptyLine = false
+	validator.AllowTrailingSpace = true
+	validator.AllowUnderscore = true
+	validator.AllowIPAddressOnly = true
+
+	assert.Equal(t, SeverityError, validator.Severity(RuleRFC6125))
+	assert.Equal(t, SeverityOff, validator.Severity(RuleIDNA2008))
+	assert.Equal(t, SeverityError, validator.Severity(RuleEmptyLine))
+	assert.Equal(t, SeverityOff, validator.Severity(RuleTrailingSpace))
+	assert.Equal(t, SeverityOff, validator.Severity(RuleUnderscore))
+	assert.Equal(t, SeverityOff, validator.Severity(RuleIPAddressOnly))
+	assert.Equal(t, SeverityError, validator.Severity(RuleLineBreak), "line-break has no Allow* field")
+}
+
+// ----------------------------------------------------------------------------
+//  Built-in rules
+// ----------------------------------------------------------------------------
+
+func TestCheckEmptyLine_not_empty(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowEmptyLine = false
+
+	assert.Empty(t, checkEmptyLine(validator, newLineInfo("example.com", false)))
+	assert.Len(t, checkEmptyLine(validator, newLineInfo(" ", false)), 1)
+}
+
+func TestValidator_Validate_labels(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	input := heredoc.Doc(`
+		0.0.0.0 _dmarc._domainkey.example.com example.com.
+		0.0.0.0 foo._bar.example.com
+		0.0.0.0 ` + strings.Repeat("a", 64) + `.example.com
+		0.0.0.0 ` + strings.Repeat("abcdefghi.", 26) + `com
+		0.0.0.0 -foo.example.com foo-.example.com
+		0.0.0.0 ab--cd.example.com xn--gpher-jua.com xn--zz-zzzz.com
+		0.0.0.0 example.123 bad..example.com
+		0.0.0.0 ex#mple.com göpher.example.com
+	`)
+
+	report := validator.ValidateString(input)
+
+	require.NoError(t, report.Err)
+	assert.Equal(t, []string{
+		`1:39: HP018 "example.com." ends with a dot`,
+		`2:13: HP003 label "_bar": underscore outside service label`,
+		`3:9: HP011 label "` + strings.Repeat("a", 64) + `": label is longer than 63 octets`,
+		"4:9: HP012 name is longer than 253 octets",
+		`5:9: HP013 label "-foo": label begins with hyphen`,
+		`5:26: HP013 label "foo-": label ends with hyphen`,
+		`6:9: HP014 label "ab--cd": label has "--" in the third and fourth positions but is not "xn--"`,
+		`6:46: HP014 label "xn--zz-zzzz": "xn--" label is not valid punycode`,
+		`7:17: HP015 label "123": all-numeric top-level label`,
+		"7:25: HP016 empty label",
+		`8:9: HP010 "ex#mple.com" is not IDNA2008 compatible: idna: disallowed rune U+0023`,
+	}, summarizeIssues(report.Issues))
+}
+
+func TestValidator_Validate_uppercase_and_trailing_dot(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	for line, expect := range map[string]string{
+		"0.0.0.0 EXAMPLE.COM":       `"EXAMPLE.COM" has uppercase letters`,
+		"0.0.0.0 www.Göpher.com":    `"www.Göpher.com" is not IDNA2008 compatible: idna: disallowed rune U+0047`,
+		"0.0.0.0 example.com.":      `"example.com." ends with a dot`,
+		"0.0.0.0 Example.Com.":      `"Example.Com." has uppercase letters`,
+		"0.0.0.0 XN--GPHER-JUA.COM": `"XN--GPHER-JUA.COM" has uppercase letters`,
+	} {
+		err := validator.ValidateLine(line)
+
+		require.Error(t, err, "line: %q", line)
+		assert.Contains(t, err.Error(), expect, "line: %q", line)
+	}
+
+	assert.Equal(t, []string{
+		`1:9: HP017 "Example.Com." has uppercase letters`,
+		`1:9: HP018 "Example.Com." ends with a dot`,
+	}, summarizeIssues(validator.ValidateString("0.0.0.0 Example.Com.\n").Issues))
+
+	// RFC 6125 2.2 accepts them
+	validator.IDNACompatible = false
+
+	require.NoError(t, validator.ValidateLine("0.0.0.0 EXAMPLE.COM example.com."))
+
+	// The lookup profile maps the case and accepts the trailing dot
+	profile := NewIDNAProfile(IDNALookup)
+	validator.IDNACompatible = true
+	validator.IDNA = &profile
+
+	require.NoError(t, validator.ValidateLine("0.0.0.0 EXAMPLE.COM example.com."))
+
+	var output strings.Builder
+
+	report, err := NewValidator().Fix(strings.NewReader("0.0.0.0 EXAMPLE.COM example.net.\n"), &output)
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, "0.0.0.0 example.com example.net\n", output.String())
+	assert.Equal(t, []string{RuleUppercase, RuleTrailingDot}, report.Fixes[0].RuleIDs)
+}
+
+func TestValidator_Validate_labels_allowed(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowHyphen = true
+	validator.AllowHyphenDouble = true
+	validator.AllowUnderscore = true
+
+	report := validator.ValidateString("0.0.0.0 -foo-.ab--cd.xn--zz-zzzz.foo_bar.example.com\n")
+
+	assert.Empty(t, summarizeIssues(report.Issues))
+
+	validator = NewValidator()
+	validator.IDNACompatible = false
+
+	report = validator.ValidateString("0.0.0.0 foo_bar.example.com göpher.example.com\n")
+
+	assert.Equal(t, []string{
+		`1:29: HP009 "göpher.example.com" is not RFC 6125 2.2 compatible`,
+	}, summarizeIssues(report.Issues), "RFC 6125 should be lenient to underscores")
+}
+
+func TestValidator_Validate_hosts_file(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.Mode = ModeHostsFile
+
+	input := heredoc.Doc(`
+		# comments are allowed in hosts(5)
+		127.0.0.1 localhost loopback # inline comment
+		::1 localhost6
+		fe80::1%lo0 localhost6
+		example.com
+		127.0.0.1
+		example.com 127.0.0.1
+		127.0.0.256 example.com
+		127.0.0.1 example.com 10.0.0.1
+		fe80::zz example.com
+		1e100.net
+	`)
+
+	report := validator.ValidateString(input)
+
+	require.NoError(t, report.Err)
+	assert.Equal(t, []string{
+		"5:1: HP020 missing address",
+		"6:1: HP021 address without hostname",
+		"7:1: HP022 hostname in address column",
+		`8:1: HP023 malformed address "127.0.0.256"`,
+		"9:23: HP024 address in hostname column",
+		`10:1: HP023 malformed address "fe80::zz"`,
+		"11:1: HP020 missing address",
+	}, summarizeIssues(report.Issues))
+}
+
+func TestValidator_Validate_domain_list(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	report := validator.ValidateString("example.com\nexample.com 127.0.0.1\n127.0.0.1\n")
+
+	assert.Equal(t, []string{
+		"3:1: HP005 IP address only line is not allowed",
+	}, summarizeIssues(report.Issues), "hosts(5) rules should be off in ModeDomainList")
+}
@@ -0,0 +1,52 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: Severity
+// ----------------------------------------------------------------------------
+
+// Severity is the level of an Issue found by the Validator.
+type Severity int
+
+const (
+	// SeverityError makes the file invalid.
+	SeverityError Severity = iota
+	// SeverityWarning is reported but does not make the file invalid.
+	SeverityWarning
+	// SeverityInfo is reported as a hint and does not make the file invalid.
+	SeverityInfo
+	// SeverityOff disables the rule.
+	SeverityOff
+)
+
+// namesSeverity is the list of the names of Severity in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesSeverity = []string{"error", "warning", "info", "off"}
+
+// ParseSeverity returns the Severity of the given name. Such as "error",
+// "warning", "info" and "off".
+func ParseSeverity(name string) (Severity, error) {
+	index := slices.Index(namesSeverity, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return SeverityError, errors.Errorf("unknown severity %#v. It must be one of: %s",
+			name, strings.Join(namesSeverity, ", "))
+	}
+
+	return Severity(index), nil
+}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(namesSeverity) {
+		return "invalid"
+	}
+
+	return namesSeverity[s]
+}
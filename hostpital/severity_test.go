@@ -0,0 +1,52 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  ParseSeverity()
+// ----------------------------------------------------------------------------
+
+func TestParseSeverity(t *testing.T) {
+	t.Parallel()
+
+	for name, expect := range map[string]Severity{
+		"error":     SeverityError,
+		" Warning ": SeverityWarning,
+		"INFO\n":    SeverityInfo,
+		"off":       SeverityOff,
+	} {
+		actual, err := ParseSeverity(name)
+
+		require.NoError(t, err, "name: %q", name)
+		assert.Equal(t, expect, actual, "name: %q", name)
+	}
+}
+
+func TestParseSeverity_unknown(t *testing.T) {
+	t.Parallel()
+
+	severity, err := ParseSeverity("fatal")
+
+	require.Error(t, err)
+	assert.Equal(t, SeverityError, severity, "it should return SeverityError on error")
+	assert.Contains(t, err.Error(), `unknown severity "fatal"`)
+	assert.Contains(t, err.Error(), "error, warning, info, off", "it should list the available severities")
+}
+
+// ----------------------------------------------------------------------------
+//  Severity.String()
+// ----------------------------------------------------------------------------
+
+func TestSeverity_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "invalid", Severity(-1).String())
+	assert.Equal(t, "off", SeverityOff.String())
+	assert.Equal(t, "invalid", Severity(4).String())
+}
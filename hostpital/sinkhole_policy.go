@@ -0,0 +1,127 @@
+package hostpital
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSinkholeAddresses are the addresses acceptable as sinkholes by
+// default. Such as "0.0.0.0 ads.example.com".
+//
+//nolint:gochecknoglobals // read-only table
+var DefaultSinkholeAddresses = []string{"0.0.0.0", "127.0.0.1", "::", "::1"}
+
+// ----------------------------------------------------------------------------
+//  Type: SinkholePolicy
+// ----------------------------------------------------------------------------
+
+// SinkholePolicy defines the addresses acceptable as sinkholes in blocklists.
+// The entries pointing to the other addresses, such as the routable ones in
+// "203.0.113.7 login.example.com", hijack the host names rather than block
+// them.
+type SinkholePolicy struct {
+	Allowed []netip.Prefix // Addresses and ranges acceptable as sinkholes (default: DefaultSinkholeAddresses).
+}
+
+// ----------------------------------------------------------------------------
+//  Type: SinkholeViolation
+// ----------------------------------------------------------------------------
+
+// SinkholeViolation is an entry pointing to an address not allowed by the
+// SinkholePolicy.
+type SinkholeViolation struct {
+	Source    string   // Path of the file the entry was read from. Empty if unknown.
+	Address   string   // The address the host names point to.
+	Hostnames []string // Host names of the entry.
+	Line      int      // Line number of the entry. Starts from 1. Zero if unknown.
+}
+
+// ----------------------------------------------------------------------------
+//  Constructor
+// ----------------------------------------------------------------------------
+
+// NewSinkholePolicy returns a new SinkholePolicy allowing the given addresses.
+// The addresses can be ranges in CIDR notation. Such as "127.0.0.0/8". If none
+// is given, DefaultSinkholeAddresses are allowed.
+func NewSinkholePolicy(addresses ...string) (*SinkholePolicy, error) {
+	if len(addresses) == 0 {
+		addresses = DefaultSinkholeAddresses
+	}
+
+	policy := &SinkholePolicy{Allowed: make([]netip.Prefix, 0, len(addresses))}
+
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+
+		if strings.Contains(address, "/") {
+			prefix, err := netip.ParsePrefix(address)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid sinkhole address %#v", address)
+			}
+
+			policy.Allowed = append(policy.Allowed, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid sinkhole address %#v", address)
+		}
+
+		addr = addr.Unmap()
+		policy.Allowed = append(policy.Allowed, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return policy, nil
+}
+
+// ----------------------------------------------------------------------------
+//  Methods
+// ----------------------------------------------------------------------------
+
+// Allows returns true if the address is acceptable as a sinkhole. It returns
+// false if the address is not an IP address.
+func (p *SinkholePolicy) Allows(address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+
+	return p.allowsAddr(addr)
+}
+
+// Check returns the violation of the line if the host names in the line point
+// to an address not allowed. The lines without host names, such as comments
+// and IP address only lines, are not violations.
+func (p *SinkholePolicy) Check(line string) (SinkholeViolation, bool) {
+	body, _, _ := strings.Cut(line, string(DelimComnt))
+	fields := strings.Fields(body)
+
+	if len(fields) < 2 {
+		return SinkholeViolation{}, false
+	}
+
+	addr, err := netip.ParseAddr(fields[0])
+	if err != nil || p.allowsAddr(addr) {
+		return SinkholeViolation{}, false
+	}
+
+	return SinkholeViolation{Address: fields[0], Hostnames: fields[1:]}, true
+}
+
+// allowsAddr is the implementation of Allows for the parsed address. The zone
+// of the address is ignored. Such as "eth0" of "fe80::1%eth0".
+func (p *SinkholePolicy) allowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+
+	for _, prefix := range p.Allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
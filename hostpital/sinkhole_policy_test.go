@@ -0,0 +1,229 @@
+package hostpital
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ----------------------------------------------------------------------------
+//  NewSinkholePolicy()
+// ----------------------------------------------------------------------------
+
+func TestNewSinkholePolicy_default(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	for address, expect := range map[string]bool{
+		"0.0.0.0":         true,
+		"127.0.0.1":       true,
+		"::":              true,
+		"::1":             true,
+		"0:0:0:0:0:0:0:1": true,
+		"::ffff:0.0.0.0":  true,
+		"127.0.0.2":       false,
+		"203.0.113.7":     false,
+		"2001:db8::1":     false,
+		"fe80::1%eth0":    false,
+		"example.com":     false,
+		"":                false,
+	} {
+		assert.Equal(t, expect, policy.Allows(address), "address: %q", address)
+	}
+}
+
+func TestNewSinkholePolicy_custom(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy(" 127.0.0.0/8 ", "192.0.2.1", "fe80::/10")
+	require.NoError(t, err)
+
+	assert.True(t, policy.Allows("127.0.0.2"), "ranges should be allowed")
+	assert.True(t, policy.Allows("192.0.2.1"))
+	assert.True(t, policy.Allows("fe80::1%eth0"), "zones should be ignored")
+	assert.False(t, policy.Allows("0.0.0.0"), "defaults should not be allowed if addresses are given")
+	assert.False(t, policy.Allows("192.0.2.2"))
+}
+
+func TestNewSinkholePolicy_invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, address := range []string{"localhost", "127.0.0.0/33", "256.0.0.1"} {
+		policy, err := NewSinkholePolicy(address)
+
+		require.Error(t, err, "address: %q", address)
+		assert.Nil(t, policy)
+		assert.Contains(t, err.Error(), "invalid sinkhole address", "address: %q", address)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//  SinkholePolicy.Check()
+// ----------------------------------------------------------------------------
+
+func TestSinkholePolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	violation, isHijack := policy.Check("  203.0.113.7 login.example.com www.example.com # comment")
+
+	require.True(t, isHijack)
+	assert.Equal(t, SinkholeViolation{
+		Address:   "203.0.113.7",
+		Hostnames: []string{"login.example.com", "www.example.com"},
+	}, violation)
+
+	for _, line := range []string{
+		"0.0.0.0 ads.example.com",
+		"203.0.113.7",
+		"# 203.0.113.7 login.example.com",
+		"login.example.com",
+		"",
+	} {
+		_, isHijack := policy.Check(line)
+
+		assert.False(t, isHijack, "line: %q", line)
+	}
+}
+
+func TestSinkholePolicy_Check_zoned_address(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	violation, isHijack := policy.Check("fe80::1%eth0 router.example.com")
+
+	require.True(t, isHijack, "the zoned address should be checked as an address")
+	assert.Equal(t, SinkholeViolation{
+		Address:   "fe80::1%eth0",
+		Hostnames: []string{"router.example.com"},
+	}, violation)
+
+	_, isHijack = policy.Check("::1%lo localhost")
+
+	assert.False(t, isHijack, "the zone should be ignored as Allows does")
+	assert.True(t, policy.Allows("::1%lo"))
+
+	policy, err = NewSinkholePolicy("fe80::/10")
+	require.NoError(t, err)
+
+	_, isHijack = policy.Check("fe80::1%eth0 router.example.com")
+
+	assert.False(t, isHijack)
+	assert.True(t, policy.Allows("fe80::1%eth0"))
+}
+
+// ----------------------------------------------------------------------------
+//  Parser.Sinkhole
+// ----------------------------------------------------------------------------
+
+func TestParser_Sinkhole(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	parser := NewParser()
+	parser.Sinkhole = policy
+
+	input := heredoc.Doc(`
+		0.0.0.0 ads.example.com
+		203.0.113.7 login.example.com # hijack
+		::1 tracker.example.com
+		2001:db8::1 www.example.com
+	`)
+
+	expectHijacks := []SinkholeViolation{
+		{Address: "203.0.113.7", Hostnames: []string{"login.example.com"}, Line: 2},
+		{Address: "2001:db8::1", Hostnames: []string{"www.example.com"}, Line: 4},
+	}
+
+	var output strings.Builder
+
+	require.NoError(t, parser.ParseReader(strings.NewReader(input), &output))
+	assert.Equal(t, "ads.example.com\ntracker.example.com\n", output.String())
+	assert.Equal(t, expectHijacks, parser.Stats().Hijacks)
+
+	parser.ParseString(input)
+	assert.Equal(t, expectHijacks, parser.Stats().Hijacks, "ParseString should report the violations too")
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte(input), 0o600))
+
+	for name, parse := range map[string]func() error{
+		"ParseFile": func() error {
+			_, err := parser.ParseFile(pathFile)
+
+			return err
+		},
+		"ParseEntries": func() error {
+			entries, err := parser.ParseEntries(pathFile)
+
+			assert.Len(t, entries, 2)
+
+			return err
+		},
+	} {
+		require.NoError(t, parse(), name)
+
+		hijacks := parser.Stats().Hijacks
+
+		require.Len(t, hijacks, 2, name)
+		assert.Equal(t, pathFile, hijacks[0].Source, "%s should set the source", name)
+		assert.Equal(t, pathFile, hijacks[1].Source, "%s should set the source", name)
+	}
+}
+
+func TestParser_Sinkhole_nil(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	output := parser.ParseString("203.0.113.7 login.example.com\n")
+
+	assert.Equal(t, "login.example.com\n", output, "entries should pass through without a policy")
+	assert.Nil(t, parser.Stats().Hijacks)
+}
+
+// ----------------------------------------------------------------------------
+//  Validator.Sinkhole
+// ----------------------------------------------------------------------------
+
+func TestValidator_Validate_sinkhole(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := "0.0.0.0 ads.example.com\n  203.0.113.7 login.example.com\n"
+
+	assert.Equal(t, SeverityOff, validator.Severity(RuleSinkhole), "it should be off without a policy")
+	assert.Equal(t, []string{"2:1: HP002 indent is not allowed"}, summarizeIssues(validator.ValidateString(input).Issues))
+
+	policy, err := NewSinkholePolicy()
+	require.NoError(t, err)
+
+	validator.Sinkhole = policy
+
+	assert.Equal(t, []string{
+		"2:1: HP002 indent is not allowed",
+		`2:3: HP040 "203.0.113.7" is not an allowed sinkhole address`,
+	}, summarizeIssues(validator.ValidateString(input).Issues))
+
+	var output strings.Builder
+
+	report, err := validator.Fix(strings.NewReader(input), &output)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0 ads.example.com\n", output.String(), "the hijacking lines should be removed")
+	require.Len(t, report.Fixes, 1)
+	assert.True(t, report.Fixes[0].Removed)
+	assert.Equal(t, []string{RuleIndent, RuleSinkhole}, report.Fixes[0].RuleIDs)
+}
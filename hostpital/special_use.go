@@ -0,0 +1,127 @@
+package hostpital
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: SpecialUse
+// ----------------------------------------------------------------------------
+
+// SpecialUse is the category of special-use and reserved domain names. Such as
+// the names in the IANA Special-Use Domain Names registry.
+//
+// Ref: https://www.iana.org/assignments/special-use-domain-names/
+type SpecialUse int
+
+const (
+	// SpecialUseNone is not a special-use domain name.
+	SpecialUseNone SpecialUse = iota
+	// SpecialUseLocalhost is "localhost", its subdomains and the conventional
+	// "localhost.localdomain". RFC 6761 6.3.
+	SpecialUseLocalhost
+	// SpecialUseLocal is ".local" for multicast DNS. RFC 6762.
+	SpecialUseLocal
+	// SpecialUseTest is ".test" for testing. RFC 6761 6.2.
+	SpecialUseTest
+	// SpecialUseInvalid is ".invalid" which never resolves. RFC 6761 6.4.
+	SpecialUseInvalid
+	// SpecialUseExample is ".example", "example.com", "example.net" and
+	// "example.org" for documentation. RFC 6761 6.5.
+	SpecialUseExample
+	// SpecialUseOnion is ".onion" for Tor hidden services. RFC 7686.
+	SpecialUseOnion
+	// SpecialUseAlt is ".alt" for non-DNS resolution. RFC 9476.
+	SpecialUseAlt
+	// SpecialUseHomeArpa is "home.arpa" for home networks. RFC 8375.
+	SpecialUseHomeArpa
+	// SpecialUseReverseDNS is "in-addr.arpa" and "ip6.arpa" for reverse DNS.
+	// RFC 6761 6.1 and RFC 6303.
+	SpecialUseReverseDNS
+	// SpecialUseArpa is the other special-use names under ".arpa". Such as
+	// "ipv4only.arpa", "resolver.arpa" and "service.arpa".
+	SpecialUseArpa
+)
+
+// namesSpecialUse is the list of the names of SpecialUse in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesSpecialUse = []string{
+	"none", "localhost", "local", "test", "invalid-tld", "example", "onion", "alt",
+	"home-arpa", "reverse-dns", "arpa",
+}
+
+// specialUseDomains is the table of the special-use domain names and their
+// categories. The subdomains are of the same category.
+//
+//nolint:gochecknoglobals // read-only table
+var specialUseDomains = map[string]SpecialUse{
+	"localhost":             SpecialUseLocalhost,
+	"localhost.localdomain": SpecialUseLocalhost,
+	"local":                 SpecialUseLocal,
+	"test":                  SpecialUseTest,
+	"invalid":               SpecialUseInvalid,
+	"example":               SpecialUseExample,
+	"example.com":           SpecialUseExample,
+	"example.net":           SpecialUseExample,
+	"example.org":           SpecialUseExample,
+	"onion":                 SpecialUseOnion,
+	"alt":                   SpecialUseAlt,
+	"home.arpa":             SpecialUseHomeArpa,
+	"in-addr.arpa":          SpecialUseReverseDNS,
+	"ip6.arpa":              SpecialUseReverseDNS,
+	"6tisch.arpa":           SpecialUseArpa,
+	"eap-noob.arpa":         SpecialUseArpa,
+	"ipv4only.arpa":         SpecialUseArpa,
+	"resolver.arpa":         SpecialUseArpa,
+	"service.arpa":          SpecialUseArpa,
+}
+
+// Classify returns the special-use category of the host name. It returns
+// SpecialUseNone if the host name is not a special-use domain name or one of
+// its subdomains. Such as "printer.local" returns SpecialUseLocal.
+//
+// The host name is case-insensitive and can have a trailing dot.
+func Classify(hostName string) SpecialUse {
+	name := strings.ToLower(strings.TrimSuffix(hostName, "."))
+
+	for name != "" {
+		if category, ok := specialUseDomains[name]; ok {
+			return category
+		}
+
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+
+		name = parent
+	}
+
+	return SpecialUseNone
+}
+
+// ParseSpecialUse returns the SpecialUse of the given name. Such as "none",
+// "localhost", "local", "test", "invalid-tld", "example", "onion", "alt",
+// "home-arpa", "reverse-dns" and "arpa".
+func ParseSpecialUse(name string) (SpecialUse, error) {
+	index := slices.Index(namesSpecialUse, strings.ToLower(strings.TrimSpace(name)))
+	if index < 0 {
+		return SpecialUseNone, errors.Errorf("unknown special-use category %#v. It must be one of: %s",
+			name, strings.Join(namesSpecialUse, ", "))
+	}
+
+	return SpecialUse(index), nil
+}
+
+// String returns the name of the category.
+func (s SpecialUse) String() string {
+	if s < 0 || int(s) >= len(namesSpecialUse) {
+		return "invalid"
+	}
+
+	return namesSpecialUse[s]
+}
@@ -0,0 +1,114 @@
+package hostpital
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	for hostName, expect := range map[string]SpecialUse{
+		"":                      SpecialUseNone,
+		"com":                   SpecialUseNone,
+		"example.co.jp":         SpecialUseNone,
+		"mylocal":               SpecialUseNone,
+		"localhost":             SpecialUseLocalhost,
+		"LocalHost.":            SpecialUseLocalhost,
+		"foo.localhost":         SpecialUseLocalhost,
+		"localhost.localdomain": SpecialUseLocalhost,
+		"printer.local":         SpecialUseLocal,
+		"foo.test":              SpecialUseTest,
+		"foo.invalid":           SpecialUseInvalid,
+		"www.example.com":       SpecialUseExample,
+		"example":               SpecialUseExample,
+		"duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion": SpecialUseOnion,
+		"foo.alt":                     SpecialUseAlt,
+		"router.home.arpa":            SpecialUseHomeArpa,
+		"1.0.0.127.in-addr.arpa":      SpecialUseReverseDNS,
+		"b.a.9.8.ip6.arpa":            SpecialUseReverseDNS,
+		"ipv4only.arpa":               SpecialUseArpa,
+		"arpa":                        SpecialUseNone,
+		"localdomain":                 SpecialUseNone,
+		"foo.localhost.localdomain.x": SpecialUseNone,
+	} {
+		assert.Equal(t, expect, Classify(hostName), "host name: %q", hostName)
+	}
+}
+
+func TestParseSpecialUse(t *testing.T) {
+	t.Parallel()
+
+	for index, name := range namesSpecialUse {
+		actual, err := ParseSpecialUse(" " + name + "\n")
+
+		require.NoError(t, err, "name: %q", name)
+		assert.Equal(t, SpecialUse(index), actual, "name: %q", name)
+		assert.Equal(t, name, actual.String())
+	}
+}
+
+func TestParseSpecialUse_unknown(t *testing.T) {
+	t.Parallel()
+
+	category, err := ParseSpecialUse("unknown")
+
+	require.Error(t, err)
+	assert.Equal(t, SpecialUseNone, category, "it should return SpecialUseNone on error")
+	assert.Contains(t, err.Error(), `unknown special-use category "unknown"`)
+}
+
+func TestSpecialUse_String_invalid(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "invalid", SpecialUse(-1).String())
+	assert.Equal(t, "invalid", SpecialUse(len(namesSpecialUse)).String())
+}
+
+func TestValidator_Validate_special_use(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	assert.Equal(t, SeverityOff, validator.Severity(RuleSpecialUse), "it should be off by default")
+	assert.Empty(t, validator.ValidateString("0.0.0.0 printer.local\n").Issues)
+
+	require.NoError(t, validator.SetSeverity("special-use", SeverityWarning))
+
+	report := validator.ValidateString("0.0.0.0 printer.local example.co.jp localhost.localdomain\n")
+
+	assert.Equal(t, []string{
+		`1:9: HP030 "printer.local" is a special-use domain name (local)`,
+		`1:37: HP030 "localhost.localdomain" is a special-use domain name (localhost)`,
+	}, summarizeIssues(report.Issues))
+}
+
+func TestParser_ParseLine_drop_special_use(t *testing.T) {
+	t.Parallel()
+
+	parser := NewParser()
+	parser.TrimIPAddress = false
+
+	{
+		actual, ok := parser.ParseLine("0.0.0.0 printer.local example.co.jp")
+
+		require.True(t, ok)
+		assert.Equal(t, "0.0.0.0 printer.local example.co.jp", actual, "it should keep them by default")
+	}
+
+	parser.DropSpecialUse = true
+
+	{
+		actual, ok := parser.ParseLine("0.0.0.0 printer.local example.co.jp")
+
+		require.True(t, ok)
+		assert.Equal(t, "0.0.0.0  example.co.jp", actual, "dropped names should leave their spaces")
+	}
+	{
+		_, ok := parser.ParseLine("127.0.0.1 localhost localhost.localdomain")
+
+		assert.False(t, ok, "lines with only special-use names should be dropped")
+	}
+}
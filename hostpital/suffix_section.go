@@ -0,0 +1,35 @@
+package hostpital
+
+// ----------------------------------------------------------------------------
+//  Type: SuffixSection
+// ----------------------------------------------------------------------------
+
+// SuffixSection is the section of the Public Suffix List the rule of a public
+// suffix belongs to.
+type SuffixSection int
+
+const (
+	// SuffixUnlisted is of the implicit rule "*" of the list. Which is the top
+	// level label of the host names matching no rules. Such as "localhost".
+	SuffixUnlisted SuffixSection = iota
+	// SuffixICANN is of the ICANN section. Such as "com" and "co.uk" which are
+	// delegated by the registries.
+	SuffixICANN
+	// SuffixPrivate is of the private section. Such as "github.io" which are
+	// submitted by the owners of the domains to host the users.
+	SuffixPrivate
+)
+
+// namesSuffixSection is the list of the names of SuffixSection in order.
+//
+//nolint:gochecknoglobals // read-only table
+var namesSuffixSection = []string{"unlisted", "ICANN", "private"}
+
+// String returns the name of the section.
+func (s SuffixSection) String() string {
+	if s < 0 || int(s) >= len(namesSuffixSection) {
+		return "invalid"
+	}
+
+	return namesSuffixSection[s]
+}
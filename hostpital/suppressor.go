@@ -0,0 +1,356 @@
+package hostpital
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Prefix and kinds of the suppression directives in comments. Such as:
+//
+//	# hostpital:ignore-next-line HP003
+//	0.0.0.0 foo_bar.example.com
+//	0.0.0.0 foo_bar.example.net # hostpital:ignore underscore
+//	# hostpital:disable HP003 HP005
+//	0.0.0.0 foo_bar.example.org
+//	# hostpital:enable
+const (
+	directivePrefix         = "hostpital:"
+	directiveDisable        = "disable"
+	directiveEnable         = "enable"
+	directiveIgnore         = "ignore"
+	directiveIgnoreNextLine = "ignore-next-line"
+)
+
+// ----------------------------------------------------------------------------
+//  Type: directive
+// ----------------------------------------------------------------------------
+
+// directive is a suppression directive found in a comment.
+type directive struct {
+	used    map[string]bool // Rule IDs that suppressed an issue. "" if all.
+	kind    string
+	snippet string
+	ruleIDs []string // Rule IDs to suppress. Empty if all.
+	column  int
+	line    int
+	all     bool
+}
+
+// issue returns a RuleUnusedSuppression issue of the directive with the message.
+func (d *directive) issue(msg string) lineIssue {
+	issue := newLineIssue(d.column, msg, nil)
+	issue.Line = d.line
+	issue.Snippet = d.snippet
+
+	return issue
+}
+
+// suppress returns true if the directive suppresses the rule. It marks the rule
+// as used.
+func (d *directive) suppress(ruleID string) bool {
+	switch {
+	case d.all:
+		d.used[""] = true
+	case slices.Contains(d.ruleIDs, ruleID):
+		d.used[ruleID] = true
+	default:
+		return false
+	}
+
+	return true
+}
+
+// unused returns the issues of the rules that the directive did not suppress.
+func (d *directive) unused() []lineIssue {
+	if d.all {
+		if d.used[""] {
+			return nil
+		}
+
+		return []lineIssue{d.issue(errors.Errorf("unused suppression %#v", d.kind).Error())}
+	}
+
+	issues := []lineIssue{}
+
+	for _, ruleID := range d.ruleIDs {
+		if !d.used[ruleID] {
+			issues = append(issues, d.issue(errors.Errorf("unused suppression %#v of %s", d.kind, ruleID).Error()))
+		}
+	}
+
+	return issues
+}
+
+// ----------------------------------------------------------------------------
+//  Type: suppressor
+// ----------------------------------------------------------------------------
+
+// suppressor applies the suppression directives to the issues of the lines.
+// It holds the state between the lines, so use one per input.
+type suppressor struct {
+	validator *Validator
+	conflicts *ConflictAnalyzer // Analyzer to record the entries to. Nil to skip RuleConflict.
+	nextLine  *directive        // Pending "ignore-next-line" directive.
+	source    string            // Source of the lines for the conflicts.
+	blocks    []*directive      // Active "disable" directives.
+}
+
+// newSuppressor returns a new suppressor for the validator.
+func newSuppressor(validator *Validator) *suppressor {
+	return &suppressor{validator: validator}
+}
+
+// finish returns the issues of the directives left unused at the end of input.
+func (s *suppressor) finish() []lineIssue {
+	issues := []lineIssue{}
+
+	if s.nextLine != nil {
+		issues = append(issues, s.nextLine.unused()...)
+		s.nextLine = nil
+	}
+
+	for _, block := range s.blocks {
+		issues = append(issues, block.unused()...)
+	}
+
+	s.blocks = nil
+
+	return s.report(issues)
+}
+
+// lineIssues returns the issues of the line numbered number that are not
+// suppressed, followed by the issues of the directives found unused.
+func (s *suppressor) lineIssues(number int, line string) []lineIssue {
+	found, body, unknown := s.parseDirective(number, line)
+	unused := unknown
+	issues := []lineIssue{}
+
+	if found != nil && body == "" {
+		unused = append(unused, s.apply(found)...)
+	} else {
+		for _, issue := range append(s.validator.lineIssues(body), s.conflictIssues(number, body)...) {
+			issue.Line = number
+			issue.Snippet = line
+
+			if !s.suppressed(found, issue.RuleID) {
+				issues = append(issues, issue)
+			}
+		}
+
+		if found != nil {
+			unused = append(unused, found.unused()...)
+		}
+
+		if s.nextLine != nil {
+			unused = append(unused, s.nextLine.unused()...)
+			s.nextLine = nil
+		}
+	}
+
+	return append(issues, s.report(unused)...)
+}
+
+// apply applies the directive of a directive only line and returns the issues
+// of the directives found unused.
+func (s *suppressor) apply(found *directive) []lineIssue {
+	unused := []lineIssue{}
+
+	if s.nextLine != nil {
+		unused = append(unused, s.nextLine.unused()...)
+		s.nextLine = nil
+	}
+
+	switch found.kind {
+	case directiveIgnoreNextLine:
+		s.nextLine = found
+	case directiveDisable:
+		s.blocks = append(s.blocks, found)
+	case directiveEnable:
+		unused = append(unused, s.enable(found)...)
+	default:
+		// "ignore" without anything to ignore
+		unused = append(unused, found.unused()...)
+	}
+
+	return unused
+}
+
+// conflictIssues records the entries of the line to the conflicts and returns
+// the RuleConflict issues of the entries mapped to an address other than the
+// winner's. The line number and the snippet are not set.
+func (s *suppressor) conflictIssues(number int, body string) []lineIssue {
+	severity := s.validator.severityByID(RuleConflict)
+	if s.conflicts == nil || severity == SeverityOff {
+		return nil
+	}
+
+	issues := []lineIssue{}
+
+	for _, conflict := range s.conflicts.AddLine(s.source, number, body) {
+		winner, entry := conflict.Winner(), conflict.Entries[1]
+		issue := newLineIssue(entry.Column, fmt.Sprintf("%#v points to %#v but %#v at %s wins",
+			entry.Hostname, entry.Address, winner.Address, winner), nil)
+		issue.RuleID = RuleConflict
+		issue.Severity = severity
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// enable ends the "disable" blocks of the rules in the directive. It ends all
+// the blocks if the directive has no rules.
+func (s *suppressor) enable(found *directive) []lineIssue {
+	unused := []lineIssue{}
+
+	if found.all {
+		if len(s.blocks) == 0 {
+			unused = append(unused, found.issue("no disabled rules to enable"))
+		}
+
+		for _, block := range s.blocks {
+			unused = append(unused, block.unused()...)
+		}
+
+		s.blocks = nil
+
+		return unused
+	}
+
+	for _, ruleID := range found.ruleIDs {
+		enabled := false
+
+		for _, block := range s.blocks {
+			index := slices.Index(block.ruleIDs, ruleID)
+			if index < 0 {
+				continue
+			}
+
+			if !block.used[ruleID] {
+				unused = append(unused, block.issue(errors.Errorf("unused suppression %#v of %s", block.kind, ruleID).Error()))
+			}
+
+			block.ruleIDs = slices.Delete(block.ruleIDs, index, index+1)
+			enabled = true
+		}
+
+		if !enabled {
+			unused = append(unused, found.issue(errors.Errorf("%s is not disabled to enable", ruleID).Error()))
+		}
+	}
+
+	s.blocks = slices.DeleteFunc(s.blocks, func(block *directive) bool {
+		return !block.all && len(block.ruleIDs) == 0
+	})
+
+	return unused
+}
+
+// parseDirective returns the directive in the comment of the line and the line
+// without the directive. The issues are of the unknown rules and directives.
+//
+// It returns nil and the line as is if the line has no directive.
+func (s *suppressor) parseDirective(number int, line string) (*directive, string, []lineIssue) {
+	index := strings.IndexRune(line, DelimComnt)
+	if index < 0 {
+		return nil, line, nil
+	}
+
+	comment := strings.TrimSpace(line[index+1:])
+	if !strings.HasPrefix(comment, directivePrefix) {
+		return nil, line, nil
+	}
+
+	fields := strings.FieldsFunc(strings.TrimPrefix(comment, directivePrefix), func(r rune) bool {
+		return r == ',' || strings.ContainsRune(Cutset, r)
+	})
+
+	found := &directive{
+		used:    map[string]bool{},
+		snippet: line,
+		column:  index + 1,
+		line:    number,
+	}
+
+	body := strings.TrimRight(line[:index], Cutset)
+	unknown := []lineIssue{}
+
+	if len(fields) > 0 {
+		found.kind = fields[0]
+
+		for _, rule := range fields[1:] {
+			ruleID, ok := s.validator.ruleID(rule)
+			if !ok {
+				unknown = append(unknown, found.issue(errors.Errorf("unknown rule %#v in suppression", rule).Error()))
+
+				continue
+			}
+
+			found.ruleIDs = append(found.ruleIDs, ruleID)
+		}
+	}
+
+	found.all = len(fields) < 2
+
+	switch found.kind {
+	case directiveIgnore:
+	case directiveDisable, directiveEnable, directiveIgnoreNextLine:
+		if body != "" {
+			// Only "ignore" can follow the entry. Leave the line to the rules.
+			unknown = append(unknown, found.issue(errors.Errorf("%#v must be on its own line", found.kind).Error()))
+
+			return nil, body, unknown
+		}
+	default:
+		unknown = append(unknown, found.issue(errors.Errorf("unknown suppression directive %#v", found.kind).Error()))
+
+		return nil, body, unknown
+	}
+
+	if len(unknown) > 0 && len(found.ruleIDs) == 0 {
+		// All the rules were unknown. Do not suppress everything by mistake.
+		return nil, body, unknown
+	}
+
+	return found, body, unknown
+}
+
+// report returns the issues with RuleUnusedSuppression and its severity. It
+// returns none if the rule is off.
+func (s *suppressor) report(issues []lineIssue) []lineIssue {
+	severity := s.validator.severityByID(RuleUnusedSuppression)
+	if severity == SeverityOff {
+		return nil
+	}
+
+	for index := range issues {
+		issues[index].RuleID = RuleUnusedSuppression
+		issues[index].Severity = severity
+	}
+
+	return issues
+}
+
+// suppressed returns true if the issue of the rule is suppressed by the
+// directive of the line, the pending "ignore-next-line" directive or the
+// "disable" blocks.
+func (s *suppressor) suppressed(found *directive, ruleID string) bool {
+	if found != nil && found.kind == directiveIgnore && found.suppress(ruleID) {
+		return true
+	}
+
+	if s.nextLine != nil && s.nextLine.suppress(ruleID) {
+		return true
+	}
+
+	for _, block := range s.blocks {
+		if block.suppress(ruleID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,210 @@
+package hostpital
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summarizeIssues returns the issues as "line:column: rule message" strings.
+func summarizeIssues(issues []Issue) []string {
+	result := []string{}
+
+	for _, issue := range issues {
+		result = append(result, fmt.Sprintf("%d:%d: %s %s", issue.Line, issue.Column, issue.RuleID, issue.Message))
+	}
+
+	return result
+}
+
+func TestValidator_Validate_suppression(t *testing.T) {
+	t.Parallel()
+
+	for index, test := range []struct {
+		input  string
+		expect []string
+	}{
+		{
+			// ignore-next-line suppresses the next line only
+			input: heredoc.Doc(`
+				# hostpital:ignore-next-line HP003
+				0.0.0.0 foo_bar.example.com
+				0.0.0.0 foo_bar.example.net
+			`),
+			expect: []string{"3:9: HP003 label \"foo_bar\": underscore outside service label"},
+		},
+		{
+			// ignore without rules suppresses all the rules of the line
+			input:  "  0.0.0.0 foo_bar.example.com # hostpital:ignore\n",
+			expect: []string{},
+		},
+		{
+			// ignore of other rules does not suppress
+			input: "0.0.0.0 foo_bar.example.com # hostpital:ignore indent\n",
+			expect: []string{
+				"1:9: HP003 label \"foo_bar\": underscore outside service label",
+				`1:29: HP090 unused suppression "ignore" of HP002`,
+			},
+		},
+		{
+			// disable and enable by names and IDs separated by commas
+			input: heredoc.Doc(`
+				# hostpital:disable ip-only,HP003
+				127.0.0.1
+				0.0.0.0 foo_bar.example.com
+				# hostpital:enable HP005
+				127.0.0.2
+				# hostpital:enable underscore
+				0.0.0.0 foo_bar.example.net
+			`),
+			expect: []string{
+				"5:1: HP005 IP address only line is not allowed",
+				"7:9: HP003 label \"foo_bar\": underscore outside service label",
+			},
+		},
+		{
+			// enable with rules ends only the blocks of the rules
+			input: heredoc.Doc(`
+				# hostpital:disable HP005
+				# hostpital:disable HP003
+				0.0.0.0 foo_bar.example.com
+				# hostpital:enable HP003
+				127.0.0.1
+			`),
+			expect: []string{},
+		},
+		{
+			// disable all until the end of input
+			input: heredoc.Doc(`
+				# hostpital:disable
+				127.0.0.1
+				  0.0.0.0 example.com
+			`),
+			expect: []string{},
+		},
+		{
+			// unused directives
+			input: heredoc.Doc(`
+				# hostpital:ignore-next-line
+				# hostpital:ignore-next-line HP003
+				0.0.0.0 example.com
+				# hostpital:ignore
+				# hostpital:disable HP005
+				# hostpital:enable HP005
+				# hostpital:enable HP003
+				# hostpital:enable
+				# hostpital:disable
+				# hostpital:disable underscore
+				# hostpital:ignore-next-line
+			`),
+			expect: []string{
+				`1:1: HP090 unused suppression "ignore-next-line"`,
+				`2:1: HP090 unused suppression "ignore-next-line" of HP003`,
+				`4:1: HP090 unused suppression "ignore"`,
+				`5:1: HP090 unused suppression "disable" of HP005`,
+				"7:1: HP090 HP003 is not disabled to enable",
+				"8:1: HP090 no disabled rules to enable",
+				`11:1: HP090 unused suppression "ignore-next-line"`,
+				`9:1: HP090 unused suppression "disable"`,
+				`10:1: HP090 unused suppression "disable" of HP003`,
+			},
+		},
+		{
+			// enable without rules ends all the blocks
+			input: heredoc.Doc(`
+				# hostpital:disable HP005
+				127.0.0.1
+				# hostpital:disable HP003
+				# hostpital:enable
+				127.0.0.2
+			`),
+			expect: []string{
+				`3:1: HP090 unused suppression "disable" of HP003`,
+				"5:1: HP005 IP address only line is not allowed",
+			},
+		},
+		{
+			// malformed directives
+			input: heredoc.Doc(`
+				# hostpital:unknown
+				# hostpital:ignore-next-line HP999
+				0.0.0.0 example.com # hostpital:disable
+				# hostpital:ignore-next-line HP003 HP999
+				0.0.0.0 foo_bar.example.com
+			`),
+			expect: []string{
+				`1:1: HP090 unknown suppression directive "unknown"`,
+				`2:1: HP090 unknown rule "HP999" in suppression`,
+				`3:21: HP090 "disable" must be on its own line`,
+				`4:1: HP090 unknown rule "HP999" in suppression`,
+			},
+		},
+	} {
+		validator := NewValidator()
+		report := validator.ValidateString(test.input)
+
+		require.NoError(t, report.Err, "test #%d failed", index)
+		assert.Equal(t, test.expect, summarizeIssues(report.Issues), "test #%d failed", index)
+	}
+}
+
+func TestValidator_Validate_suppression_off(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.SetSeverity(RuleUnusedSuppression, SeverityOff))
+
+	report := validator.ValidateString("# hostpital:ignore-next-line\n0.0.0.0 example.com # hostpital:ignore\n")
+
+	assert.Empty(t, report.Issues, "unused suppressions should not be reported if the rule is off")
+}
+
+func TestValidator_Validate_suppression_severity(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.SetSeverity("unused-suppression", SeverityError))
+
+	report := validator.ValidateString("0.0.0.0 example.com # hostpital:ignore\n")
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, SeverityError, report.Issues[0].Severity)
+	assert.Equal(t, "0.0.0.0 example.com # hostpital:ignore", report.Issues[0].Snippet)
+	assert.False(t, report.OK())
+}
+
+func TestValidator_Issues_suppression_break(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	count := 0
+
+	for range validator.Issues(strings.NewReader("# hostpital:disable HP003\n# hostpital:disable HP005\n")) {
+		count++
+
+		break
+	}
+
+	assert.Equal(t, 1, count, "it should stop yielding the unused suppressions at the end of input")
+}
+
+func TestValidator_ValidateLine_suppression(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	require.NoError(t, validator.ValidateLine("0.0.0.0 foo_bar.example.com # hostpital:ignore HP003"))
+	require.NoError(t, validator.ValidateLine("# hostpital:disable"),
+		"directive lines should be valid even if comments are not allowed")
+
+	err := validator.ValidateLine("0.0.0.0 foo_bar.example.com # hostpital:ignore HP010")
+
+	require.Error(t, err, "other rules should not be suppressed")
+	assert.Contains(t, err.Error(), "underscore outside service label")
+}
@@ -0,0 +1,43 @@
+package hostpital
+
+import "github.com/pkg/errors"
+
+// toIDNA2008 converts the given host name to ASCII/punycode and returns an
+// error if the result is not IDNA2008 compatible or breaks the constraints of
+// LabelValidator. This is the same check the Parser applies to each host name
+// when IDNACompatible is true. IP addresses are returned as is.
+//
+// If profile is not nil, the host name is converted and checked by the profile
+// instead and LabelValidator is relaxed as the profile.
+func toIDNA2008(profile *IDNAProfile, hostName string) (string, error) {
+	convert, isCompatible, labelValidator := TransformToASCII, IsCompatibleIDNA2008, NewLabelValidator()
+
+	if profile != nil {
+		convert = profile.ToASCII
+		isCompatible = func(hostASCII string) bool {
+			converted, err := profile.ToASCII(hostASCII)
+
+			return err == nil && converted == hostASCII
+		}
+		labelValidator = profile.labelValidator()
+	}
+
+	hostASCII, err := convert(hostName)
+	if err != nil {
+		return "", errors.Wrapf(err, "%#v is not IDNA2008 compatible", hostName)
+	}
+
+	if !isCompatible(hostASCII) {
+		return "", errors.Errorf("%#v is not IDNA2008 compatible", hostName)
+	}
+
+	if IsIPAddress(hostASCII) {
+		return hostASCII, nil
+	}
+
+	if labelErrs := labelValidator.Validate(hostASCII); len(labelErrs) > 0 {
+		return "", errors.Wrapf(labelErrs[0], "%#v is not IDNA2008 compatible", hostName)
+	}
+
+	return hostASCII, nil
+}
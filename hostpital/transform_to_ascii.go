@@ -13,3 +13,9 @@ func TransformToASCII(hostName string) (string, error) {
 
 	return hostASCII, errors.Wrap(err, "failed to convert host name to ASCII")
 }
+
+// TransformToASCIIWith is like TransformToASCII but converts according to the
+// given profile. Such as NewIDNAProfile(IDNARegistration).
+func TransformToASCIIWith(profile IDNAProfile, hostName string) (string, error) {
+	return profile.ToASCII(hostName)
+}
@@ -14,3 +14,9 @@ func TransformToUnicode(hostASCII string) (string, error) {
 
 	return hostPunycode, errors.Wrap(err, "failed to convert host name to punycode")
 }
+
+// TransformToUnicodeWith is like TransformToUnicode but converts according to
+// the given profile. Such as NewIDNAProfile(IDNADisplay).
+func TransformToUnicodeWith(profile IDNAProfile, hostASCII string) (string, error) {
+	return profile.ToUnicode(hostASCII)
+}
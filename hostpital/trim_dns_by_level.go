@@ -9,6 +9,10 @@ import "strings"
 //	TrimDNSByLevel("www.example.com", 0) returns "com". Such as the top level domain.
 //	TrimDNSByLevel("www.example.com", 1) returns "example.com". Such as the second level domain.
 //	TrimDNSByLevel("www.example.com", 5) returns "www.example.com". As is.
+//
+// Note that the labels are counted naively. For example, "a.b.example.co.uk"
+// with the level 1 returns "co.uk". Use RegistrableDomain to count the public
+// suffixes such as "co.uk" as the top level.
 func TrimDNSByLevel(host string, level int) string {
 	parsed := strings.Split(host, ".")
 	if len(parsed) <= level {
@@ -1,16 +1,16 @@
 package hostpital
 
 import (
-	"bufio"
-	"fmt"
-	"log"
-	"os"
+	"context"
+	"io"
+	"io/fs"
+	"iter"
 	"path/filepath"
 	"strings"
 	"sync"
+	"unicode"
 
 	"github.com/pkg/errors"
-	"golang.org/x/net/idna"
 )
 
 // ----------------------------------------------------------------------------
@@ -20,20 +20,35 @@ import (
 // Validator holds the settings and the rules for the validation. To clean up
 // the hostfile, use the methods in the Parser type instead.
 //
+// The checks are the rules listed by Rules. The Allow* fields, IDNACompatible
+// and Mode are kept for compatibility and map onto the built-in rules. See the
+// Rule* constants for the mapping.
+//
+// Note that RulePublicSuffix is a warning. So the public suffixes such as
+// "co.uk" are reported by Issues but do not fail Validate and ValidateLine as
+// the former versions.
+//
 // It is recommended to use NewValidator() to create a new Validator due to the
 // default values.
 type Validator struct {
 	mutx               sync.Mutex
 	AllowComment       bool // If true, the line can be a comment (default: false).
 	AllowEmptyLine     bool // If true, empty line returns true (default: true).
-	AllowHyphen        bool // If true, the label can begin with hyphen (default: false).
-	AllowHyphenDouble  bool // If true, unconvertable punycode with double hyphen is allowed (default: false).
+	AllowHyphen        bool // If true, the label can begin and end with hyphen (default: false).
+	AllowHyphenDouble  bool // If true, unconvertable punycode with double hyphen is allowed. Such as "ab--c" and "xn--invalid" (default: false).
 	AllowIndent        bool // If true, the line can be indented (default: false).
 	AllowIPAddressOnly bool // If true, the line can be only an IP address (default: false).
 	AllowTrailingSpace bool // If true, the line can have trailing spaces (default: false).
-	AllowUnderscore    bool // If true, the label can have underscore (default: false).
+	AllowUnderscore    bool // If true, the label can have underscore. Otherwise only the leading service labels such as "_dmarc" can (default: false).
 	IDNACompatible     bool // If true, the host must be compatible to IDNA2008 and false to RFC 6125 2.2 (default: true).
+	Mode               Mode // Semantics of the lines. Such as ModeHostsFile (default: ModeDomainList).
 	isInitialized      bool
+	Conflicts          *ConflictAnalyzer   // If set, the entries are recorded to report the conflicts. See RuleConflict (default: nil).
+	IDNA               *IDNAProfile        // If set and IDNACompatible is true, the host names are validated by the profile (default: nil).
+	PublicSuffixes     *PublicSuffixList   // List of the public suffixes for RulePublicSuffix. The embedded snapshot is used if nil (default: nil).
+	Sinkhole           *SinkholePolicy     // If set, the host names must point to the addresses allowed. See RuleSinkhole (default: nil).
+	rules              []Rule              // Custom rules added by AddRule.
+	severities         map[string]Severity // Severities of the rules by ID set by SetSeverity or AddRule.
 }
 
 // ----------------------------------------------------------------------------
@@ -53,95 +68,257 @@ func NewValidator() *Validator {
 //  Methods
 // ----------------------------------------------------------------------------
 
-// ValidateFile returns true if the file is valid according to the settings.
-func (v *Validator) ValidateFile(pathFile string) bool {
+// AddRule adds a custom rule with the severity. The issues of the rule are
+// reported after the built-in rules. It errors if the ID or the name of the
+// rule is already in use.
+//
+// It is safe to call concurrently with the other methods.
+func (v *Validator) AddRule(rule Rule, severity Severity) error {
+	if rule == nil {
+		return errors.New("the given rule is nil")
+	}
+
+	if err := checkSeverity(rule.ID(), severity); err != nil {
+		return err
+	}
+
 	v.mutx.Lock()
 	defer v.mutx.Unlock()
 
-	pathFile = filepath.Clean(pathFile)
-
-	osFile, err := os.Open(pathFile)
-	if err != nil {
-		return false
+	for _, registered := range v.registeredRules() {
+		if registered.ID() == rule.ID() || registered.Name() == rule.Name() {
+			return errors.Errorf("rule %#v (%s) is already registered", rule.ID(), rule.Name())
+		}
 	}
 
-	defer func() {
-		_ = osFile.Close()
-	}()
+	v.rules = append(v.rules, rule)
+	v.setSeverity(rule.ID(), severity)
+
+	return nil
+}
 
-	if !IsExistingFile(pathFile) {
-		return false
+// Fix reads the lines from input, applies the safe fixes and writes the fixed
+// lines to output. The fixes are:
+//
+//   - Strips the indents and trailing spaces and collapses the white spaces.
+//   - Converts the host names to lowercase ASCII/punycode without the trailing
+//     dot.
+//   - Removes the comments unless allowed. The suppression directives are kept.
+//   - Drops the host names and addresses that have issues of SeverityError.
+//   - Removes the lines left with no host names, the IP address only lines,
+//     the lines violating the Sinkhole policy and the empty lines if they are
+//     not allowed.
+//
+// The report holds the Fixes applied and the Issues left in the fixed lines
+// with the line numbers of the input. The error is of reading or writing and
+// is set to Report.Err as well.
+func (v *Validator) Fix(input io.Reader, output io.Writer) (Report, error) {
+	if output == nil {
+		err := errors.New("the given io.Writer is nil")
+
+		return Report{Issues: []Issue{}, Err: err}, err
 	}
 
-	scanner := bufio.NewScanner(osFile)
+	v.mutx.Lock()
+	defer v.mutx.Unlock()
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	report := Report{Issues: []Issue{}, Fixes: []Fix{}}
+	reader := newLineReader(input, DefaultMaxLineLength, LongLineFail)
+	original := newSuppressor(v)   // Suppressor of the input lines.
+	normalized := newSuppressor(v) // Suppressor of the normalized lines.
+	remaining := newSuppressor(v)  // Suppressor of the fixed lines.
+	allowComment := v.AllowComment || v.Mode == ModeHostsFile
+
+	for reader.Scan() {
+		number, line := reader.Line(), reader.Text()
+		issues := original.lineIssues(number, line)
+		fixed := normalizeLine(line, allowComment, v.IDNA)
+		keep := fixed != "" || strings.TrimSpace(line) == ""
+
+		if keep {
+			fixed, keep = dropInvalidFields(fixed, normalized.lineIssues(number, fixed))
+		}
 
-		err := v.ValidateLine(line)
-		if err != nil {
-			log.Println("invalid line:", line, err)
+		left := []lineIssue{}
+
+		if keep {
+			left = remaining.lineIssues(number, fixed)
+
+			if _, err := writeLines(output, fixed+string(LF)); err != nil {
+				report.Err = err
+
+				break
+			}
+		}
+
+		for _, issue := range left {
+			report.Issues = append(report.Issues, issue.Issue)
+		}
 
-			return false
+		if fixed != line || !keep {
+			report.Fixes = append(report.Fixes, Fix{
+				Before:  line,
+				After:   fixed,
+				RuleIDs: fixedRuleIDs(issues, left),
+				Line:    number,
+				Removed: !keep,
+			})
 		}
 	}
 
-	err = scanner.Err()
-	if err != nil {
-		log.Println("failed to scan file:", err)
+	report.LinesRead = reader.Line()
 
-		return false
+	if report.Err != nil {
+		return report, report.Err
 	}
 
-	return true
-}
+	if err := reader.Err(); err != nil {
+		report.Err = errors.Wrap(err, "failed to read from reader")
 
-// ValidateLine returns nil if the line is valid according to the settings.
-//
-//nolint:cyclop // cyclomatic complexity 12 is acceptable here
-func (v *Validator) ValidateLine(line string) error {
-	trimmed, err := v.trimLine(line)
-	if err != nil {
-		return errors.Wrap(err, "failed to trim line")
+		return report, report.Err
 	}
 
-	if trimmed == "" {
-		return nil
+	for _, issue := range remaining.finish() {
+		report.Issues = append(report.Issues, issue.Issue)
 	}
 
-	if v.AllowComment {
-		if IsCommentLine(trimmed) {
-			return nil
-		}
+	return report, nil
+}
+
+// Issues returns an iterator over the issues of the lines read from input
+// according to the settings. The lines are validated as they are read, so
+// breaking the loop stops reading the input. The methods of the Validator can
+// be called in the loop.
+//
+// If reading fails, an Issue of the failure is yielded last.
+func (v *Validator) Issues(input io.Reader) iter.Seq[Issue] {
+	return func(yield func(Issue) bool) {
+		reader := newLineReader(input, DefaultMaxLineLength, LongLineFail)
 
-		noComment, err := TrimComment(trimmed)
+		err := v.scanIssues(context.Background(), "", reader, yield)
 		if err != nil {
-			return errors.Wrap(err, "failed to trim comment")
+			yield(Issue{
+				Line:    reader.Line() + 1,
+				Column:  1,
+				Message: err.Error(),
+			})
 		}
+	}
+}
 
-		if trimmed != noComment {
-			return v.ValidateLine(noComment)
-		}
+// Rules returns a copy of the registered rules. The built-in rules come first
+// in the order of the checks followed by the custom rules added by AddRule.
+func (v *Validator) Rules() []Rule {
+	v.mutx.Lock()
+	defer v.mutx.Unlock()
+
+	return v.registeredRules()
+}
+
+// SetSeverity sets the severity of the rule. The rule can be specified by ID
+// or name. Such as "HP001" or "trailing-space". Use SeverityOff to disable it.
+//
+// Note that the Allow* fields and IDNACompatible take precedence over it.
+func (v *Validator) SetSeverity(rule string, severity Severity) error {
+	if err := checkSeverity(rule, severity); err != nil {
+		return err
 	}
 
-	if !v.AllowIPAddressOnly && IsIPAddress(trimmed) {
-		return errors.New("IP address only line is not allowed")
+	v.mutx.Lock()
+	defer v.mutx.Unlock()
+
+	ruleID, ok := v.ruleID(rule)
+	if !ok {
+		return errors.Errorf("unknown rule %#v", rule)
 	}
 
-	if v.AllowUnderscore {
-		trimmed = strings.ReplaceAll(trimmed, "_", "-")
+	v.setSeverity(ruleID, severity)
+
+	return nil
+}
+
+// Severity returns the severity of the rule in effect. The rule can be
+// specified by ID or name. It returns SeverityOff for unknown rules and rules
+// disabled by the Allow* fields, IDNACompatible or Mode.
+func (v *Validator) Severity(rule string) Severity {
+	v.mutx.Lock()
+	defer v.mutx.Unlock()
+
+	ruleID, ok := v.ruleID(rule)
+	if !ok {
+		return SeverityOff
 	}
 
-	for chunk := range strings.SplitSeq(TrimWordGaps(trimmed), " ") {
-		err := v.validateChunk(chunk)
-		if err != nil {
-			return errors.Wrap(err, "failed to validate chunk/part of line")
+	return v.severityByID(ruleID)
+}
+
+// Validate validates all the lines read from input according to the settings
+// and returns the report of all the issues found. Unlike ValidateLine, it does
+// not stop at the first invalid line.
+func (v *Validator) Validate(input io.Reader) Report {
+	return v.validate(context.Background(), "", input)
+}
+
+// ValidateFile returns true if the file is valid according to the settings.
+// It is a wrapper of ValidateFileReport. Use it to know the reasons.
+//
+// The issues can be suppressed by the directives in the comments below. The
+// rules are specified by ID or name and all the rules are suppressed if none
+// is given. The directives are honored regardless of AllowComment.
+//
+//	# hostpital:ignore-next-line HP003   ... suppresses the next line.
+//	0.0.0.0 foo_bar # hostpital:ignore   ... suppresses the line.
+//	# hostpital:disable ip-only          ... suppresses until "enable".
+//	# hostpital:enable ip-only           ... ends "disable". All if no rules.
+//
+// The directives that suppressed nothing are reported as RuleUnusedSuppression
+// issues, which are warnings by default.
+func (v *Validator) ValidateFile(pathFile string) bool {
+	return v.ValidateFileReport(pathFile).OK()
+}
+
+// ValidateFileContext returns nil if the file is valid according to the
+// settings. Otherwise the error tells the reason, such as the first invalid
+// line or the failure to read the file.
+//
+// It stops promptly when ctx is done and returns ctx.Err() wrapped with the
+// number of lines processed.
+func (v *Validator) ValidateFileContext(ctx context.Context, pathFile string) error {
+	return v.validateFS(ctx, osFS{}, filepath.Clean(pathFile)).asError()
+}
+
+// ValidateFileReport is like Validate but reads the file from pathFile. The
+// failure to open the file is reported as Report.Err.
+func (v *Validator) ValidateFileReport(pathFile string) Report {
+	return v.validateFS(context.Background(), osFS{}, filepath.Clean(pathFile))
+}
+
+// ValidateFS is like ValidateFile but reads the named file from the given file
+// system. Such as embed.FS, zip.Reader and fstest.MapFS.
+func (v *Validator) ValidateFS(fsys fs.FS, name string) bool {
+	return v.validateFS(context.Background(), fsys, name).OK()
+}
+
+// ValidateLine returns nil if the line is valid according to the settings. The
+// error is of the first issue found in the line with SeverityError.
+//
+// The "# hostpital:ignore" directive at the end of the line is honored. See
+// ValidateFile for the directives.
+func (v *Validator) ValidateLine(line string) error {
+	for _, issue := range newSuppressor(v).lineIssues(1, line) {
+		if issue.Severity == SeverityError {
+			return issue.err
 		}
 	}
 
 	return nil
 }
 
+// ValidateString is like Validate but validates the given string.
+func (v *Validator) ValidateString(input string) Report {
+	return v.Validate(strings.NewReader(input))
+}
+
 // initialize sets the default values.
 func (v *Validator) initialize() {
 	// Set default values
@@ -150,58 +327,282 @@ func (v *Validator) initialize() {
 	v.isInitialized = true
 }
 
-func (v *Validator) trimLine(line string) (string, error) {
-	trimmed := strings.TrimLeft(line, " \t")
+// isAllowed returns true if the built-in rule is disabled by the Allow* fields,
+// IDNACompatible or Mode.
+func (v *Validator) isAllowed(ruleID string) bool {
+	switch ruleID {
+	case RuleTrailingSpace:
+		return v.AllowTrailingSpace
+	case RuleIndent:
+		return v.AllowIndent
+	case RuleUnderscore:
+		return v.AllowUnderscore || !v.IDNACompatible || (v.IDNA != nil && !v.IDNA.STD3Rules)
+	case RuleLabelHyphen:
+		return v.AllowHyphen || (v.IDNACompatible && v.IDNA != nil && !v.IDNA.CheckHyphens)
+	case RuleReservedLDH:
+		return v.AllowHyphenDouble || (v.IDNACompatible && v.IDNA != nil && !v.IDNA.CheckHyphens)
+	case RuleEmptyLine:
+		return v.AllowEmptyLine
+	case RuleIPAddressOnly:
+		return v.AllowIPAddressOnly || v.Mode == ModeHostsFile
+	case RuleMissingAddress, RuleAddressWithoutHostname, RuleHostnameInAddressColumn,
+		RuleMalformedAddress, RuleAddressInHostnameColumn:
+		return v.Mode != ModeHostsFile
+	case RuleSinkhole:
+		return v.Sinkhole == nil
+	case RuleConflict:
+		return v.Conflicts == nil
+	case RuleUppercase, RuleTrailingDot:
+		return !v.IDNACompatible || (v.IDNA != nil && v.IDNA.Base != IDNARegistration)
+	case RuleRFC6125:
+		return v.IDNACompatible
+	case RuleIDNA2008:
+		return !v.IDNACompatible
+	}
+
+	return false
+}
 
-	if !v.AllowIndent && trimmed != line {
-		return "", errors.New("indent is not allowed")
+// lineIssues returns all the issues found in the line by the rules in the order
+// of Rules. The line number of the issues is not set.
+func (v *Validator) lineIssues(line string) []lineIssue {
+	info := newLineInfo(line, v.AllowComment || v.Mode == ModeHostsFile)
+	issues := []lineIssue{}
+
+	add := func(ruleID string, found []lineIssue) {
+		severity := v.severityByID(ruleID)
+
+		for _, issue := range found {
+			issue.RuleID = ruleID
+			issue.Snippet = line
+			issue.Severity = severity
+			issues = append(issues, issue)
+		}
 	}
 
-	trimmed = strings.TrimRight(line, " \t")
+	for _, rule := range builtinRules {
+		if v.severityByID(rule.id) != SeverityOff {
+			add(rule.id, rule.check(v, info))
+		}
+	}
 
-	if !v.AllowTrailingSpace && trimmed != line {
-		return "", errors.New("trailing space is not allowed")
+	for _, rule := range v.rules {
+		if v.severityByID(rule.ID()) == SeverityOff {
+			continue
+		}
+
+		found := []lineIssue{}
+		for _, issue := range rule.Check(info) {
+			found = append(found, newLineIssue(issue.Column, issue.Message, nil))
+		}
+
+		add(rule.ID(), found)
+	}
+
+	return issues
+}
+
+// publicSuffixes returns the PublicSuffixes or the embedded snapshot if nil.
+func (v *Validator) publicSuffixes() *PublicSuffixList {
+	if v.PublicSuffixes == nil {
+		return defaultPublicSuffixes()
 	}
 
-	trimmed = strings.TrimSpace(line)
+	return v.PublicSuffixes
+}
+
+// registeredRules is the implementation of Rules. The lock must be held by the
+// caller.
+func (v *Validator) registeredRules() []Rule {
+	rules := make([]Rule, 0, len(builtinRules)+len(v.rules))
 
-	if trimmed == "" {
-		if !v.AllowEmptyLine {
-			return "", errors.New("empty line is not allowed")
+	for _, rule := range builtinRules {
+		rules = append(rules, boundRule{validator: v, builtinRule: rule})
+	}
+
+	return append(rules, v.rules...)
+}
+
+// ruleID returns the ID of the registered rule specified by ID or name. The
+// lock must be held by the caller.
+func (v *Validator) ruleID(rule string) (string, bool) {
+	for _, registered := range v.registeredRules() {
+		if registered.ID() == rule || registered.Name() == rule {
+			return registered.ID(), true
 		}
 	}
 
-	return trimmed, nil
+	return "", false
 }
 
-// validateChunk returns nil if the given chunk/part of line is valid according
-// to the settings.
+// scanIssues validates the lines read by reader and yields the issues found.
+// The source locates the entries recorded to Conflicts. It returns the error of
+// reading or the cancellation of ctx. It returns nil if yield returns false.
 //
-// This method validates RFC 6125 2.2 and IDNA2008 compatibility.
-func (v *Validator) validateChunk(chunk string) error {
-	_, err := TransformToUnicode(chunk)
-	if err != nil && v.AllowHyphenDouble {
-		chunk = strings.ReplaceAll(chunk, "--", "aa")
+// The lock is held while validating each line but not while yielding, so yield
+// can call the methods of the Validator.
+func (v *Validator) scanIssues(ctx context.Context, source string, reader *lineReader, yield func(Issue) bool) error {
+	suppressor := newSuppressor(v)
+	suppressor.conflicts = v.Conflicts
+	suppressor.source = source
+
+	yieldAll := func(issues func() []lineIssue) bool {
+		v.mutx.Lock()
+		found := issues()
+		v.mutx.Unlock()
+
+		for _, issue := range found {
+			if !yield(issue.Issue) {
+				return false
+			}
+		}
+
+		return true
 	}
 
-	if strings.Contains(chunk, ".-") && v.AllowHyphen {
-		chunk = strings.ReplaceAll(chunk, ".-", ".a")
+	for reader.Scan() {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "validation canceled after %d lines processed", reader.Line()-1)
+		}
+
+		if !yieldAll(func() []lineIssue { return suppressor.lineIssues(reader.Line(), reader.Text()) }) {
+			return nil
+		}
 	}
 
-	// RFC 6125 2.2 compatible
-	if !v.IDNACompatible && !IsCompatibleRFC6125(chunk) {
-		return errors.Errorf("%#v is not RFC 6125 2.2 compatible", chunk)
+	if err := reader.Err(); err != nil {
+		return errors.Wrap(err, "failed to read from reader")
 	}
 
-	// IDNA2008 compatible
-	if v.IDNACompatible && !IsCompatibleIDNA2008(chunk) {
-		_, err := idna.Registration.ToASCII(chunk)
-		if err != nil {
-			msgErr := fmt.Sprintf("%#v is not IDNA2008 compatible", chunk)
+	yieldAll(suppressor.finish)
+
+	return nil
+}
+
+// setSeverity is the implementation of SetSeverity which takes the ID of a
+// registered rule. The lock must be held by the caller.
+func (v *Validator) setSeverity(ruleID string, severity Severity) {
+	if v.severities == nil {
+		v.severities = make(map[string]Severity)
+	}
+
+	v.severities[ruleID] = severity
+}
+
+// severityByID is like Severity but takes the ID of a registered rule. The
+// lock must be held by the caller.
+func (v *Validator) severityByID(ruleID string) Severity {
+	if v.isAllowed(ruleID) {
+		return SeverityOff
+	}
+
+	if severity, ok := v.severities[ruleID]; ok {
+		return severity
+	}
+
+	severity := SeverityError
 
-			return errors.Wrap(err, msgErr)
+	for _, rule := range builtinRules {
+		if rule.id == ruleID {
+			severity = rule.severity
 		}
 	}
 
+	return severity
+}
+
+// validate validates the lines read from input of the source and returns the
+// report. It stops when ctx is done.
+func (v *Validator) validate(ctx context.Context, source string, input io.Reader) Report {
+	report := Report{Issues: []Issue{}}
+	reader := newLineReader(input, DefaultMaxLineLength, LongLineFail)
+
+	report.Err = v.scanIssues(ctx, source, reader, func(issue Issue) bool {
+		report.Issues = append(report.Issues, issue)
+
+		return true
+	})
+	report.LinesRead = reader.Line()
+
+	return report
+}
+
+// validateFS validates the named file in fsys and returns the report. It stops
+// when ctx is done.
+func (v *Validator) validateFS(ctx context.Context, fsys fs.FS, name string) Report {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Report{Source: name, Issues: []Issue{}, Err: errors.Wrap(err, "failed to open the file")}
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		return Report{Source: name, Issues: []Issue{}, Err: errors.Errorf("%#v is not a file", name)}
+	}
+
+	report := v.validate(ctx, name, file)
+	report.Source = name
+
+	return report
+}
+
+// ----------------------------------------------------------------------------
+//  Type: lineIssue
+// ----------------------------------------------------------------------------
+
+// lineIssue is an Issue with the error to be returned by ValidateLine. The
+// error keeps the messages of the former versions.
+type lineIssue struct {
+	err error
+	Issue
+}
+
+// ----------------------------------------------------------------------------
+//  Functions (Private)
+// ----------------------------------------------------------------------------
+
+// checkSeverity returns an error if the severity to set to the rule is out of
+// range.
+func checkSeverity(rule string, severity Severity) error {
+	if severity < SeverityError || severity > SeverityOff {
+		return errors.Errorf("invalid severity %d for rule %#v", severity, rule)
+	}
+
 	return nil
 }
+
+// ----------------------------------------------------------------------------
+//  Type: lineField
+// ----------------------------------------------------------------------------
+
+// lineField is a field of a line separated by white spaces.
+type lineField struct {
+	text  string
+	index int // Byte offset of the field in the line.
+}
+
+// splitFields splits the line by white spaces like strings.Fields but keeps the
+// byte offsets of the fields.
+func splitFields(line string) []lineField {
+	fields := []lineField{}
+	begin := -1
+
+	for index, char := range line {
+		switch {
+		case unicode.IsSpace(char) && begin >= 0:
+			fields = append(fields, lineField{text: line[begin:index], index: begin})
+			begin = -1
+		case !unicode.IsSpace(char) && begin < 0:
+			begin = index
+		}
+	}
+
+	if begin >= 0 {
+		fields = append(fields, lineField{text: line[begin:], index: begin})
+	}
+
+	return fields
+}
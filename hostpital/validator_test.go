@@ -1,15 +1,220 @@
 package hostpital
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/MakeNowJust/heredoc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// ----------------------------------------------------------------------------
+//  Issues
+// ----------------------------------------------------------------------------
+
+func TestValidator_Issues(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := "example.com\n  indented.example.com\nexample.net\n127.0.0.1\n"
+
+	issues := slices.Collect(validator.Issues(strings.NewReader(input)))
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, 2, issues[0].Line)
+	assert.Equal(t, "  indented.example.com", issues[0].Snippet)
+	assert.Contains(t, issues[0].Message, "indent is not allowed")
+	assert.Equal(t, 4, issues[1].Line)
+	assert.Equal(t, "IP address only line is not allowed", issues[1].Message)
+}
+
+func TestValidator_Issues_break(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := strings.Repeat(" indented.example.com\n", 10)
+	count := 0
+
+	for issue := range validator.Issues(strings.NewReader(input)) {
+		require.Equal(t, 1, issue.Line)
+
+		count++
+
+		break
+	}
+
+	require.Equal(t, 1, count, "it should stop on break")
+}
+
+func TestValidator_Issues_call_validator_in_loop(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := " indented.example.com\n127.0.0.1\n"
+	done := make(chan []string)
+
+	go func() {
+		ruleIDs := []string{}
+
+		for issue := range validator.Issues(strings.NewReader(input)) {
+			ruleIDs = append(ruleIDs, issue.RuleID)
+
+			// Both lock the validator.
+			_ = validator.ValidateString(issue.Snippet)
+			_ = validator.SetSeverity(RuleIPAddressOnly, SeverityWarning)
+		}
+
+		done <- ruleIDs
+	}()
+
+	select {
+	case ruleIDs := <-done:
+		assert.Equal(t, []string{RuleIndent, RuleIPAddressOnly}, ruleIDs)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "it should not hold the lock while yielding")
+	}
+}
+
+func TestValidator_Issues_read_error(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	input := "example.com\n" + strings.Repeat("a", DefaultMaxLineLength+1) + "\n"
+
+	issues := slices.Collect(validator.Issues(strings.NewReader(input)))
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, 2, issues[0].Line, "it should point the line failed to read")
+	assert.Contains(t, issues[0].Message, "failed to read from reader")
+	assert.Contains(t, issues[0].Message, "line 2 is longer than the max line length")
+	assert.Empty(t, issues[0].Snippet)
+}
+
+// ----------------------------------------------------------------------------
+//  Validate
+// ----------------------------------------------------------------------------
+
+func TestValidator_Validate_all_issues(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowComment = true
+
+	input := heredoc.Doc(`
+		0.0.0.0 example.com
+		  0.0.0.0 indented.example.com
+		0.0.0.0 example.com  ` + `
+		0.0.0.0 foo_bar.example.com # comment
+		127.0.0.1
+		# comment line
+		0.0.0.0 göpher.example.com bad..example.com
+	`)
+
+	report := validator.Validate(strings.NewReader(input))
+
+	require.NoError(t, report.Err)
+	require.False(t, report.OK())
+	assert.Equal(t, 7, report.LinesRead)
+
+	type summary struct {
+		RuleID string
+		Line   int
+		Column int
+	}
+
+	actual := []summary{}
+	for _, issue := range report.Issues {
+		actual = append(actual, summary{RuleID: issue.RuleID, Line: issue.Line, Column: issue.Column})
+
+		assert.Equal(t, SeverityError, issue.Severity)
+		assert.Equal(t, strings.Split(input, "\n")[issue.Line-1], issue.Snippet)
+	}
+
+	assert.Equal(t, []summary{
+		{RuleID: "HP002", Line: 2, Column: 1},
+		{RuleID: "HP001", Line: 3, Column: 20},
+		{RuleID: "HP003", Line: 4, Column: 9},
+		{RuleID: "HP005", Line: 5, Column: 1},
+		{RuleID: "HP016", Line: 7, Column: 33},
+	}, actual, "it should report all the issues and keep going after the first one")
+}
+
+func TestValidator_Validate_rfc6125(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.IDNACompatible = false
+
+	report := validator.ValidateString("0.0.0.0 exämple.com")
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, "HP009", report.Issues[0].RuleID)
+	assert.Equal(t, 9, report.Issues[0].Column)
+}
+
+func TestValidator_Validate_line_break(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.AllowComment = true
+
+	issues := validator.lineIssues("example.com\n# comment")
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "HP006", issues[0].RuleID)
+	assert.Equal(t, "line break found", issues[0].Message)
+}
+
+func TestValidator_ValidateString_ok(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	report := validator.ValidateString("example.com\n\nexample.net\n")
+
+	require.True(t, report.OK())
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 3, report.LinesRead)
+}
+
+// ----------------------------------------------------------------------------
+//  ValidateFileReport
+// ----------------------------------------------------------------------------
+
+func TestValidator_ValidateFileReport(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte(" example.com\nexample.net \n"), 0o600))
+
+	validator := NewValidator()
+
+	report := validator.ValidateFileReport(pathFile)
+
+	require.NoError(t, report.Err)
+	assert.Equal(t, pathFile, report.Source)
+	assert.Len(t, report.Issues, 2, "it should not stop at the first invalid line")
+}
+
+func TestValidator_ValidateFileReport_not_exist(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	report := validator.ValidateFileReport(filepath.Join(t.TempDir(), "not_exist"))
+
+	require.Error(t, report.Err)
+	assert.False(t, report.OK())
+	assert.Contains(t, report.Err.Error(), "failed to open the file")
+}
+
 // ----------------------------------------------------------------------------
 //  ValidateFile
 // ----------------------------------------------------------------------------
@@ -66,6 +271,73 @@ func TestValidator_ValidateFile_scanner_error(t *testing.T) {
 	require.False(t, ok, "it should return false if scanner reports an error")
 }
 
+// ----------------------------------------------------------------------------
+//  ValidateFileContext
+// ----------------------------------------------------------------------------
+
+func TestValidator_ValidateFileContext_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	validator := NewValidator()
+
+	err := validator.ValidateFileContext(ctx, filepath.Join("testdata", "default.txt"))
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.Canceled, "it should wrap the context error")
+	assert.Contains(t, err.Error(), "validation canceled after 0 lines processed",
+		"it should contain the progress")
+}
+
+func TestValidator_ValidateFileContext_invalid_line(t *testing.T) {
+	t.Parallel()
+
+	pathFile := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(pathFile, []byte("example.com\n  indented.example.com\n"), 0o600))
+
+	validator := NewValidator()
+
+	err := validator.ValidateFileContext(context.Background(), pathFile)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid line 2: "  indented.example.com"`,
+		"it should contain the line number and the line")
+	assert.Contains(t, err.Error(), "indent is not allowed", "it should contain the reason")
+}
+
+func TestValidator_ValidateFileContext_path_is_dir(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+
+	err := validator.ValidateFileContext(context.Background(), t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a file")
+}
+
+// ----------------------------------------------------------------------------
+//  ValidateFS
+// ----------------------------------------------------------------------------
+
+func TestValidator_ValidateFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"valid/hosts":   {Data: []byte("example.com\nexample.net\n")},
+		"invalid/hosts": {Data: []byte("example.com\n  indented.example.com\n")},
+	}
+
+	validator := NewValidator()
+
+	assert.True(t, validator.ValidateFS(fsys, "valid/hosts"))
+	assert.False(t, validator.ValidateFS(fsys, "invalid/hosts"), "it should be false if a line is invalid")
+	assert.False(t, validator.ValidateFS(fsys, "valid"), "it should be false if the name is a directory")
+	assert.False(t, validator.ValidateFS(fsys, "not_exist"), "it should be false if the file does not exist")
+}
+
 // ----------------------------------------------------------------------------
 //  ValidateLine
 // ----------------------------------------------------------------------------
@@ -95,11 +367,32 @@ func TestValidator_ValidateLine_allow_underscore(t *testing.T) {
 	}
 }
 
+func TestValidator_ValidateLine_underscore_rfc6125(t *testing.T) {
+	t.Parallel()
+
+	validator := NewValidator()
+	validator.IDNACompatible = false // RFC 6125 2.2 leniently accepts underscores
+
+	require.False(t, validator.AllowUnderscore)
+	assert.Equal(t, SeverityOff, validator.Severity(RuleUnderscore),
+		"underscore rule should be off in RFC 6125 mode regardless of AllowUnderscore")
+
+	for _, line := range []string{
+		"_dmarc.example.com",
+		"0.0.0.0 _dmarc.example.com",
+		"0.0.0.0 _sip._tcp.example.com",
+		"0.0.0.0 foo_bar.example.com",
+		"0.0.0.0 _a_.com",
+	} {
+		require.NoError(t, validator.ValidateLine(line), "line: %q", line)
+	}
+}
+
 func TestValidator_ValidateLine_allow_double_hyphen(t *testing.T) {
 	t.Parallel()
 
 	// Hostname that contains malformed punycode with double hyphen
-	const line = "127.0.0.0 123.--foo.bar.example.com"
+	const line = "127.0.0.0 123.ab--foo.bar.example.com"
 
 	validator := NewValidator()
 
@@ -111,7 +404,7 @@ func TestValidator_ValidateLine_allow_double_hyphen(t *testing.T) {
 
 		require.Error(t, err, "it should return an error if host begins with number")
 		require.Contains(t, err.Error(), "is not IDNA2008 compatible", "error should contain the reason")
-		require.Contains(t, err.Error(), "idna: invalid label", "error should contain the reason")
+		require.Contains(t, err.Error(), `label "ab--foo"`, "error should contain the reason")
 	}
 
 	// Allow host that begins with number
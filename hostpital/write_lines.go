@@ -0,0 +1,24 @@
+package hostpital
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// writeLines writes the given lines to the io.Writer as is. The lines must
+// contain their line breaks. It returns the number of bytes written.
+func writeLines(output io.Writer, lines ...string) (int64, error) {
+	var written int64
+
+	for _, line := range lines {
+		size, err := io.WriteString(output, line)
+		written += int64(size)
+
+		if err != nil {
+			return written, errors.Wrap(err, "failed to write to io.Writer")
+		}
+	}
+
+	return written, nil
+}